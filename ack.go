@@ -0,0 +1,154 @@
+package ssevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+const (
+	ackPath         = "/ack"
+	ackBufferSize   = 100
+	maxAckBatchSize = 100
+)
+
+// Ack is the body of POST /ack, acknowledging the events received by the connection with the subscriber ID
+type Ack struct {
+	SubscriberID string   `json:"subscriberId"`
+	EventIDs     []string `json:"eventIds"`
+}
+
+// newAckHandler passes the acknowledgements of the clients to Options OnAck, the callers are verified with
+// Options Authenticate like the SSE connections and acknowledge only the events of their own connections, at most
+// maxAckBatchSize at once
+func newAckHandler(sseCtrl *HttpController) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		claims, ok := sseCtrl.authenticate(w, req, sseCtrl.options.Authenticate)
+		if !ok {
+			return
+		}
+
+		var ack Ack
+		if !decodeBody(w, req, &ack) {
+			return
+		}
+		if ack.SubscriberID == "" {
+			respondError(w, errors.New("missing subscriberId"))
+			return
+		}
+		if len(ack.EventIDs) > maxAckBatchSize {
+			respondError(w, fmt.Errorf("too many eventIds, at most %d are acknowledged at once", maxAckBatchSize))
+			return
+		}
+		if _, status := sseCtrl.ownedSubscribers(req, claims, ack.SubscriberID); status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		sseCtrl.options.OnAck(ack)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ackPathFor is the path of the acknowledgement endpoint next to the SSE endpoint path, e.g. /api/ack for /api/sse,
+// the server mounts it there and the client posts to it
+func ackPathFor(ssePath string) string {
+	return path.Join(path.Dir(ssePath), ackPath)
+}
+
+// ackURL is the acknowledgement endpoint next to the SSE endpoint of the url, see ackPathFor
+func ackURL(sseURL string) (string, error) {
+	u, err := url.Parse(sseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing sse url: %w", err)
+	}
+	u.Path = ackPathFor(u.Path)
+	u.RawPath = ""
+	u.RawQuery = ""
+
+	return u.String(), nil
+}
+
+// queueAckLocked acknowledges the event once the server announced acknowledgements in its hello, infoMu must be held
+func (c *Client) queueAckLocked(e Event) {
+	if c.acks == nil || e.Id == "" || c.serverInfo == nil || !c.serverInfo.Ack {
+		return
+	}
+	select {
+	case c.acks <- pendingAck{subscriberID: c.serverInfo.SubscriberID, eventID: e.Id}:
+	default:
+		c.logger.Warn("sse ack queue full, dropping ack", "event_id", e.Id)
+	}
+}
+
+// pendingAck is an event to acknowledge, with the subscriber ID of the connection that received it
+type pendingAck struct {
+	subscriberID string
+	eventID      string
+}
+
+// runAcks sends the queued acknowledgements until ctx is done, the ones queued meanwhile for the same subscriber are
+// sent together
+func (c *Client) runAcks(ctx context.Context) {
+	var next *pendingAck
+	for {
+		if next == nil {
+			select {
+			case pending := <-c.acks:
+				next = &pending
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		ack := Ack{SubscriberID: next.subscriberID, EventIDs: []string{next.eventID}}
+		next = nil
+	collect:
+		for len(ack.EventIDs) < maxAckBatchSize {
+			select {
+			case pending := <-c.acks:
+				if pending.subscriberID != ack.SubscriberID {
+					next = &pending
+					break collect
+				}
+				ack.EventIDs = append(ack.EventIDs, pending.eventID)
+			default:
+				break collect
+			}
+		}
+
+		if err := c.sendAck(ctx, ack); err != nil && ctx.Err() == nil {
+			c.logger.Warn("failed sending sse ack", "events", len(ack.EventIDs), "err", err)
+		}
+	}
+}
+
+func (c *Client) sendAck(ctx context.Context, ack Ack) error {
+	body, err := json.Marshal(ack)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed creating ack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err = c.prepareRequest(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected ack response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
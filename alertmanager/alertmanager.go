@@ -0,0 +1,111 @@
+// Package alertmanager maps Prometheus Alertmanager webhook notifications into named SSE events, so dashboards can
+// subscribe to live alerts by pointing an Alertmanager webhook receiver to the server. The Options Handlers are mounted
+// when the server is created, so the handler emits through an ssevents.EmitterFunc bound to the server afterwards:
+//
+//	var server *ssevents.Server
+//	emitter := ssevents.EmitterFunc(func(e ssevents.Event) { server.Emit(e) })
+//	server, err := ssevents.NewServer(&ssevents.Options{Handlers: map[string]http.HandlerFunc{
+//		"POST /alertmanager": alertmanager.Handler(emitter),
+//	}})
+package alertmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	// EventFiring is the name of events emitted for alerts that started firing
+	EventFiring = "alert-firing"
+	// EventResolved is the name of events emitted for alerts that got resolved
+	EventResolved = "alert-resolved"
+
+	// MaxBodySize is the largest accepted notification
+	MaxBodySize = 1 << 20
+
+	statusResolved = "resolved"
+)
+
+// Payload is the body of an Alertmanager webhook notification, version 4.
+type Payload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert is a single alert of the notification, it is also the data of the emitted events.
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// ToEvents converts every alert of the notification into an EventFiring or EventResolved event with the alert JSON as
+// its data. The event id is made out of the alert fingerprint and status.
+func ToEvents(payload Payload) ([]ssevents.Event, error) {
+	events := make([]ssevents.Event, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		data, err := json.Marshal(alert)
+		if err != nil {
+			return nil, fmt.Errorf("failed encoding alert %s: %w", alert.Fingerprint, err)
+		}
+
+		evt := ssevents.Event{Event: EventFiring, Data: string(data)}
+		if alert.Status == statusResolved {
+			evt.Event = EventResolved
+		}
+		if alert.Fingerprint != "" {
+			evt.Id = alert.Fingerprint + "-" + alert.Status
+		}
+		events = append(events, evt)
+	}
+
+	return events, nil
+}
+
+// Handler receives Alertmanager webhook notifications and emits their alerts, mount it as a POST route on the server
+// and configure it as the url of an Alertmanager webhook_config. Notifications larger than MaxBodySize are rejected
+// with 413 Request Entity Too Large.
+func Handler(emitter ssevents.Emitter) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var payload Payload
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, MaxBodySize)).Decode(&payload); err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, "failed: "+err.Error(), status)
+			return
+		}
+		if len(payload.Alerts) == 0 {
+			http.Error(w, "failed: notification has no alerts", http.StatusBadRequest)
+			return
+		}
+
+		events, err := ToEvents(payload)
+		if err != nil {
+			http.Error(w, "failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, evt := range events {
+			emitter.Emit(evt)
+		}
+	}
+}
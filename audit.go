@@ -0,0 +1,73 @@
+package ssevents
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type actorCtxKey struct{}
+
+// AuditRecord describes a single emit, who did it and to how many subscribers the event was handed over
+type AuditRecord struct {
+	// Actor identifies who emitted the event, see WithActor and Options EmitActor, empty when unknown
+	Actor string
+	// Target is empty for events emitted to all subscribers, otherwise "user:<id>", "subscriber:<id>" or
+	// "topic:<name>"
+	Target    string
+	EventID   string
+	EventName string
+	Time      time.Time
+	Delivered int
+	Dropped   int
+}
+
+// Auditor receives a record of every emit, it is called synchronously so implementations should hand the record off
+// to their storage without blocking. Implementations must be safe for concurrent use.
+type Auditor interface {
+	Audit(ctx context.Context, record AuditRecord)
+}
+
+// AuditorFunc is an adapter allowing the use of ordinary functions as an Auditor.
+type AuditorFunc func(ctx context.Context, record AuditRecord)
+
+// Audit calls f(ctx, record).
+func (f AuditorFunc) Audit(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// WithActor attributes the emits done with the returned context, e.g. through EmitContext, to the actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, empty when there is none
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorCtxKey{}).(string)
+	return actor
+}
+
+// clientIP returns the IP address of the client, it is the default actor of the emit endpoint
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func (c *HttpController) audit(ctx context.Context, e Event, target string, outcome emitOutcome) {
+	if c.options.Auditor == nil {
+		return
+	}
+	c.options.Auditor.Audit(ctx, AuditRecord{
+		Actor:     ActorFromContext(ctx),
+		Target:    target,
+		EventID:   e.Id,
+		EventName: e.Event,
+		Time:      time.Now(),
+		Delivered: outcome.delivered,
+		Dropped:   outcome.dropped,
+	})
+}
@@ -0,0 +1,59 @@
+package ssevents
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrForbidden is returned by Options Authenticate and AuthenticateEmit, also wrapped, to reject an authenticated
+// caller that is not allowed access with 403 Forbidden instead of 401 Unauthorized.
+var ErrForbidden = errors.New("forbidden")
+
+// authenticate verifies the caller of the request with the function, responding with 401 or 403 when it fails
+func (c *HttpController) authenticate(
+	w http.ResponseWriter, req *http.Request, authenticate func(req *http.Request) (map[string]any, error),
+) (map[string]any, bool) {
+	if authenticate == nil {
+		return nil, true
+	}
+	claims, err := authenticate(req)
+	if err == nil {
+		return claims, true
+	}
+
+	c.log.Warn("request rejected", "path", req.URL.Path, "remote_addr", req.RemoteAddr, "err", err)
+	status := http.StatusUnauthorized
+	if errors.Is(err, ErrForbidden) {
+		status = http.StatusForbidden
+	}
+	http.Error(w, http.StatusText(status), status)
+
+	return nil, false
+}
+
+// ownedSubscribers returns the subscribers of the connections with the ID that belong to the caller of the request,
+// the user resolved from its claims like for the SSE connections. The status reports 404 Not Found when there is no
+// such connection and 403 Forbidden when all of them belong to other users.
+func (c *HttpController) ownedSubscribers(req *http.Request, claims map[string]any, id string) ([]*subscriber, int) {
+	userID := newConnInfo(req, c.options, claims).UserID
+	var owned []*subscriber
+	var forbidden bool
+	c.hub.subscribers.each(func(sub *subscriber) {
+		if sub.info.ID != id {
+			return
+		}
+		if sub.info.UserID != "" && sub.info.UserID != userID {
+			forbidden = true
+			return
+		}
+		owned = append(owned, sub)
+	})
+	switch {
+	case len(owned) > 0:
+		return owned, http.StatusOK
+	case forbidden:
+		return nil, http.StatusForbidden
+	default:
+		return nil, http.StatusNotFound
+	}
+}
@@ -0,0 +1,98 @@
+package ssevents
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	backoffInitialDelayDefault = 500 * time.Millisecond
+	backoffMaxDelayDefault     = 30 * time.Second
+	backoffMultiplierDefault   = 2
+	backoffJitterDefault       = 0.2
+	backoffMaxRetriesDefault   = 4
+)
+
+// BackoffUnlimitedRetries as the Backoff MaxRetries reconnects until the client is shut down
+const BackoffUnlimitedRetries = -1
+
+// BackoffNoJitter as the Backoff Jitter disables the randomization, as 0 falls back to the default
+const BackoffNoJitter = -1
+
+// Backoff is the policy of the delays between reconnection attempts of the client. The first delay is InitialDelay,
+// every following one is multiplied by Multiplier up to MaxDelay. Jitter randomizes each delay by up to the given
+// fraction, e.g. 0.2 for ±20%, so that the clients of a restarted server do not reconnect all at once.
+type Backoff struct {
+	// InitialDelay before the first reconnection attempt, default 500ms
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between the attempts, default 30s
+	MaxDelay time.Duration
+	// Multiplier grows the delay after every failed attempt, default 2
+	Multiplier float64
+	// Jitter is the fraction of the delay randomly added or subtracted, between 0 and 1, default 0.2,
+	// BackoffNoJitter, or any negative value, keeps the delays exact
+	Jitter float64
+	// MaxRetries is the number of consecutive failed attempts after which the client gives up with
+	// ErrToManyFailedReconnects, default 4, BackoffUnlimitedRetries never gives up. The count starts over once a
+	// connection is established, see ResetAfter.
+	MaxRetries int
+	// ResetAfter is how long a connection has to last for the count of failed attempts to start over, so a server
+	// accepting and dropping connections right away still exhausts the retries. Default is 0 which starts over on
+	// every established connection.
+	ResetAfter time.Duration
+	// KeepRetrying reports ErrToManyFailedReconnects on the errors channel once the retries are exhausted and keeps
+	// reconnecting, with delays growing up to MaxDelay, instead of shutting the client down, e.g. for long-lived
+	// daemons
+	KeepRetrying bool
+}
+
+func newUpdatedBackoff(backoff *Backoff) Backoff {
+	updated := Backoff{
+		InitialDelay: backoffInitialDelayDefault,
+		MaxDelay:     backoffMaxDelayDefault,
+		Multiplier:   backoffMultiplierDefault,
+		Jitter:       backoffJitterDefault,
+		MaxRetries:   backoffMaxRetriesDefault,
+	}
+	if backoff == nil {
+		return updated
+	}
+	if backoff.InitialDelay > 0 {
+		updated.InitialDelay = backoff.InitialDelay
+	}
+	if backoff.MaxDelay > 0 {
+		updated.MaxDelay = backoff.MaxDelay
+	}
+	if backoff.Multiplier >= 1 {
+		updated.Multiplier = backoff.Multiplier
+	}
+	if backoff.Jitter < 0 {
+		updated.Jitter = 0
+	} else if backoff.Jitter > 0 {
+		updated.Jitter = min(backoff.Jitter, 1)
+	}
+	if backoff.MaxRetries > 0 || backoff.MaxRetries == BackoffUnlimitedRetries {
+		updated.MaxRetries = backoff.MaxRetries
+	}
+	updated.ResetAfter = backoff.ResetAfter
+	updated.KeepRetrying = backoff.KeepRetrying
+
+	return updated
+}
+
+// exhausted reports whether the client gives up after the number of consecutive failed attempts
+func (b Backoff) exhausted(attempts int) bool {
+	return b.MaxRetries != BackoffUnlimitedRetries && attempts >= b.MaxRetries
+}
+
+// Delay returns the delay before the given reconnection attempt, starting from 0
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.InitialDelay) * math.Pow(b.Multiplier, float64(attempt))
+	delay = min(delay, float64(b.MaxDelay))
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
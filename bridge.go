@@ -0,0 +1,39 @@
+package ssevents
+
+import (
+	"context"
+	"errors"
+)
+
+// EventBridge relays the events emitted to all subscribers between the instances of a deployment through a broker, so
+// clients connected to any instance behind a load balancer receive them, see the redisbridge package.
+type EventBridge interface {
+	// Publish sends the event to all instances, including this one
+	Publish(ctx context.Context, e Event) error
+	// Subscribe passes the events published by any instance to emit, it should block until the ctx is cancelled
+	// returning nil or the context error when stopped.
+	Subscribe(ctx context.Context, emit func(e Event)) error
+}
+
+// runBridge emits the events received from the bridge to the local subscribers until the controller shuts down
+func (c *HttpController) runBridge() {
+	err := c.options.Bridge.Subscribe(c.shutdownCtx, func(e Event) {
+		c.emitLocal(e.Context(), e)
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		c.log.Error("event bridge stopped with an error", "err", err)
+	}
+}
+
+// publish hands the event to the bridge, it reports false when the event has to be emitted locally instead
+func (c *HttpController) publish(ctx context.Context, e Event) bool {
+	if c.options.Bridge == nil {
+		return false
+	}
+	if err := c.options.Bridge.Publish(ctx, e); err != nil {
+		c.log.Error("failed publishing event to the bridge, emitting locally", "err", err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,40 @@
+// Package brotlisse provides the brotli ssevents.Compressor, pass it first to prefer it over gzip for the clients
+// accepting both:
+//
+//	server, err := ssevents.NewServer(&ssevents.Options{
+//		EnableCompression: true,
+//		Compressors:       []ssevents.Compressor{brotlisse.Compressor{}, ssevents.GzipCompressor{}},
+//	})
+//
+// Browsers accept brotli only over HTTPS.
+package brotlisse
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/doppelganger113/ssevents"
+)
+
+// qualityDefault trades some of the compression ratio for the speed needed by frequently flushed streams
+const qualityDefault = 4
+
+// Compressor is the ssevents.Compressor of the br content coding
+type Compressor struct {
+	// Quality of the compression from 0 to 11, default is 4
+	Quality int
+}
+
+var _ ssevents.Compressor = Compressor{}
+
+func (Compressor) Encoding() string {
+	return "br"
+}
+
+func (c Compressor) NewWriter(w io.Writer) ssevents.CompressWriter {
+	quality := qualityDefault
+	if c.Quality > 0 {
+		quality = c.Quality
+	}
+	return brotli.NewWriterLevel(w, quality)
+}
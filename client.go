@@ -1,19 +1,27 @@
 package ssevents
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"mime"
 	"net/http"
 	"os"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
 var (
 	ErrToManyFailedReconnects = errors.New("closing client due to too many reconnection attempts")
+	ErrClientClosed           = errors.New("sse client closed before connecting")
+	// ErrConnectionIdle closes a connection that received nothing for ClientOptions IdleTimeout, the client reconnects
+	ErrConnectionIdle = errors.New("sse connection idle")
 )
 
 // Filter is a predicate like function for filtering out events consumed from the client if they should be sent
@@ -27,6 +35,72 @@ var FilterNoHeartbeat = func(e Event) bool {
 type ClientOptions struct {
 	DropSlowConsumerMsgs bool
 	Logger               *slog.Logger
+	// Sinks receive every consumed event before it is passed to the observers, note that with sinks the Events
+	// channel is consumed by the client.
+	Sinks []Sink
+	// Metrics receives measurements of the stream health, default discards them
+	Metrics ClientMetrics
+	// ExpvarName, when set, publishes the client counters under the name through expvar, NewSSEClient fails with
+	// ErrExpvarNameTaken when another variable is published under it
+	ExpvarName string
+	// RedactEvent rewrites events before they are logged, e.g. RedactEventData, default logs them verbatim. Event data
+	// is logged only at debug level.
+	RedactEvent func(e Event) Event
+	// HTTPClient sends the SSE requests, e.g. configured with a proxy, TLS settings or tracing, note that its Timeout
+	// bounds the whole stream so it should be 0. Default is a client with the default transport and no timeout.
+	HTTPClient *http.Client
+	// Transport of the default HTTP client, used only when HTTPClient is not set
+	Transport http.RoundTripper
+	// Method of the SSE request, e.g. POST for endpoints subscribing with a JSON body like GraphQL over SSE, default
+	// is GET
+	Method string
+	// Body is sent with the SSE request on every connection attempt, as application/json unless the Headers set
+	// another Content-Type
+	Body []byte
+	// BodyProvider creates the body of every connection attempt instead of Body, e.g. with the cursor to resume from
+	BodyProvider func(ctx context.Context) (io.Reader, error)
+	// MaxEventSize closes the connection with ErrEventTooLarge once an event of the stream exceeds the number of bytes,
+	// before it is read whole, and the client reconnects. Default is 0 which reads events of any size.
+	MaxEventSize int
+	// Decoders read the streams of other formats than text/event-stream, by the media type of the response
+	// Content-Type, e.g. MediaTypeJSONLines with NewJSONLinesDecoder. They are announced in the Accept header.
+	Decoders map[string]func(r io.Reader) StreamDecoder
+	// Headers are added to the SSE request, e.g. cookies or custom headers
+	Headers http.Header
+	// RequestModifier is called with the SSE request before every connection attempt, after the Headers are set, e.g.
+	// to attach a fresh bearer token
+	RequestModifier func(req *http.Request)
+	// TokenProvider is called before every connection attempt and acknowledgement, its token is sent as the Bearer
+	// token of the Authorization header, so clients of OAuth protected endpoints survive the expiry of their tokens
+	// across reconnects. Tokens should be cached by the provider until they expire. A failing provider fails the
+	// connection attempt.
+	TokenProvider func(ctx context.Context) (string, error)
+	// OnComment receives the comment lines of the stream, like the keep-alives of a server with HeartbeatComment,
+	// default skips them
+	OnComment func(comment string)
+	// Backoff is the policy of the delays between reconnection attempts, unset fields use the defaults of Backoff
+	Backoff *Backoff
+	// AutoAck acknowledges every received event with an ID to servers announcing acknowledgements in their hello, see
+	// the Options OnAck of the server. The Headers, TokenProvider and RequestModifier apply to the ack requests too.
+	AutoAck bool
+	// AckURL overrides the acknowledgement endpoint, default is /ack next to the SSE endpoint, e.g. /api/ack for
+	// /api/sse. Used only in conjunction with AutoAck.
+	AckURL string
+	// SubscriptionsURL overrides the endpoint of AddTopic and RemoveTopic, default is /subscriptions below the SSE
+	// endpoint, e.g. /api/sse/subscriptions for /api/sse
+	SubscriptionsURL string
+	// IdleTimeout closes the connection and reconnects once nothing, not even a heartbeat, was received for the
+	// duration, detecting half-open connections. It should exceed the heartbeat interval of the server and the time
+	// slow observers may block the delivery of an event, default is 0 which waits indefinitely.
+	IdleTimeout time.Duration
+	// OnConnect is called once a connection to the server is established
+	OnConnect func()
+	// OnDisconnect is called once an established connection is closed, with the error that closed it, nil when the
+	// server ended the stream or the client was shut down
+	OnDisconnect func(err error)
+	// OnReconnectAttempt is called before waiting for the delay of every reconnection attempt, starting from 1, e.g.
+	// for logging or refreshing the credentials used by the RequestModifier
+	OnReconnectAttempt func(attempt int, delay time.Duration)
 }
 
 type Client struct {
@@ -38,11 +112,44 @@ type Client struct {
 	closed               bool
 	firstConnEstablished bool
 	firstConnCh          chan struct{}
+	observersMu          sync.Mutex
 	observers            []*Observer
+	started              bool
+	fanoutRunning        bool
+	loopStarted          bool
+	sinks                []Sink
+	metrics              ClientMetrics
+	redactEvent          func(e Event) Event
+	backoff              Backoff
+	onComment            func(comment string)
+	headers              http.Header
+	requestModifier      func(req *http.Request)
+	tokenProvider        func(ctx context.Context) (string, error)
+	method               string
+	bodyProvider         func(ctx context.Context) (io.Reader, error)
+	decoders             map[string]func(r io.Reader) StreamDecoder
+	maxEventSize         int
+	accept               string
+	infoMu               sync.Mutex
+	serverInfo           *ServerInfo
+	lastEventID          string
+	retry                time.Duration
 	shutdownCtx          context.Context
 	shutdownFn           context.CancelFunc
 	eventCh              chan Event
 	errorCh              chan error
+	stateMu              sync.Mutex
+	state                ConnState
+	stateCh              chan ConnState
+	ackURL               string
+	acks                 chan pendingAck
+	subscriptionsURL     string
+	topics               []string
+	topicsChanged        bool
+	idleTimeout          time.Duration
+	onConnect            func()
+	onDisconnect         func(err error)
+	onReconnectAttempt   func(attempt int, delay time.Duration)
 }
 
 // NewSSEClient connects to an SSE server and sends events to a channel
@@ -55,6 +162,26 @@ func NewSSEClient(url string, options *ClientOptions) (*Client, error) {
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	var dropSlowConsumerMsgs bool
+	var sinks []Sink
+	var metrics ClientMetrics = noopClientMetrics{}
+	var redactEvent func(e Event) Event
+	var backoff *Backoff
+	var onComment func(comment string)
+	var headers http.Header
+	var requestModifier func(req *http.Request)
+	var tokenProvider func(ctx context.Context) (string, error)
+	method := http.MethodGet
+	var bodyProvider func(ctx context.Context) (io.Reader, error)
+	var decoders map[string]func(r io.Reader) StreamDecoder
+	var maxEventSize int
+	accept := mediaTypeEventStream
+	var ackEndpoint string
+	var acks chan pendingAck
+	var subscriptionsEndpoint string
+	var idleTimeout time.Duration
+	var onConnect func()
+	var onDisconnect func(err error)
+	var onReconnectAttempt func(attempt int, delay time.Duration)
 
 	if options != nil {
 		if options.Logger != nil {
@@ -63,6 +190,76 @@ func NewSSEClient(url string, options *ClientOptions) (*Client, error) {
 		if options.DropSlowConsumerMsgs {
 			dropSlowConsumerMsgs = true
 		}
+		sinks = options.Sinks
+		if options.HTTPClient != nil {
+			client = options.HTTPClient
+		} else if options.Transport != nil {
+			client.Transport = options.Transport
+		}
+		redactEvent = options.RedactEvent
+		backoff = options.Backoff
+		onComment = options.OnComment
+		headers = options.Headers.Clone()
+		requestModifier = options.RequestModifier
+		tokenProvider = options.TokenProvider
+		if options.Method != "" {
+			method = options.Method
+		}
+		bodyProvider = options.BodyProvider
+		maxEventSize = options.MaxEventSize
+		if len(options.Decoders) > 0 {
+			decoders = maps.Clone(options.Decoders)
+			mediaTypes := slices.Sorted(maps.Keys(decoders))
+			if _, ok := decoders[mediaTypeEventStream]; !ok {
+				mediaTypes = append([]string{mediaTypeEventStream}, mediaTypes...)
+			}
+			accept = strings.Join(mediaTypes, ", ")
+		}
+		if bodyProvider == nil && options.Body != nil {
+			body := slices.Clone(options.Body)
+			bodyProvider = func(context.Context) (io.Reader, error) {
+				return bytes.NewReader(body), nil
+			}
+		}
+		subscriptionsEndpoint = options.SubscriptionsURL
+		idleTimeout = options.IdleTimeout
+		onConnect = options.OnConnect
+		onDisconnect = options.OnDisconnect
+		onReconnectAttempt = options.OnReconnectAttempt
+		if options.Metrics != nil {
+			metrics = options.Metrics
+		}
+		if options.ExpvarName != "" {
+			expvarMetrics, err := newExpvarClientMetrics(options.ExpvarName)
+			if err != nil {
+				shutdownFn()
+				return nil, err
+			}
+			metrics = clientMetricsGroup{metrics, expvarMetrics}
+		}
+		if options.AutoAck {
+			ackEndpoint = options.AckURL
+			if ackEndpoint == "" {
+				var err error
+				if ackEndpoint, err = ackURL(url); err != nil {
+					shutdownFn()
+					return nil, err
+				}
+			}
+			acks = make(chan pendingAck, ackBufferSize)
+		}
+	}
+	if subscriptionsEndpoint == "" {
+		var err error
+		if subscriptionsEndpoint, err = subscriptionsURL(url); err != nil {
+			shutdownFn()
+			return nil, err
+		}
+	}
+	topics, err := topicsFromURL(url)
+	if err != nil {
+		shutdownFn()
+		return nil, err
 	}
 
 	return &Client{
@@ -70,11 +267,33 @@ func NewSSEClient(url string, options *ClientOptions) (*Client, error) {
 		logger:               logger,
 		client:               client,
 		url:                  url,
+		sinks:                sinks,
+		metrics:              metrics,
+		redactEvent:          redactEvent,
+		backoff:              newUpdatedBackoff(backoff),
+		onComment:            onComment,
+		headers:              headers,
+		requestModifier:      requestModifier,
+		tokenProvider:        tokenProvider,
+		method:               method,
+		bodyProvider:         bodyProvider,
+		decoders:             decoders,
+		maxEventSize:         maxEventSize,
+		accept:               accept,
 		shutdownCtx:          shutdownCtx,
 		shutdownFn:           shutdownFn,
 		firstConnCh:          make(chan struct{}, 1),
 		eventCh:              make(chan Event),
 		errorCh:              make(chan error),
+		stateCh:              make(chan ConnState, connStateBufferSize),
+		ackURL:               ackEndpoint,
+		acks:                 acks,
+		subscriptionsURL:     subscriptionsEndpoint,
+		topics:               topics,
+		idleTimeout:          idleTimeout,
+		onConnect:            onConnect,
+		onDisconnect:         onDisconnect,
+		onReconnectAttempt:   onReconnectAttempt,
 	}, nil
 }
 
@@ -127,13 +346,21 @@ func (c *Client) emitEventsWait(obs *Observer, evt Event) (isObserverDone, stop
 		defer cancel()
 	}
 
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if obs.closed {
+		return
+	}
 	select {
 	case obs.EventCh <- evt:
 		isObserverDone = c.isObserverDone(obs)
+	case <-obs.done:
+		return
 	case <-c.shutdownCtx.Done():
 		stop = true
 		return
 	case <-observerTimeoutCtx.Done():
+		c.metrics.ObserverDropped()
 		stop = true
 		return
 	}
@@ -142,6 +369,11 @@ func (c *Client) emitEventsWait(obs *Observer, evt Event) (isObserverDone, stop
 }
 
 func (c *Client) emitEventsOrDrop(obs *Observer, evt Event) (isObserverDone, stop bool, err error) {
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if obs.closed {
+		return
+	}
 	select {
 	case obs.EventCh <- evt:
 		isObserverDone = c.isObserverDone(obs)
@@ -150,180 +382,522 @@ func (c *Client) emitEventsOrDrop(obs *Observer, evt Event) (isObserverDone, sto
 		stop = true
 		return
 	default:
-		c.logger.Info("Dropping event due to slow Observer", "evt", evt)
+		c.logger.Info("sse event dropped due to slow observer", eventLogArgs(evt, c.redactEvent, false)...)
+		c.metrics.ObserverDropped()
 	}
 
 	return
 }
 
+// fanout passes the consumed events to the sinks and observers, it runs only once there is any of them so the Events
+// channel is left to the caller otherwise
 func (c *Client) fanout() {
-	if len(c.observers) == 0 {
-		return
-	}
 	for {
 		evt, ok := <-c.eventCh
 		if !ok {
 			return
 		}
+		if c.logger.Enabled(c.shutdownCtx, slog.LevelDebug) {
+			c.logger.Debug("sse event consumed", eventLogArgs(evt, c.redactEvent, true)...)
+		}
+		c.publishToSinks(evt)
 
-		// Not going to work fully
-		var obsForRemoval []*Observer
-
-		for i := 0; i < len(c.observers); i++ {
-			if c.observers[i] == nil {
+		for _, obs := range c.observersSnapshot() {
+			if !obs.hasSatisfiedFilters(evt) {
 				continue
 			}
-			if c.observers[i].hasSatisfiedFilters(evt) {
-				c.logger.Debug("Consumed", "evt", evt)
-				var stop bool
-				var err error
-				var isObserverDone bool
-
-				if c.dropSlowConsumerMsgs {
-					isObserverDone, stop, err = c.emitEventsOrDrop(c.observers[i], evt)
-				} else {
-					isObserverDone, stop, err = c.emitEventsWait(c.observers[i], evt)
-				}
-				if err != nil {
-					return
+			for _, out := range obs.transform([]Event{evt}) {
+				if obs.debounce > 0 {
+					c.debounceToObserver(obs, out)
+					continue
 				}
+				done, stop := c.emitToObserver(obs, out)
 				if stop {
 					return
 				}
-				if isObserverDone {
-					c.logger.Debug("removing completed observer", "obs", c.observers[i])
-					obsForRemoval = append(obsForRemoval, c.observers[i])
+				if done {
+					break
 				}
 			}
 		}
+	}
+}
 
-		if obsForRemoval != nil {
-			c.observers = slices.DeleteFunc(c.observers, func(o *Observer) bool {
-				if slices.Contains(obsForRemoval, o) {
-					close(o.EventCh)
-					return true
-				}
-				return false
-			})
-			obsForRemoval = nil
+// debounceToObserver replaces the pending event of the observer, delivering it once no other one arrived for the
+// debounce interval
+func (c *Client) debounceToObserver(obs *Observer, evt Event) {
+	obs.debounceMu.Lock()
+	defer obs.debounceMu.Unlock()
+	obs.debounced, obs.pending = evt, true
+	if obs.debounceTimer != nil {
+		obs.debounceTimer.Reset(obs.debounce)
+		return
+	}
+	obs.debounceTimer = time.AfterFunc(obs.debounce, func() {
+		obs.debounceMu.Lock()
+		debounced, pending := obs.debounced, obs.pending
+		obs.pending = false
+		obs.debounceMu.Unlock()
+		if pending {
+			c.emitToObserver(obs, debounced)
 		}
+	})
+}
+
+// emitToObserver passes the event to the observer unsubscribing it once completed, stop reports the fanout should end
+func (c *Client) emitToObserver(obs *Observer, evt Event) (done, stop bool) {
+	var err error
+	if c.dropSlowConsumerMsgs {
+		done, stop, err = c.emitEventsOrDrop(obs, evt)
+	} else {
+		done, stop, err = c.emitEventsWait(obs, evt)
+	}
+	if err != nil || stop {
+		return done, true
 	}
+	if done {
+		c.logger.Debug("sse observer completed")
+		c.Unsubscribe(obs)
+	}
+
+	return done, false
 }
 
-// Start - event subscriber is started and blocks until it gets its first message signaling the connection started
+// Start - event subscriber is started and blocks until it gets its first message signaling the connection started,
+// or the client is closed, like after too many failed connection attempts. See StartContext to bound the wait.
 func (c *Client) Start() {
+	_ = c.StartContext(context.Background())
+}
+
+// StartContext is Start bound to the context, the client is shut down once the context is done. It returns the
+// context error when the context is done before the first connection is established and ErrClientClosed when the
+// client is closed before that.
+func (c *Client) StartContext(ctx context.Context) error {
+	c.Lock()
+	if c.closed {
+		c.Unlock()
+		return ErrClientClosed
+	}
+	c.loopStarted = true
+	c.Unlock()
+	c.setState(ConnStateConnecting)
+
 	// run observers if any for fanout
-	go c.fanout()
+	c.observersMu.Lock()
+	c.started = true
+	if len(c.observers) > 0 || len(c.sinks) > 0 {
+		c.startFanoutLocked()
+	}
+	c.observersMu.Unlock()
 
 	go c.runReconnectionLoop(c.shutdownCtx)
+	if c.acks != nil {
+		go c.runAcks(c.shutdownCtx)
+	}
+	context.AfterFunc(ctx, c.Shutdown)
+
 	// wait for first connection
-	<-c.firstConnCh
+	select {
+	case <-c.firstConnCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.shutdownCtx.Done():
+		select {
+		case <-c.firstConnCh:
+			return nil
+		default:
+			return ErrClientClosed
+		}
+	}
 }
 
 // Shutdown stops the client and closes all the subscribers
 func (c *Client) Shutdown() {
-	c.logger.Info("client shutting down")
+	c.ShutdownWithReport()
+}
+
+// ShutdownWithReport is Shutdown reporting the observers that were closed and their unread events, calling it again
+// returns an empty report.
+func (c *Client) ShutdownWithReport() ClientShutdownReport {
+	started := time.Now()
+	var report ClientShutdownReport
 	c.Lock()
 	defer c.Unlock()
 	if !c.closed {
-		c.logger.Info("Not closed, closing...")
 		c.closed = true
 		c.shutdownFn()
-		close(c.eventCh)
+		// Once started the reconnection loop is the sender of the events and closes the channel when it returns
+		if !c.loopStarted {
+			close(c.eventCh)
+		}
 		close(c.errorCh)
-		c.logger.Info("closing observers")
-		for i := 0; i < len(c.observers); i++ {
-			if c.observers[i] != nil {
-				close(c.observers[i].EventCh)
+		c.setState(ConnStateClosed)
+		c.observersMu.Lock()
+		for _, obs := range c.observers {
+			unread := len(obs.EventCh)
+			if obs.close() {
+				report.ObserversClosed++
+				report.EventsDiscarded += unread
 			}
 		}
+		c.observers = nil
+		c.observersMu.Unlock()
+		report.Duration = time.Since(started)
+		c.logger.Info("sse client closed",
+			"url", c.url,
+			"observers", report.ObserversClosed,
+			"events_discarded", report.EventsDiscarded,
+		)
 	}
+
+	return report
 }
 
-func (c *Client) connectAndListen(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+// connectAndListen reads the events of a single connection, connected reports whether the server accepted it
+func (c *Client) connectAndListen(ctx context.Context) (connected bool, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var body io.Reader
+	if c.bodyProvider != nil {
+		if body, err = c.bodyProvider(ctx); err != nil {
+			return false, fmt.Errorf("failed creating request body: %w", err)
+		}
+	}
+	requestURL, err := c.requestURL()
 	if err != nil {
-		return fmt.Errorf("failed creating request: %w", err)
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, c.method, requestURL, body)
+	if err != nil {
+		return false, fmt.Errorf("failed creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Accept", c.accept)
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
+	if lastEventID := c.LastEventID(); lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if err = c.prepareRequest(req); err != nil {
+		return false, err
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to connect: %v", err)
+		return false, fmt.Errorf("failed to connect: %v", err)
 	}
 	defer func() {
 		err = errors.Join(err, resp.Body.Close())
 	}()
 
-	// Ensure the server response is SSE
-	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
-		return fmt.Errorf(
+	// Ensure the server response is SSE or a stream of the Decoders
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	newDecoder, ok := c.decoders[mediaType]
+	if resp.StatusCode != http.StatusOK || (!ok && mediaType != mediaTypeEventStream) {
+		return false, fmt.Errorf(
 			"invalid SSE response: status %d, content-type %s",
 			resp.StatusCode,
 			resp.Header.Get("Content-Type"),
 		)
 	}
 
+	c.infoMu.Lock()
+	c.serverInfo = nil
+	c.infoMu.Unlock()
+
+	c.metrics.Connected()
+	c.setState(ConnStateConnected)
+	connectedAt := time.Now()
+	c.logger.Info("sse client connected", "url", c.url)
+	var received int
+	defer func() {
+		c.metrics.Disconnected()
+		c.logger.Info("sse client disconnected",
+			"url", c.url,
+			"duration", time.Since(connectedAt),
+			"events_received", received,
+			"err", err,
+		)
+		if c.onDisconnect != nil {
+			c.onDisconnect(err)
+		}
+	}()
+	if c.onConnect != nil {
+		c.onConnect()
+	}
+
 	// Notify on first connection
 	if !c.firstConnEstablished {
+		c.firstConnEstablished = true
 		c.firstConnCh <- struct{}{}
 	}
 
-	return ReadEvents(ctx, resp.Body, c.eventCh)
+	var stream io.Reader = resp.Body
+	if c.idleTimeout > 0 {
+		idle := newIdleReader(resp.Body, c.idleTimeout, func() { cancel(ErrConnectionIdle) })
+		defer idle.stop()
+		stream = idle
+	}
+	var decoder StreamDecoder
+	var sseDecoder *Decoder
+	if newDecoder != nil {
+		decoder = newDecoder(countingReader{reader: stream, metrics: c.metrics})
+	} else {
+		sseDecoder = NewDecoder(stream)
+		sseDecoder.metrics = c.metrics
+		sseDecoder.OnComment = c.onComment
+		sseDecoder.MaxEventSize = c.maxEventSize
+		// The last event ID persists across connections, like the one of an EventSource
+		sseDecoder.lastEventID = c.LastEventID()
+		decoder = sseDecoder
+	}
+	received, err = readEvents(ctx, decoder, c.eventCh, c.metrics, c.intercept)
+	if sseDecoder != nil {
+		c.applyDecoderState(sseDecoder)
+	}
+	if cause := context.Cause(ctx); errors.Is(cause, ErrConnectionIdle) {
+		return true, cause
+	}
+	return true, err
+}
+
+// prepareRequest sets the Headers, the token of the TokenProvider and applies the RequestModifier
+func (c *Client) prepareRequest(req *http.Request) error {
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	if c.tokenProvider != nil {
+		token, err := c.tokenProvider(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed getting token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if c.requestModifier != nil {
+		c.requestModifier(req)
+	}
+
+	return nil
+}
+
+// intercept consumes the protocol events of the server, all others are passed on remembering their ID for resuming
+func (c *Client) intercept(e Event) bool {
+	if e.Retry > 0 {
+		c.infoMu.Lock()
+		c.retry = time.Duration(e.Retry) * time.Millisecond
+		c.infoMu.Unlock()
+	}
+	if e.Event == EventNameShutdown {
+		c.logger.Info("sse server shutting down", "url", c.url, "retry", e.Retry)
+	}
+	if e.Event != eventNameHello {
+		if e.Id != "" {
+			c.infoMu.Lock()
+			c.lastEventID = e.Id
+			c.queueAckLocked(e)
+			c.infoMu.Unlock()
+		}
+		return true
+	}
+	info, err := parseServerInfo(e)
+	if err != nil {
+		c.logger.Warn("sse client received invalid hello", "err", err)
+		return false
+	}
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	c.serverInfo = &info
+
+	return false
+}
+
+// applyDecoderState keeps the last event ID and retry of the stream, which include the ones of frames without data
+// that never reach intercept
+func (c *Client) applyDecoderState(decoder *Decoder) {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	c.lastEventID = decoder.LastEventID()
+	if retry := decoder.Retry(); retry > 0 {
+		c.retry = time.Duration(retry) * time.Millisecond
+	}
+}
+
+// LastEventID returns the ID of the latest received event, it is sent as the Last-Event-ID header on reconnect so a
+// server with replay enabled sends the missed events.
+func (c *Client) LastEventID() string {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	return c.lastEventID
+}
+
+// ServerInfo returns the capabilities announced by the server on the latest connection, false when the server did not
+// send them, see Options SendHello.
+func (c *Client) ServerInfo() (ServerInfo, bool) {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.serverInfo == nil {
+		return ServerInfo{}, false
+	}
+	return *c.serverInfo, true
 }
 
 func (c *Client) runReconnectionLoop(ctx context.Context) {
+	defer close(c.eventCh)
 	defer c.Shutdown()
 	var retryCounter int
-	var lastTimeConnected time.Time
 
 	for {
-		// If we haven't retried recently
-		if time.Since(lastTimeConnected) > 60*time.Second {
-			retryCounter = 0
-		}
-		lastTimeConnected = time.Now()
-
-		if err := c.connectAndListen(ctx); err != nil {
-			if !c.closed {
-				select {
-				case c.errorCh <- err:
-				default:
-					c.logger.Error("dropping error, channel full", "err", err)
-				}
-			}
+		connectedAt := time.Now()
+		connected, err := c.connectAndListen(ctx)
+		if err != nil {
+			c.sendError(err)
 		}
 		if ctx.Err() != nil {
 			return
 		}
+		// Only consecutive failures count towards the limit
+		if connected && time.Since(connectedAt) >= c.backoff.ResetAfter {
+			retryCounter = 0
+		}
 
-		if retryCounter > 3 {
-			select {
-			case c.errorCh <- ErrToManyFailedReconnects:
-			default:
-				c.logger.Error("dropping error, channel full", "err", ErrToManyFailedReconnects)
+		if c.backoff.exhausted(retryCounter) {
+			if !c.backoff.KeepRetrying {
+				c.sendError(ErrToManyFailedReconnects)
+				c.Shutdown()
+				return
+			}
+			// Reported once, the following attempts are delayed by MaxDelay until a connection is established
+			if retryCounter == c.backoff.MaxRetries {
+				c.sendError(ErrToManyFailedReconnects)
 			}
-			c.Shutdown()
-			return
 		}
 
-		c.logger.Info("reconnecting...")
-		time.Sleep(2 * time.Second)
+		c.setState(ConnStateReconnecting)
+		delay := c.reconnectBackoff().Delay(retryCounter)
+		c.logger.Info("sse client reconnecting", "url", c.url, "attempt", retryCounter+1, "delay", delay)
+		c.metrics.ReconnectAttempted()
+		if c.onReconnectAttempt != nil {
+			c.onReconnectAttempt(retryCounter+1, delay)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
 		retryCounter++
 	}
 }
 
-// Subscribe adds the observer which will then receive the copy of the event in a fanout manner
+// sendError passes the error to the errors channel unless the client is closed or nobody is ready to receive it
+func (c *Client) sendError(err error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.errorCh <- err:
+	default:
+		c.logger.Error("dropping error, channel full", "err", err)
+	}
+}
+
+// reconnectBackoff is the backoff policy starting at the latest retry interval sent by the server, if any
+func (c *Client) reconnectBackoff() Backoff {
+	backoff := c.backoff
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.retry > 0 {
+		backoff.InitialDelay = c.retry
+		backoff.MaxDelay = max(backoff.MaxDelay, c.retry)
+	}
+
+	return backoff
+}
+
+// startFanoutLocked starts the fanout unless it is running already, observersMu must be held
+func (c *Client) startFanoutLocked() {
+	if !c.fanoutRunning {
+		c.fanoutRunning = true
+		go c.fanout()
+	}
+}
+
+// Subscribe adds the observer which will then receive the copy of the event in a fanout manner, also after the client
+// is started. Observers built with a Context are unsubscribed once it is done.
 func (c *Client) Subscribe(o *Observer) *Observer {
 	if o == nil {
 		panic("unable to add nil Observer")
 	}
-	if c.observers == nil {
-		c.observers = make([]*Observer, 0)
+	c.observersMu.Lock()
+	defer c.observersMu.Unlock()
+	if o.done == nil {
+		o.done = make(chan struct{})
+	}
+	if o.ctx != nil {
+		o.stopCtx = context.AfterFunc(o.ctx, func() { c.Unsubscribe(o) })
 	}
 	c.observers = append(c.observers, o)
+	if c.started {
+		c.startFanoutLocked()
+	}
+
+	return o
+}
+
+// SubscribeFunc subscribes an observer of the events accepted by the filter, or all but heartbeats when it is nil,
+// and calls the handler for each of them on a goroutine of its own. A panicking handler is recovered and logged, it
+// keeps receiving the following events until the observer is unsubscribed or the client is shut down.
+func (c *Client) SubscribeFunc(filter Filter, handler func(e Event)) *Observer {
+	builder := NewObserverBuilder()
+	if filter != nil {
+		builder.Filter(filter)
+	}
+	o := c.Subscribe(builder.Build())
+	go func() {
+		for evt := range o.EventCh {
+			c.handleEvent(handler, evt)
+		}
+	}()
 
 	return o
 }
+
+// handleEvent calls the handler of SubscribeFunc recovering from its panic
+func (c *Client) handleEvent(handler func(e Event), evt Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			args := append(eventLogArgs(evt, c.redactEvent, false), "panic", r)
+			c.logger.Error("sse observer handler panicked", args...)
+		}
+	}()
+	handler(evt)
+}
+
+// Unsubscribe removes the observer and closes its channel, it returns false if the observer was not subscribed. It is
+// safe to call at any time, also while the observer is blocking the delivery of an event.
+func (c *Client) Unsubscribe(o *Observer) bool {
+	c.observersMu.Lock()
+	i := slices.Index(c.observers, o)
+	if i >= 0 {
+		c.observers = slices.Delete(c.observers, i, i+1)
+	}
+	c.observersMu.Unlock()
+	if i < 0 {
+		return false
+	}
+	o.close()
+
+	return true
+}
+
+// observersSnapshot returns a copy of the subscribed observers so they can be iterated without holding the lock
+func (c *Client) observersSnapshot() []*Observer {
+	c.observersMu.Lock()
+	defer c.observersMu.Unlock()
+
+	return slices.Clone(c.observers)
+}
@@ -0,0 +1,568 @@
+// Package cluster routes targeted emits, EmitToUser and EmitToSubscriber, between horizontally scaled server instances.
+//
+// Instances gossip their membership to each other and place every user and connection ID on a consistent hashing ring.
+// The instance owning a key on the ring keeps track of which instances hold connections for it, so a targeted event
+// is forwarded only to the instances that are able to deliver it instead of being broadcast to all of them.
+//
+//	node, err := cluster.NewNode(cluster.Options{
+//		ID: "node-a", AdvertiseURL: "http://10.0.0.1:3000", Seeds: seeds, Secret: os.Getenv("CLUSTER_SECRET"),
+//	})
+//	server, err := ssevents.NewServer(&ssevents.Options{
+//		Handlers:     node.Handlers(),
+//		OnConnect:    node.OnConnect,
+//		OnDisconnect: node.OnDisconnect,
+//		Sources:      []ssevents.Source{node},
+//	})
+//	node.Attach(server)
+//
+//	err = node.EmitToUser(ctx, "user-1", ssevents.Event{Data: "hello"})
+package cluster
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	gossipIntervalDefault = time.Second
+	virtualNodesDefault   = 64
+
+	kindUser       = "user"
+	kindSubscriber = "subscriber"
+)
+
+var (
+	ErrMissingAdvertiseURL = errors.New("cluster: AdvertiseURL is required")
+	ErrMissingSecret       = errors.New("cluster: Secret is required unless Insecure is set")
+	ErrNotAttached         = errors.New("cluster: node is not attached to a local server")
+)
+
+// LocalEmitter delivers targeted events to the connections of this instance, it is implemented by ssevents.Server
+// and ssevents.HttpController.
+type LocalEmitter interface {
+	EmitToUser(userID string, e ssevents.Event) int
+	EmitToSubscriber(id string, e ssevents.Event) bool
+}
+
+type Options struct {
+	// ID uniquely identifies the instance in the cluster, default is the hostname
+	ID string
+	// AdvertiseURL is the base url on which other instances reach this one, e.g. http://10.0.0.1:3000
+	AdvertiseURL string
+	// Seeds are advertise urls of instances used for joining the cluster
+	Seeds []string
+	// GossipInterval defines how often membership is exchanged with a random member, default is 1s
+	GossipInterval time.Duration
+	// MemberTimeout defines after how long without a heartbeat a member is removed, default is 5 gossip intervals
+	MemberTimeout time.Duration
+	// VirtualNodes is the number of points each instance takes on the hashing ring, default is 64
+	VirtualNodes int
+	// Secret is required from other instances on all cluster endpoints, it is mandatory unless Insecure is set
+	Secret string
+	// Insecure allows running without a Secret. The cluster endpoints then accept requests from anyone able to reach
+	// the server, who can register connections and deliver events to any user, so only use it when the endpoints are
+	// not exposed, e.g. in tests.
+	Insecure bool
+	// Client used for talking to other instances, default is http.Client with a 5s timeout
+	Client *http.Client
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+type member struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Heartbeat uint64 `json:"heartbeat"`
+	// updatedAt is the local time when the heartbeat last increased
+	updatedAt time.Time
+}
+
+// registration tells the owner of a key on the ring that a node holds connections for it
+type registration struct {
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+	Node   string `json:"node"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// Node is a single instance of the cluster.
+type Node struct {
+	mu             sync.Mutex
+	id             string
+	url            string
+	seeds          []string
+	gossipInterval time.Duration
+	memberTimeout  time.Duration
+	virtualNodes   int
+	secret         string
+	client         *http.Client
+	logger         *slog.Logger
+	local          LocalEmitter
+	members        map[string]*member
+	ring           *ring
+	// connections are held by this node by their subscriber ID, used to re-register them when ring ownership changes
+	connections map[string]*connection
+	// directory maps the keys owned by this node to the nodes holding connections for them and their count
+	directory map[string]map[string]int
+	// pending registrations of connections are sent by Run, so OnConnect and OnDisconnect do not wait on other nodes
+	pending []registration
+	// flush signals Run that pending registrations were queued
+	flush chan struct{}
+	// resyncs signals Run that the membership received through gossip changed, so resyncs do not run concurrently
+	resyncs chan struct{}
+}
+
+// connection counts the local connections sharing a subscriber ID, which is registered until the last one closes
+type connection struct {
+	info  ssevents.ConnInfo
+	count int
+}
+
+var _ ssevents.Source = (*Node)(nil)
+
+func NewNode(options Options) (*Node, error) {
+	if options.AdvertiseURL == "" {
+		return nil, ErrMissingAdvertiseURL
+	}
+	if options.Secret == "" && !options.Insecure {
+		return nil, ErrMissingSecret
+	}
+
+	n := &Node{
+		id:             options.ID,
+		url:            options.AdvertiseURL,
+		seeds:          options.Seeds,
+		gossipInterval: gossipIntervalDefault,
+		virtualNodes:   virtualNodesDefault,
+		secret:         options.Secret,
+		client:         &http.Client{Timeout: 5 * time.Second},
+		logger:         slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		members:        make(map[string]*member),
+		connections:    make(map[string]*connection),
+		directory:      make(map[string]map[string]int),
+		flush:          make(chan struct{}, 1),
+		resyncs:        make(chan struct{}, 1),
+	}
+	if n.id == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Join(errors.New("cluster: ID is required when hostname is unavailable"), err)
+		}
+		n.id = hostname
+	}
+	if options.GossipInterval > 0 {
+		n.gossipInterval = options.GossipInterval
+	}
+	n.memberTimeout = 5 * n.gossipInterval
+	if options.MemberTimeout > 0 {
+		n.memberTimeout = options.MemberTimeout
+	}
+	if options.VirtualNodes > 0 {
+		n.virtualNodes = options.VirtualNodes
+	}
+	if options.Client != nil {
+		n.client = options.Client
+	}
+	if options.Logger != nil {
+		n.logger = options.Logger
+	}
+
+	n.members[n.id] = &member{ID: n.id, URL: n.url, updatedAt: time.Now()}
+	n.ring = newRing(n.virtualNodes, []string{n.id})
+
+	return n, nil
+}
+
+// Attach sets the local server to which events routed to this instance are delivered.
+func (n *Node) Attach(local LocalEmitter) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.local = local
+}
+
+// ID returns the identifier of this instance.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Members returns the IDs of all currently known instances, including this one.
+func (n *Node) Members() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.memberIDs()
+}
+
+// Run gossips membership and sends the registrations of local connections until the ctx is cancelled, it is usually
+// started as one of the server Sources.
+func (n *Node) Run(ctx context.Context, _ func(e ssevents.Event)) error {
+	ticker := time.NewTicker(n.gossipInterval)
+	defer ticker.Stop()
+
+	n.gossip(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			n.gossip(ctx)
+		case <-n.flush:
+			n.flushRegistrations(ctx)
+		case <-n.resyncs:
+			n.resync(ctx)
+		}
+	}
+}
+
+// OnConnect registers a local connection in the cluster, pass it as ssevents.Options OnConnect. The registration is
+// queued and sent by Run, connections sharing a subscriber ID are registered once.
+func (n *Node) OnConnect(info ssevents.ConnInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if conn, ok := n.connections[info.ID]; ok {
+		conn.count++
+		return
+	}
+	n.connections[info.ID] = &connection{info: info, count: 1}
+	n.enqueue(info, false)
+}
+
+// OnDisconnect removes a local connection from the cluster, pass it as ssevents.Options OnDisconnect. The removal is
+// queued and sent by Run once the last connection of the subscriber ID closed.
+func (n *Node) OnDisconnect(info ssevents.ConnInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	conn, ok := n.connections[info.ID]
+	if !ok {
+		return
+	}
+	if conn.count--; conn.count > 0 {
+		return
+	}
+	delete(n.connections, info.ID)
+	n.enqueue(conn.info, true)
+}
+
+// EmitToUser delivers the event to all connections of the user regardless of the instance they are connected to.
+func (n *Node) EmitToUser(ctx context.Context, userID string, e ssevents.Event) error {
+	return n.route(ctx, kindUser, userID, e)
+}
+
+// EmitToSubscriber delivers the event to the connection with the given ID regardless of the instance holding it.
+func (n *Node) EmitToSubscriber(ctx context.Context, id string, e ssevents.Event) error {
+	return n.route(ctx, kindSubscriber, id, e)
+}
+
+// route forwards the event to the owner of the key which then delivers it to the nodes holding its connections
+func (n *Node) route(ctx context.Context, kind, key string, e ssevents.Event) error {
+	n.mu.Lock()
+	owner := n.ring.owner(directoryKey(kind, key))
+	ownerURL := n.memberURL(owner)
+	n.mu.Unlock()
+
+	if owner == n.id {
+		return n.deliverOwned(ctx, kind, key, e)
+	}
+
+	return n.post(ctx, ownerURL, pathRoute, delivery{Kind: kind, Key: key, Event: e}, nil)
+}
+
+// deliverOwned delivers the event for a key owned by this node to every node holding its connections
+func (n *Node) deliverOwned(ctx context.Context, kind, key string, e ssevents.Event) error {
+	n.mu.Lock()
+	var targets []string
+	for nodeID := range n.directory[directoryKey(kind, key)] {
+		if nodeID == n.id {
+			targets = append(targets, nodeID)
+		} else if url := n.memberURL(nodeID); url != "" {
+			targets = append(targets, url)
+		}
+	}
+	n.mu.Unlock()
+
+	var errs []error
+	for _, target := range targets {
+		if target == n.id {
+			errs = append(errs, n.deliverLocal(kind, key, e))
+			continue
+		}
+		errs = append(errs, n.post(ctx, target, pathDeliver, delivery{Kind: kind, Key: key, Event: e}, nil))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n *Node) deliverLocal(kind, key string, e ssevents.Event) error {
+	n.mu.Lock()
+	local := n.local
+	n.mu.Unlock()
+
+	if local == nil {
+		return ErrNotAttached
+	}
+	switch kind {
+	case kindUser:
+		local.EmitToUser(key, e)
+	case kindSubscriber:
+		local.EmitToSubscriber(key, e)
+	}
+
+	return nil
+}
+
+// enqueue queues the registrations of the connection for Run, the lock has to be held
+func (n *Node) enqueue(info ssevents.ConnInfo, remove bool) {
+	n.pending = append(n.pending, registration{Kind: kindSubscriber, Key: info.ID, Node: n.id, Delete: remove})
+	if info.UserID != "" {
+		n.pending = append(n.pending, registration{Kind: kindUser, Key: info.UserID, Node: n.id, Delete: remove})
+	}
+	select {
+	case n.flush <- struct{}{}:
+	default:
+	}
+}
+
+// flushRegistrations sends the queued registrations to the owners of their keys
+func (n *Node) flushRegistrations(ctx context.Context) {
+	n.mu.Lock()
+	registrations := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	if len(registrations) > 0 {
+		n.sendRegistrations(ctx, registrations, false)
+	}
+}
+
+// sendRegistrations groups the registrations by their owners and sends them in batches. When replacing, every member
+// receives its batch, even an empty one, and drops the previous registrations of this node.
+func (n *Node) sendRegistrations(ctx context.Context, registrations []registration, replace bool) {
+	batches := make(map[string][]registration)
+	n.mu.Lock()
+	if replace {
+		for id := range n.members {
+			batches[id] = []registration{}
+		}
+	}
+	for _, r := range registrations {
+		owner := n.ring.owner(directoryKey(r.Kind, r.Key))
+		batches[owner] = append(batches[owner], r)
+	}
+	n.mu.Unlock()
+
+	path := pathRegister
+	if replace {
+		path = pathSync
+	}
+	for owner, batch := range batches {
+		if owner == n.id {
+			if replace {
+				n.replaceRegistrations(n.id, batch)
+			} else {
+				n.applyRegistrations(batch)
+			}
+			continue
+		}
+		n.mu.Lock()
+		url := n.memberURL(owner)
+		n.mu.Unlock()
+		if err := n.post(ctx, url, path, batch, nil); err != nil {
+			n.logger.Error("failed sending cluster registrations", "owner", owner, "err", err)
+		}
+	}
+}
+
+func (n *Node) applyRegistrations(registrations []registration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, r := range registrations {
+		key := directoryKey(r.Kind, r.Key)
+		nodes := n.directory[key]
+		if r.Delete {
+			if nodes == nil {
+				continue
+			}
+			if nodes[r.Node]--; nodes[r.Node] <= 0 {
+				delete(nodes, r.Node)
+			}
+			if len(nodes) == 0 {
+				delete(n.directory, key)
+			}
+			continue
+		}
+		if nodes == nil {
+			nodes = make(map[string]int)
+			n.directory[key] = nodes
+		}
+		nodes[r.Node]++
+	}
+}
+
+// resync re-registers all local connections after ring ownership changed, the new owners rebuild their directory
+func (n *Node) resync(ctx context.Context) {
+	n.mu.Lock()
+	// Entries of nodes that left can no longer be delivered to
+	for key, nodes := range n.directory {
+		for nodeID := range nodes {
+			if _, ok := n.members[nodeID]; !ok {
+				delete(nodes, nodeID)
+			}
+		}
+		if len(nodes) == 0 {
+			delete(n.directory, key)
+		}
+	}
+	// The resync sends every local connection, so the queued registrations are already covered
+	n.pending = nil
+	var registrations []registration
+	for _, conn := range n.connections {
+		info := conn.info
+		registrations = append(registrations, registration{Kind: kindSubscriber, Key: info.ID, Node: n.id})
+		if info.UserID != "" {
+			registrations = append(registrations, registration{Kind: kindUser, Key: info.UserID, Node: n.id})
+		}
+	}
+	n.mu.Unlock()
+
+	n.sendRegistrations(ctx, registrations, true)
+}
+
+// replaceRegistrations drops all entries of the node and applies the new ones, making resyncs idempotent
+func (n *Node) replaceRegistrations(nodeID string, registrations []registration) {
+	n.mu.Lock()
+	for key, nodes := range n.directory {
+		delete(nodes, nodeID)
+		if len(nodes) == 0 {
+			delete(n.directory, key)
+		}
+	}
+	n.mu.Unlock()
+
+	n.applyRegistrations(registrations)
+}
+
+// gossip exchanges the member list with a random member, or with the seeds while no other member is known
+func (n *Node) gossip(ctx context.Context) {
+	n.mu.Lock()
+	self := n.members[n.id]
+	self.Heartbeat++
+	self.updatedAt = time.Now()
+
+	var targets []string
+	for id, m := range n.members {
+		if id != n.id {
+			targets = append(targets, m.URL)
+		}
+	}
+	if len(targets) == 0 {
+		targets = slices.DeleteFunc(slices.Clone(n.seeds), func(seed string) bool {
+			return seed == n.url
+		})
+	} else {
+		targets = []string{targets[rand.IntN(len(targets))]}
+	}
+	state := n.snapshot()
+	n.mu.Unlock()
+
+	changed := n.expireMembers()
+	for _, target := range targets {
+		var response []member
+		if err := n.post(ctx, target, pathGossip, state, &response); err != nil {
+			n.logger.Debug("cluster gossip failed", "target", target, "err", err)
+			continue
+		}
+		changed = n.merge(response) || changed
+	}
+
+	if changed {
+		n.resync(ctx)
+	}
+}
+
+// merge updates the known members with the received ones, returning true when the membership changed
+func (n *Node) merge(received []member) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var changed bool
+	now := time.Now()
+	for _, m := range received {
+		if m.ID == n.id || m.ID == "" {
+			continue
+		}
+		known, ok := n.members[m.ID]
+		if !ok {
+			n.members[m.ID] = &member{ID: m.ID, URL: m.URL, Heartbeat: m.Heartbeat, updatedAt: now}
+			changed = true
+			continue
+		}
+		if m.Heartbeat > known.Heartbeat {
+			known.Heartbeat = m.Heartbeat
+			known.URL = m.URL
+			known.updatedAt = now
+		}
+	}
+	if changed {
+		n.ring = newRing(n.virtualNodes, n.memberIDs())
+		n.logger.Info("cluster membership changed", "members", n.memberIDs())
+	}
+
+	return changed
+}
+
+// expireMembers removes members without a heartbeat for longer than the member timeout
+func (n *Node) expireMembers() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var changed bool
+	for id, m := range n.members {
+		if id != n.id && time.Since(m.updatedAt) > n.memberTimeout {
+			delete(n.members, id)
+			changed = true
+		}
+	}
+	if changed {
+		n.ring = newRing(n.virtualNodes, n.memberIDs())
+		n.logger.Info("cluster membership changed", "members", n.memberIDs())
+	}
+
+	return changed
+}
+
+func (n *Node) snapshot() []member {
+	members := make([]member, 0, len(n.members))
+	for _, m := range n.members {
+		members = append(members, *m)
+	}
+	return members
+}
+
+func (n *Node) memberIDs() []string {
+	ids := make([]string, 0, len(n.members))
+	for id := range n.members {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (n *Node) memberURL(id string) string {
+	if m, ok := n.members[id]; ok {
+		return m.URL
+	}
+	return ""
+}
+
+func directoryKey(kind, key string) string {
+	return kind + ":" + key
+}
@@ -0,0 +1,51 @@
+package cluster
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// ring is a consistent hashing ring with virtual nodes, it is not safe for concurrent use.
+type ring struct {
+	virtualNodes int
+	hashes       []uint32
+	owners       map[uint32]string
+}
+
+func newRing(virtualNodes int, members []string) *ring {
+	r := &ring{
+		virtualNodes: virtualNodes,
+		owners:       make(map[uint32]string, virtualNodes*len(members)),
+	}
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			h := hashKey(member + "#" + strconv.Itoa(i))
+			r.hashes = append(r.hashes, h)
+			r.owners[h] = member
+		}
+	}
+	slices.Sort(r.hashes)
+
+	return r
+}
+
+// owner returns the member responsible for the key, empty when the ring has no members.
+func (r *ring) owner(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := hashKey(key)
+	idx, _ := slices.BinarySearch(r.hashes, h)
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+
+	return r.owners[r.hashes[idx]]
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()
+}
@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	pathGossip   = "/cluster/gossip"
+	pathRegister = "/cluster/register"
+	pathSync     = "/cluster/sync"
+	pathRoute    = "/cluster/route"
+	pathDeliver  = "/cluster/deliver"
+
+	headerSecret = "X-Cluster-Secret"
+	headerNode   = "X-Cluster-Node"
+
+	// maxBodySize bounds the requests of other nodes, a sync carries the registrations of all connections of a node
+	maxBodySize = 8 << 20
+)
+
+// delivery is a targeted event travelling between nodes
+type delivery struct {
+	Kind  string         `json:"kind"`
+	Key   string         `json:"key"`
+	Event ssevents.Event `json:"event"`
+}
+
+// Handlers returns the cluster endpoints which need to be mounted on the server, e.g. through ssevents.Options
+// Handlers.
+func (n *Node) Handlers() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"POST " + pathGossip: n.guard(func(w http.ResponseWriter, req *http.Request) {
+			var received []member
+			if !decode(w, req, &received) {
+				return
+			}
+			if n.merge(received) {
+				select {
+				case n.resyncs <- struct{}{}:
+				default:
+				}
+			}
+
+			n.mu.Lock()
+			state := n.snapshot()
+			n.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(state)
+		}),
+		"POST " + pathRegister: n.guard(func(w http.ResponseWriter, req *http.Request) {
+			var registrations []registration
+			if !decode(w, req, &registrations) {
+				return
+			}
+			n.applyRegistrations(registrations)
+		}),
+		"POST " + pathSync: n.guard(func(w http.ResponseWriter, req *http.Request) {
+			var registrations []registration
+			if !decode(w, req, &registrations) {
+				return
+			}
+			n.replaceRegistrations(req.Header.Get(headerNode), registrations)
+		}),
+		"POST " + pathRoute: n.guard(func(w http.ResponseWriter, req *http.Request) {
+			var d delivery
+			if !decode(w, req, &d) {
+				return
+			}
+			if err := n.deliverOwned(req.Context(), d.Kind, d.Key, d.Event); err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+			}
+		}),
+		"POST " + pathDeliver: n.guard(func(w http.ResponseWriter, req *http.Request) {
+			var d delivery
+			if !decode(w, req, &d) {
+				return
+			}
+			if err := n.deliverLocal(d.Kind, d.Key, d.Event); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			}
+		}),
+	}
+}
+
+func (n *Node) guard(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if n.secret != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get(headerSecret)), []byte(n.secret)) != 1 {
+			http.Error(w, "invalid cluster secret", http.StatusForbidden)
+			return
+		}
+		handler(w, req)
+	}
+}
+
+func decode(w http.ResponseWriter, req *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodySize)).Decode(v); err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "failed: "+err.Error(), status)
+		return false
+	}
+	return true
+}
+
+// post sends the payload as JSON to another node, decoding the response into result when not nil
+func (n *Node) post(ctx context.Context, baseURL, path string, payload, result any) (err error) {
+	if baseURL == "" {
+		return errors.New("cluster: unknown member")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed encoding cluster request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed creating cluster request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerNode, n.id)
+	if n.secret != "" {
+		req.Header.Set(headerSecret, n.secret)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed cluster request to %s: %w", baseURL, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("cluster request to %s failed with status %d: %s", baseURL, resp.StatusCode, msg)
+	}
+	if result != nil {
+		if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed decoding cluster response: %w", err)
+		}
+	}
+
+	return nil
+}
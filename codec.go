@@ -0,0 +1,63 @@
+package ssevents
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Codec encodes the data of events on the server and decodes it on the client, see NewEncodedEvent and
+// SubscribeCodec. JSONCodec is built in, the protosse and msgpacksse packages provide binary ones.
+type Codec interface {
+	// Marshal encodes the value
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes the data into the value pointed to by v
+	Unmarshal(data []byte, v any) error
+	// Binary reports if the encoded data is not text, it is then sent base64 encoded, see Event.SetBinary
+	Binary() bool
+}
+
+// JSONCodec encodes the data as JSON, it is the Codec of EmitJSON and SubscribeJSON
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Binary() bool {
+	return false
+}
+
+// NewEncodedEvent creates an event with the given name and the value encoded by the codec as its data
+func NewEncodedEvent(codec Codec, event string, v any) (Event, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed marshalling data of event %q: %w", event, err)
+	}
+	e := Event{Event: event}
+	if codec.Binary() {
+		e.SetBinary(data)
+	} else {
+		e.Data = string(data)
+	}
+
+	return e, nil
+}
+
+// DecodeEvent decodes the data of the event encoded by the codec into the value pointed to by v
+func DecodeEvent(codec Codec, e Event, v any) error {
+	data, err := e.Binary()
+	if err == nil {
+		err = codec.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed decoding data of event %q with id %q: %w", e.Event, e.Id, err)
+	}
+
+	return nil
+}
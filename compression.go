@@ -0,0 +1,113 @@
+package ssevents
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Compressor compresses the SSE stream with a content coding negotiated through the Accept-Encoding header, see
+// Options EnableCompression and the brotlisse package.
+type Compressor interface {
+	// Encoding is the content coding sent as the Content-Encoding header, e.g. gzip
+	Encoding() string
+	// NewWriter compresses into w
+	NewWriter(w io.Writer) CompressWriter
+}
+
+// CompressWriter is the writer of a Compressor, Flush writes everything compressed so far so events are not held back
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// GzipCompressor is the Compressor of the gzip content coding
+type GzipCompressor struct {
+	// Level of the compression, default is gzip.DefaultCompression
+	Level int
+}
+
+func (GzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (g GzipCompressor) NewWriter(w io.Writer) CompressWriter {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	cw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		// An invalid level falls back to the default one
+		cw = gzip.NewWriter(w)
+	}
+	return cw
+}
+
+// negotiateCompressor returns the first compressor accepted by the Accept-Encoding header, nil when none is
+func negotiateCompressor(req *http.Request, compressors []Compressor) Compressor {
+	accepted := req.Header.Get("Accept-Encoding")
+	if accepted == "" {
+		return nil
+	}
+	for _, compressor := range compressors {
+		for _, coding := range strings.Split(accepted, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+			if !strings.EqualFold(strings.TrimSpace(name), compressor.Encoding()) {
+				continue
+			}
+			if !refused(params) {
+				return compressor
+			}
+		}
+	}
+
+	return nil
+}
+
+// refused reports whether the parameters of an accepted coding have a zero quality value, which refuses the coding
+func refused(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(name, "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(value, 64)
+		return err == nil && q == 0
+	}
+	return false
+}
+
+// compressedResponseWriter compresses everything written to the response, flushing the compressor before the response
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	cw CompressWriter
+}
+
+func newCompressedResponseWriter(w http.ResponseWriter, compressor Compressor) *compressedResponseWriter {
+	return &compressedResponseWriter{ResponseWriter: w, cw: compressor.NewWriter(w)}
+}
+
+func (w *compressedResponseWriter) Write(p []byte) (int, error) {
+	return w.cw.Write(p)
+}
+
+// FlushError is used by http.ResponseController
+func (w *compressedResponseWriter) FlushError() error {
+	if err := w.cw.Flush(); err != nil {
+		return err
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the other methods of the response
+func (w *compressedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close writes the end of the compressed stream
+func (w *compressedResponseWriter) Close() error {
+	return w.cw.Close()
+}
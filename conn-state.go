@@ -0,0 +1,59 @@
+package ssevents
+
+//go:generate stringer -type=ConnState
+
+// ConnState is the state of the connection of the Client to the server, see Client.States
+type ConnState int
+
+const (
+	// ConnStateIdle is the state before the client is started
+	ConnStateIdle ConnState = iota
+	// ConnStateConnecting is the state while establishing the first connection
+	ConnStateConnecting
+	// ConnStateConnected is the state while the server streams the events
+	ConnStateConnected
+	// ConnStateReconnecting is the state after the connection was lost, while waiting for and attempting to reconnect
+	ConnStateReconnecting
+	// ConnStateClosed is the final state once the client is shut down, also after too many failed reconnects
+	ConnStateClosed
+)
+
+const connStateBufferSize = 16
+
+// State returns the current state of the connection
+func (c *Client) State() ConnState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// States notifies every change of the connection state, e.g. for showing the status in a UI, and is closed after
+// ConnStateClosed. The channel keeps the latest changes when not read, the oldest are discarded.
+func (c *Client) States() <-chan ConnState {
+	return c.stateCh
+}
+
+// setState moves the connection to the state, the closed state is final
+func (c *Client) setState(state ConnState) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.state == state || c.state == ConnStateClosed {
+		return
+	}
+	c.state = state
+	c.logger.Debug("sse client state changed", "url", c.url, "state", state)
+
+	// The client is the only sender, so once the oldest change is discarded there is room for the new one
+	select {
+	case c.stateCh <- state:
+	default:
+		select {
+		case <-c.stateCh:
+		default:
+		}
+		c.stateCh <- state
+	}
+	if state == ConnStateClosed {
+		close(c.stateCh)
+	}
+}
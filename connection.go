@@ -0,0 +1,109 @@
+package ssevents
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+type connInfoCtxKey struct{}
+
+type disconnectCtxKey struct{}
+
+type subscribedCtxKey struct{}
+
+// ConnInfo describes a single SSE connection to the server.
+type ConnInfo struct {
+	// ID is a unique, stable identifier of the connection for its whole lifetime, or the one returned by Options
+	// SubscriberIDFunc. Clients learn it from the hello event, see Options SendHello.
+	ID string
+	// UserID identifies the user owning the connection, resolved through Options UserIDFunc, empty when not set
+	UserID string
+	// RemoteAddr is the network address of the client
+	RemoteAddr string
+	// Protocol of the connection, e.g. HTTP/1.1 or HTTP/2.0
+	Protocol string
+	// Header holds the headers of the SSE request, e.g. User-Agent or X-Forwarded-For
+	Header http.Header
+	// ConnectedAt is the time when the connection was established
+	ConnectedAt time.Time
+	// Topics the connection subscribed to with the topic query parameter, see EmitTo. Later changes through Options
+	// DynamicTopics are not reflected in the ConnInfo of the request context.
+	Topics []string
+	// ClientCert is the verified client certificate when the server requires mutual TLS, nil otherwise
+	ClientCert *x509.Certificate
+	// Claims are the verified claims of the caller returned by Options Authenticate, nil when not authenticated
+	Claims map[string]any
+}
+
+// ConnInfoFromContext returns the connection information stored in the context of the SSE request and handler.
+func ConnInfoFromContext(ctx context.Context) (ConnInfo, bool) {
+	info, ok := ctx.Value(connInfoCtxKey{}).(ConnInfo)
+	return info, ok
+}
+
+func withConnInfo(ctx context.Context, info ConnInfo) context.Context {
+	return context.WithValue(ctx, connInfoCtxKey{}, info)
+}
+
+// withDisconnect carries the function closing the connection of the SSE request to its subscriber
+func withDisconnect(ctx context.Context, disconnect func()) context.Context {
+	return context.WithValue(ctx, disconnectCtxKey{}, disconnect)
+}
+
+func disconnectFromContext(ctx context.Context) (func(), bool) {
+	disconnect, ok := ctx.Value(disconnectCtxKey{}).(func())
+	return disconnect, ok
+}
+
+// withSubscribed carries the function the subscriber of the SSE request calls once it is stored
+func withSubscribed(ctx context.Context, subscribed func()) context.Context {
+	return context.WithValue(ctx, subscribedCtxKey{}, subscribed)
+}
+
+// markSubscribed reports the subscriber of the SSE request as stored, when its connection waits for it
+func markSubscribed(ctx context.Context) {
+	if subscribed, ok := ctx.Value(subscribedCtxKey{}).(func()); ok {
+		subscribed()
+	}
+}
+
+// newConnInfo describes the connection of the request, without a UserIDFunc the user is the "sub" claim if present,
+// otherwise the common name of the verified client certificate
+func newConnInfo(req *http.Request, options *Options, claims map[string]any) ConnInfo {
+	info := ConnInfo{
+		ID:          newConnectionID(),
+		RemoteAddr:  req.RemoteAddr,
+		Protocol:    req.Proto,
+		Header:      req.Header.Clone(),
+		ConnectedAt: time.Now(),
+		Topics:      topicsFromQuery(req.URL.Query()),
+		Claims:      claims,
+	}
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		info.ClientCert = req.TLS.PeerCertificates[0]
+	}
+	if options.SubscriberIDFunc != nil {
+		if id := options.SubscriberIDFunc(req); id != "" {
+			info.ID = id
+		}
+	}
+	if options.UserIDFunc != nil {
+		info.UserID = options.UserIDFunc(req)
+	} else if sub, ok := claims["sub"].(string); ok {
+		info.UserID = sub
+	} else if info.ClientCert != nil {
+		info.UserID = info.ClientCert.Subject.CommonName
+	}
+
+	return info
+}
+
+func newConnectionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
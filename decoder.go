@@ -0,0 +1,211 @@
+package ssevents
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrEventTooLarge is returned by the Decoder for an event exceeding its MaxEventSize, the stream cannot be decoded
+// further
+var ErrEventTooLarge = errors.New("sse event too large")
+
+// utf8BOM is skipped at the start of a stream
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+// Decoder reads events from an SSE stream. Lines are assembled from a bufio.Reader so there is no limit on their
+// length, other than MaxEventSize, and frames split across reads of the underlying reader are decoded as their bytes
+// arrive. Lines end with "\r\n", "\n" or "\r" and a UTF-8 BOM at the start of the stream is skipped, as specified by
+// the WHATWG.
+type Decoder struct {
+	// OnComment, when set, receives the text of every comment line, like the ": ping" keep-alives of the server,
+	// which are skipped otherwise
+	OnComment func(comment string)
+	// MaxEventSize, when set, fails the decoding with ErrEventTooLarge once the lines of an event exceed the number
+	// of bytes, before they are read whole
+	MaxEventSize int
+	reader       *bufio.Reader
+	// size is the number of bytes of the lines of the event decoded so far
+	size    int
+	line    []byte
+	data    []byte
+	metrics ClientMetrics
+	started bool
+	// lastEventID and retry are applied once their field is parsed and persist across events, also of frames that
+	// are not dispatched, like a standalone "retry: 5000"
+	lastEventID string
+	retry       int
+}
+
+// NewDecoder creates a Decoder reading from r, typically an HTTP response body
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{reader: bufio.NewReader(&lineEndingReader{reader: r}), metrics: noopClientMetrics{}}
+}
+
+// Decode returns the next event of the stream, events without data are skipped. Consecutive data lines are joined
+// with a newline and a single space after the colon of a field is optional, lines starting with a colon are comments.
+// It returns io.EOF once the stream ends, an event not terminated by an empty line before the end is discarded.
+func (d *Decoder) Decode() (Event, error) {
+	var event Event
+	d.data = d.data[:0]
+	d.size = 0
+
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+
+		if len(line) == 0 {
+			if len(d.data) > 0 {
+				event.Data = string(d.data[:len(d.data)-1])
+				return event, nil
+			}
+			event = Event{} // Reset for next event
+			d.size = 0
+			continue
+		}
+		if line[0] == ':' {
+			if d.OnComment != nil {
+				d.OnComment(string(bytes.TrimPrefix(line[1:], []byte(" "))))
+			}
+			continue
+		}
+
+		name, value, found := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(name) {
+		case "id":
+			// IDs with a NULL character are ignored
+			if bytes.IndexByte(value, 0) < 0 {
+				event.Id = string(value)
+				d.lastEventID = event.Id
+			}
+		case "event":
+			event.Event = string(value)
+		case "data":
+			d.data = append(d.data, value...)
+			d.data = append(d.data, '\n')
+		case metaField:
+			if err := json.Unmarshal(value, &event.Meta); err != nil {
+				d.metrics.ParseFailed()
+			}
+		case "retry":
+			// Only ASCII digits are valid, strconv.Atoi accepts a sign as well
+			if retry, retryErr := strconv.Atoi(string(value)); retryErr == nil && isDigits(value) {
+				event.Retry = retry
+				d.retry = retry
+			} else {
+				d.metrics.ParseFailed()
+			}
+		default:
+			if !found {
+				d.metrics.ParseFailed()
+				continue
+			}
+			if event.Extensions == nil {
+				event.Extensions = make(map[string]string)
+			}
+			event.Extensions[string(name)] = string(value)
+		}
+	}
+}
+
+// LastEventID returns the value of the latest id field of the stream, also of frames without data which are not
+// returned by Decode, an empty id field resets it
+func (d *Decoder) LastEventID() string {
+	return d.lastEventID
+}
+
+// Retry returns the latest reconnection time of the stream in milliseconds, also of frames without data which are not
+// returned by Decode, 0 when the stream did not set it
+func (d *Decoder) Retry() int {
+	return d.retry
+}
+
+// readLine returns the next line without its line ending, the returned slice is valid only until the next call. A
+// final line without a line ending is returned before io.EOF.
+func (d *Decoder) readLine() ([]byte, error) {
+	line, err := d.reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// The line does not fit the reader buffer, assemble it from the consecutive slices
+		d.line = append(d.line[:0], line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			if err = d.checkSize(len(d.line)); err != nil {
+				return nil, err
+			}
+			line, err = d.reader.ReadSlice('\n')
+			d.line = append(d.line, line...)
+		}
+		line = d.line
+	}
+	if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+		return nil, err
+	}
+	d.metrics.BytesRead(len(line))
+	if err = d.checkSize(len(line)); err != nil {
+		return nil, err
+	}
+	d.size += len(line)
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if !d.started {
+		d.started = true
+		line = bytes.TrimPrefix(line, utf8BOM)
+	}
+
+	return line, nil
+}
+
+// checkSize fails once the line of n bytes does not fit the MaxEventSize of the event
+func (d *Decoder) checkSize(n int) error {
+	if d.MaxEventSize > 0 && d.size+n > d.MaxEventSize {
+		return fmt.Errorf("%w: more than %d bytes", ErrEventTooLarge, d.MaxEventSize)
+	}
+	return nil
+}
+
+func isDigits(value []byte) bool {
+	for _, b := range value {
+		if b < '0' || b > '9' {
+			return false
+		}
+	}
+	return len(value) > 0
+}
+
+// lineEndingReader turns the "\r\n" and "\r" line endings into "\n", also when a "\r\n" is split across reads
+type lineEndingReader struct {
+	reader io.Reader
+	// skipLF drops the "\n" following a "\r" that was already turned into a "\n"
+	skipLF bool
+}
+
+func (r *lineEndingReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.reader.Read(p)
+		written := 0
+		for _, b := range p[:n] {
+			switch {
+			case b == '\n' && r.skipLF:
+				r.skipLF = false
+				continue
+			case b == '\r':
+				r.skipLF = true
+				b = '\n'
+			default:
+				r.skipLF = false
+			}
+			p[written] = b
+			written++
+		}
+		// A read of a single dropped "\n" is retried, bufio.Reader fails after too many empty reads
+		if written > 0 || n == 0 || err != nil {
+			return written, err
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package dockerevents consumes the Docker engine events API and re-emits them over SSE, by default only container
+// lifecycle events, useful for local development dashboards and CI visualizations.
+//
+//	source, err := dockerevents.New(dockerevents.Options{})
+//	server, err := ssevents.NewServer(&ssevents.Options{Sources: []ssevents.Source{source}})
+//
+// Events are named after the object type and action, e.g. container.start or container.die, with the engine message
+// JSON as data.
+package dockerevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	hostDefault       = "unix:///var/run/docker.sock"
+	retryDelayDefault = 2 * time.Second
+)
+
+type Options struct {
+	// Host is the engine address, unix:// and tcp:// are supported, default is DOCKER_HOST or the local socket
+	Host string
+	// Types of objects to receive events for, default is container only, e.g. container, image, network, volume
+	Types []string
+	// Filters are additional engine event filters, e.g. {"label": {"ci=true"}}
+	Filters map[string][]string
+	// RetryDelay defines how long to wait before reconnecting after the stream failed, default is 2s
+	RetryDelay time.Duration
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Message is an engine event, it is also the data of the emitted events.
+type Message struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Scope    string `json:"scope"`
+	Time     int64  `json:"time"`
+	TimeNano int64  `json:"timeNano"`
+}
+
+// Source is a ssevents.Source emitting Docker engine events.
+type Source struct {
+	client     *http.Client
+	baseURL    string
+	filters    map[string][]string
+	retryDelay time.Duration
+	logger     *slog.Logger
+}
+
+var _ ssevents.Source = (*Source)(nil)
+
+func New(options Options) (*Source, error) {
+	host := options.Host
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = hostDefault
+	}
+
+	s := &Source{
+		filters:    map[string][]string{"type": {"container"}},
+		retryDelay: retryDelayDefault,
+		logger:     slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		socket := strings.TrimPrefix(host, "unix://")
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		s.client = &http.Client{Transport: transport}
+		s.baseURL = "http://docker"
+	case strings.HasPrefix(host, "tcp://"):
+		s.client = &http.Client{}
+		s.baseURL = "http://" + strings.TrimPrefix(host, "tcp://")
+	default:
+		return nil, fmt.Errorf("dockerevents: unsupported host %q", host)
+	}
+
+	for name, values := range options.Filters {
+		s.filters[name] = values
+	}
+	if len(options.Types) > 0 {
+		s.filters["type"] = options.Types
+	}
+	if options.RetryDelay > 0 {
+		s.retryDelay = options.RetryDelay
+	}
+	if options.Logger != nil {
+		s.logger = options.Logger
+	}
+
+	return s, nil
+}
+
+// Run streams engine events until the ctx is cancelled, reconnecting from the last received event on failures.
+func (s *Source) Run(ctx context.Context, emit func(e ssevents.Event)) error {
+	var since int64
+	for {
+		err := s.stream(ctx, &since, emit)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			s.logger.Error("docker events stream failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.retryDelay):
+		}
+	}
+}
+
+func (s *Source) stream(ctx context.Context, since *int64, emit func(e ssevents.Event)) (err error) {
+	filters, err := json.Marshal(s.filters)
+	if err != nil {
+		return fmt.Errorf("failed encoding filters: %w", err)
+	}
+	query := url.Values{}
+	query.Set("filters", string(filters))
+	if *since > 0 {
+		// since is in seconds with nanosecond fraction, resuming right after the last received event
+		next := *since + 1
+		query.Set("since", fmt.Sprintf("%d.%09d", next/int64(time.Second), next%int64(time.Second)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/events?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed creating events request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed connecting to docker: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("docker events failed with status %d: %s", resp.StatusCode, msg)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var raw json.RawMessage
+		if err = decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed decoding docker event: %w", err)
+		}
+		var msg Message
+		if err = json.Unmarshal(raw, &msg); err != nil {
+			return fmt.Errorf("failed decoding docker event: %w", err)
+		}
+
+		*since = msg.TimeNano
+		emit(ssevents.Event{
+			Id:    strconv.FormatInt(msg.TimeNano, 10),
+			Event: msg.Type + "." + msg.Action,
+			Data:  string(raw),
+		})
+	}
+}
@@ -11,11 +11,13 @@ func _() {
 	_ = x[EmitStrategyBlock-0]
 	_ = x[EmitStrategyDrop-1]
 	_ = x[EmitStrategyTimeout-2]
+	_ = x[EmitStrategyDropOldest-3]
+	_ = x[EmitStrategyDisconnect-4]
 }
 
-const _EmitStrategy_name = "EmitStrategyBlockEmitStrategyDropEmitStrategyTimeout"
+const _EmitStrategy_name = "EmitStrategyBlockEmitStrategyDropEmitStrategyTimeoutEmitStrategyDropOldestEmitStrategyDisconnect"
 
-var _EmitStrategy_index = [...]uint8{0, 17, 33, 52}
+var _EmitStrategy_index = [...]uint8{0, 17, 33, 52, 74, 96}
 
 func (i EmitStrategy) String() string {
 	if i < 0 || i >= EmitStrategy(len(_EmitStrategy_index)-1) {
@@ -0,0 +1,27 @@
+package ssevents
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// SequentialEventIDs returns an AutoEventID generator of monotonically increasing IDs starting at 1. The sequence
+// starts over with the process, use RandomEventID when clients may resume across restarts.
+func SequentialEventIDs() func() string {
+	var seq atomic.Uint64
+	return func() string {
+		return strconv.FormatUint(seq.Add(1), 10)
+	}
+}
+
+// RandomEventID is an AutoEventID generator of random version 4 UUIDs
+func RandomEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,82 @@
+package ssevents
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventStore keeps the events with an ID emitted to all subscribers, the missed ones are replayed to connections
+// resuming with the Last-Event-ID header. A persistent store, e.g. sqlstore, replays them across server restarts.
+type EventStore interface {
+	// Append stores the event, called for every emitted event with an ID in the emit order
+	Append(ctx context.Context, e Event) error
+	// After returns the stored events following the one with the ID, found is false when the ID is not stored (any
+	// more) in which case nothing is replayed
+	After(ctx context.Context, id string) (events []Event, found bool, err error)
+}
+
+type storedEvent struct {
+	event    Event
+	storedAt time.Time
+}
+
+// MemoryEventStore is an EventStore keeping the latest events in a ring
+type MemoryEventStore struct {
+	mu     sync.Mutex
+	events []storedEvent
+	next   int
+	full   bool
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+var _ EventStore = (*MemoryEventStore)(nil)
+
+// NewMemoryEventStore keeps up to maxCount events, dropping the oldest ones, and does not replay the ones older than
+// maxAge unless it is 0.
+func NewMemoryEventStore(maxCount int, maxAge time.Duration) *MemoryEventStore {
+	if maxCount < 1 {
+		maxCount = 1
+	}
+	return &MemoryEventStore{events: make([]storedEvent, maxCount), maxAge: maxAge, now: time.Now}
+}
+
+func (s *MemoryEventStore) Append(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[s.next] = storedEvent{event: e, storedAt: s.now()}
+	s.next = (s.next + 1) % len(s.events)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+func (s *MemoryEventStore) After(_ context.Context, id string) ([]Event, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ordered := s.events[:s.next]
+	if s.full {
+		ordered = append(append(make([]storedEvent, 0, len(s.events)), s.events[s.next:]...), s.events[:s.next]...)
+	}
+	if s.maxAge > 0 {
+		cutoff := s.now().Add(-s.maxAge)
+		for len(ordered) > 0 && ordered[0].storedAt.Before(cutoff) {
+			ordered = ordered[1:]
+		}
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if ordered[i].event.Id != id {
+			continue
+		}
+		events := make([]Event, 0, len(ordered)-i-1)
+		for _, stored := range ordered[i+1:] {
+			events = append(events, stored.event)
+		}
+		return events, true, nil
+	}
+
+	return nil, false, nil
+}
@@ -1,8 +1,18 @@
 package ssevents
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type Event struct {
@@ -15,51 +25,252 @@ type Event struct {
 	Data  string `json:"data"`
 	// Retry, in milliseconds, specifies to the browser when it should retry the connection
 	Retry int `json:"retry,omitempty"`
+	// Extensions are additional fields sent as "name: value" lines, like the traceparent of the emit. Browsers ignore
+	// unknown fields while this package's client reads them back. Names of the standard fields are never written.
+	Extensions map[string]string `json:"extensions,omitempty"`
+	// Meta travels with the event as a single JSON encoded "meta" field, e.g. correlation and tenant IDs or content
+	// type hints, read back by this package's client. Unlike Extensions its keys and values may hold any text.
+	Meta map[string]string `json:"meta,omitempty"`
+	// ctx is propagated from the emitter to the delivery of the event, it is never sent over the wire
+	ctx context.Context
+	// seq orders the events of the replay buffer, zero when replay is disabled
+	seq uint64
+}
+
+// ErrInvalidEvent is returned by Event Validate for the events that cannot be written as a single frame
+var ErrInvalidEvent = errors.New("invalid sse event")
+
+// lineBreakRemover strips the line breaks of the fields written on a single line
+var lineBreakRemover = strings.NewReplacer("\r", "", "\n", "")
+
+const (
+	// EncodingExtension is the extension marking the encoding of the data, see SetBinary
+	EncodingExtension = "encoding"
+	encodingBase64    = "base64"
+)
+
+// SetBinary sets the data to the base64 encoding of the payload marked by the EncodingExtension, as an SSE stream
+// carries only text. Receivers get the payload back with Binary.
+func (e *Event) SetBinary(payload []byte) {
+	e.Data = base64.StdEncoding.EncodeToString(payload)
+	// The extensions may be shared with the copies of the event
+	extensions := maps.Clone(e.Extensions)
+	if extensions == nil {
+		extensions = make(map[string]string, 1)
+	}
+	extensions[EncodingExtension] = encodingBase64
+	e.Extensions = extensions
+}
+
+// Binary returns the payload set with SetBinary, decoding the data when marked as base64 by the EncodingExtension,
+// otherwise the data as is
+func (e Event) Binary() ([]byte, error) {
+	if e.Extensions[EncodingExtension] != encodingBase64 {
+		return []byte(e.Data), nil
+	}
+	payload, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding binary data: %w", err)
+	}
+
+	return payload, nil
+}
+
+// NewJSONEvent creates an event with the given name and the value marshalled to JSON as its data
+func NewJSONEvent(event string, v any) (Event, error) {
+	return NewEncodedEvent(JSONCodec, event, v)
+}
+
+// Validate reports an event name or ID with a line break, which would end the field and corrupt the frame, or an ID
+// with a NULL character, which is ignored by the receivers. Data may contain line breaks, every line is written as a
+// data field of its own.
+func (e Event) Validate() error {
+	if strings.ContainsAny(e.Event, "\r\n") {
+		return fmt.Errorf("%w: event name %q contains a line break", ErrInvalidEvent, e.Event)
+	}
+	if strings.ContainsAny(e.Id, "\r\n\x00") {
+		return fmt.Errorf("%w: id %q contains a line break or a NULL character", ErrInvalidEvent, e.Id)
+	}
+
+	return nil
 }
 
 func (e Event) String() string {
+	var names [maxStackExtensions]string
+	extensionNames := e.appendExtensionNames(names[:0])
+
+	size := len(e.Id) + len(e.Event) + len(e.Data) + 40
+	for _, name := range extensionNames {
+		size += len(name) + len(e.Extensions[name]) + 3
+	}
 	builder := strings.Builder{}
+	builder.Grow(size)
 	if e.Id != "" {
-		_, _ = fmt.Fprintf(&builder, "id: %s ", e.Id)
+		writeStringField(&builder, "id", e.Id)
 	}
 	if e.Event != "" {
-		_, _ = fmt.Fprintf(&builder, "event: %s ", e.Event)
+		writeStringField(&builder, "event", e.Event)
 	}
 	if e.Retry > 0 {
-		_, _ = fmt.Fprintf(&builder, "retry: %d ", e.Retry)
+		var num [20]byte
+		builder.WriteString("retry: ")
+		builder.Write(strconv.AppendInt(num[:0], int64(e.Retry), 10))
+		builder.WriteByte(' ')
 	}
-
-	_, _ = fmt.Fprintf(&builder, "data: %s", e.Data)
+	for _, name := range extensionNames {
+		writeStringField(&builder, name, e.Extensions[name])
+	}
+	if meta, ok := e.encodeMeta(); ok {
+		writeStringField(&builder, metaField, meta)
+	}
+	builder.WriteString("data: ")
+	builder.WriteString(e.Data)
 
 	return builder.String()
 }
 
+func writeStringField(builder *strings.Builder, name, value string) {
+	builder.WriteString(name)
+	builder.WriteString(": ")
+	builder.WriteString(value)
+	builder.WriteByte(' ')
+}
+
+// WriteTo writes the event in the wire format to w with a single write, it is encoded into a pooled buffer so writing
+// it does not allocate
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	buf := getEventBuffer()
+	defer putEventBuffer(buf)
+	e.writeResponse(buf)
+	n, err := w.Write(buf.Bytes())
+
+	return int64(n), err
+}
+
 // ToResponseString - converts the SSEEvent into a string that will get sent as a response in the data section
+//
+// Deprecated: use WriteTo, which does not allocate the string, the returned error is always nil.
 func (e Event) ToResponseString() (string, error) {
-	builder := strings.Builder{}
+	buf := getEventBuffer()
+	defer putEventBuffer(buf)
+	e.writeResponse(buf)
+
+	return buf.String(), nil
+}
+
+// writeResponse serializes the event in the wire format into the buffer, writes to a bytes.Buffer never fail. The line
+// breaks of the event name and ID are removed so an invalid event cannot inject fields or frames, see Validate.
+func (e Event) writeResponse(buf *bytes.Buffer) {
 	if e.Event != "" {
-		if _, err := fmt.Fprintf(&builder, "event: %s\n", e.Event); err != nil {
-			return "", err
-		}
+		writeField(buf, "event", removeLineBreaks(e.Event))
+	}
+	writeData(buf, e.Data)
+	if e.Id != "" {
+		writeField(buf, "id", removeLineBreaks(e.Id))
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: ")
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(e.Retry), 10))
+		buf.WriteByte('\n')
 	}
+	var names [maxStackExtensions]string
+	for _, name := range e.appendExtensionNames(names[:0]) {
+		writeField(buf, name, e.Extensions[name])
+	}
+	if meta, ok := e.encodeMeta(); ok {
+		writeField(buf, metaField, meta)
+	}
+	buf.WriteString("\n\n")
+}
 
-	if _, err := fmt.Fprintf(&builder, "data: %s\n", e.Data); err != nil {
-		return "", err
+func writeField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// removeLineBreaks returns the value without its line breaks, allocating only when it has some
+func removeLineBreaks(value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		return value
 	}
+	return lineBreakRemover.Replace(value)
+}
 
-	if e.Id != "" {
-		if _, err := fmt.Fprintf(&builder, "id: %s\n", e.Id); err != nil {
-			return "", err
+// writeData writes every line of the data as a separate data field, receivers join them back with a newline
+func writeData(buf *bytes.Buffer, data string) {
+	for {
+		i := strings.IndexAny(data, "\r\n")
+		if i < 0 {
+			writeField(buf, "data", data)
+			return
 		}
-	}
-	if e.Retry > 0 {
-		if _, err := fmt.Fprintf(&builder, "retry: %d\n", e.Retry); err != nil {
-			return "", err
+		writeField(buf, "data", data[:i])
+		if strings.HasPrefix(data[i:], "\r\n") {
+			i++
 		}
+		data = data[i+1:]
 	}
-	if _, err := builder.WriteString("\n\n"); err != nil {
-		return "", err
+}
+
+// maxPooledEventBuffer keeps buffers grown by an occasional huge event from being held by the pool
+const maxPooledEventBuffer = 64 << 10
+
+var eventBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getEventBuffer() *bytes.Buffer {
+	return eventBufferPool.Get().(*bytes.Buffer)
+}
+
+func putEventBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledEventBuffer {
+		return
+	}
+	buf.Reset()
+	eventBufferPool.Put(buf)
+}
+
+// metaField carries the Meta of an event, it is reserved so no extension is written with its name
+const metaField = "meta"
+
+// encodeMeta returns the Meta encoded as JSON, without line breaks as they are escaped, false when it is empty
+func (e Event) encodeMeta() (string, bool) {
+	if len(e.Meta) == 0 {
+		return "", false
+	}
+	// Marshalling a map of strings does not fail, the keys are sorted
+	meta, _ := json.Marshal(e.Meta)
+	return string(meta), true
+}
+
+// isStandardField reports if the name is one of the fields defined by the SSE specification or reserved by this
+// package
+func isStandardField(name string) bool {
+	switch name {
+	case "id", "event", "data", "retry", metaField:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxStackExtensions is the number of extension names sorted without allocating when encoding an event
+const maxStackExtensions = 4
+
+// appendExtensionNames appends the sorted names of extensions that can be written as fields to names
+func (e Event) appendExtensionNames(names []string) []string {
+	for name, value := range e.Extensions {
+		if name == "" || isStandardField(name) || strings.ContainsAny(name, ":\r\n") || strings.ContainsAny(value, "\r\n") {
+			continue
+		}
+		names = append(names, name)
 	}
+	slices.Sort(names)
 
-	return builder.String(), nil
+	return names
 }
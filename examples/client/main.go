@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/doppelganger113/ssevents"
@@ -48,13 +49,14 @@ func main() {
 
 	c.Start()
 
-	sigTerm := ssevents.WatchSigTerm()
+	sigCtx, stop := ssevents.NotifyContext(context.Background())
+	defer stop()
 
 	log.Info("client started")
 	// Read from channels
 	for {
 		select {
-		case <-sigTerm:
+		case <-sigCtx.Done():
 			log.Info("shut down signal received")
 			return
 		case errCh, ok := <-c.Errors():
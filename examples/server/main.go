@@ -72,6 +72,9 @@ func main() {
 		logErrorAndExit(err)
 	}
 
+	sigCtx, stop := ssevents.NotifyContext(context.Background())
+	defer stop()
+
 	serverErr := make(chan error)
 	go func() {
 		log.Info("Started server on port :" + strconv.Itoa(*port))
@@ -83,7 +86,7 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
 		logErrorAndExit(errors.Join(err, srvr.Shutdown(ctx)))
-	case <-ssevents.WatchSigTerm():
+	case <-sigCtx.Done():
 		log.Info("shut down signal received")
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
@@ -0,0 +1,129 @@
+package ssevents
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// ErrExpvarNameTaken is returned when the ExpvarName is already published through expvar by a variable other than a
+// map
+var ErrExpvarNameTaken = errors.New("expvar name is already published")
+
+var expvarMu sync.Mutex
+
+// expvarMap returns the published map with the name, reusing it when already published as expvar panics on duplicates
+func expvarMap(name string) (*expvar.Map, error) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	switch v := expvar.Get(name).(type) {
+	case nil:
+		return expvar.NewMap(name), nil
+	case *expvar.Map:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w as %T: %s", ErrExpvarNameTaken, v, name)
+	}
+}
+
+// expvarServerMetrics publishes the server counters through expvar
+type expvarServerMetrics struct {
+	vars *expvar.Map
+}
+
+func newExpvarServerMetrics(name string) (*expvarServerMetrics, error) {
+	vars, err := expvarMap(name)
+	if err != nil {
+		return nil, err
+	}
+	return &expvarServerMetrics{vars: vars}, nil
+}
+
+func (m *expvarServerMetrics) Connected() {
+	m.vars.Add("subscribers", 1)
+	m.vars.Add("connects", 1)
+}
+
+func (m *expvarServerMetrics) Disconnected() {
+	m.vars.Add("subscribers", -1)
+	m.vars.Add("disconnects", 1)
+}
+
+func (m *expvarServerMetrics) Emitted() {
+	m.vars.Add("emitted", 1)
+}
+
+func (m *expvarServerMetrics) Dropped(_ EmitStrategy) {
+	m.vars.Add("dropped", 1)
+}
+
+func (m *expvarServerMetrics) WriteFailed() {
+	m.vars.Add("write_errors", 1)
+}
+
+func (m *expvarServerMetrics) FlushFailed() {
+	m.vars.Add("flush_errors", 1)
+}
+
+func (m *expvarServerMetrics) WriteTimedOut() {
+	m.vars.Add("write_timeouts", 1)
+}
+
+func (m *expvarServerMetrics) HeartbeatSent() {
+	m.vars.Add("heartbeats", 1)
+}
+
+func (m *expvarServerMetrics) HeartbeatFailed() {
+	m.vars.Add("heartbeat_failures", 1)
+}
+
+func (m *expvarServerMetrics) EmitRateLimited() {
+	m.vars.Add("emit_rate_limited", 1)
+}
+
+func (m *expvarServerMetrics) EventRejected() {
+	m.vars.Add("rejected", 1)
+}
+
+// expvarClientMetrics publishes the client counters through expvar
+type expvarClientMetrics struct {
+	vars *expvar.Map
+}
+
+func newExpvarClientMetrics(name string) (*expvarClientMetrics, error) {
+	vars, err := expvarMap(name)
+	if err != nil {
+		return nil, err
+	}
+	return &expvarClientMetrics{vars: vars}, nil
+}
+
+func (m *expvarClientMetrics) Connected() {
+	m.vars.Add("connected", 1)
+}
+
+func (m *expvarClientMetrics) Disconnected() {
+	m.vars.Add("connected", -1)
+}
+
+func (m *expvarClientMetrics) ReconnectAttempted() {
+	m.vars.Add("reconnects", 1)
+}
+
+func (m *expvarClientMetrics) EventReceived(_ string) {
+	m.vars.Add("received", 1)
+}
+
+func (m *expvarClientMetrics) ParseFailed() {
+	m.vars.Add("parse_errors", 1)
+}
+
+func (m *expvarClientMetrics) BytesRead(n int) {
+	m.vars.Add("bytes_read", int64(n))
+}
+
+func (m *expvarClientMetrics) ObserverDropped() {
+	m.vars.Add("dropped", 1)
+}
@@ -0,0 +1,93 @@
+package ssevents
+
+import "sync"
+
+const fanoutBatchSizeDefault = 256
+
+// fanoutJob is a registry shard a worker delivers the event to
+type fanoutJob struct {
+	shard   *registryShard
+	e       Event
+	match   func(info ConnInfo) bool
+	outcome *emitOutcome
+	wg      *sync.WaitGroup
+}
+
+func (h *Hub) startFanoutWorkers(workers int) {
+	h.fanoutJobs = make(chan fanoutJob)
+	for range workers {
+		go h.fanoutWorker()
+	}
+}
+
+func (h *Hub) fanoutWorker() {
+	for {
+		select {
+		case job := <-h.fanoutJobs:
+			h.deliverShard(job)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *Hub) deliverShard(job fanoutJob) {
+	defer job.wg.Done()
+	h.deliverTo(job.shard, job.e, job.match, job.outcome)
+}
+
+// deliverTo delivers the event to the subscribers of the shard accepted by match whose filter accepts the event
+func (h *Hub) deliverTo(shard *registryShard, e Event, match func(info ConnInfo) bool, outcome *emitOutcome) {
+	pooled := subscribersPool.Get().(*[]*subscriber)
+	subs := shard.collect((*pooled)[:0])
+	for _, sub := range subs {
+		if sub.accepts(e) && (match == nil || match(sub.connInfo())) {
+			h.deliver(sub, e, outcome)
+		}
+	}
+	// The pooled slice must not keep the subscribers alive once they are gone
+	clear(subs)
+	*pooled = subs[:0]
+	subscribersPool.Put(pooled)
+}
+
+// fanout delivers the event to the subscribers accepted by match, or to all of them when match is nil, skipping those
+// whose filter rejects the event. Without workers, or for at most FanoutBatchSize subscribers, the shards of the
+// registry are delivered one after another on the calling goroutine. Otherwise every shard is handed to the workers,
+// when all of them are busy the caller delivers the shard itself, keeping the concurrency bounded. Either way fanout
+// returns once the event was handed to every subscriber, so events of a single emitter keep their order.
+func (h *Hub) fanout(e Event, match func(info ConnInfo) bool) emitOutcome {
+	var outcome emitOutcome
+	if h.fanoutJobs == nil || h.subscribers.len() <= h.options.FanoutBatchSize {
+		for i := range h.subscribers.shards {
+			h.deliverTo(&h.subscribers.shards[i], e, match, &outcome)
+		}
+		return outcome
+	}
+
+	outcomes := make([]emitOutcome, len(h.subscribers.shards))
+	var wg sync.WaitGroup
+	for i := range outcomes {
+		job := fanoutJob{
+			shard:   &h.subscribers.shards[i],
+			e:       e,
+			match:   match,
+			outcome: &outcomes[i],
+			wg:      &wg,
+		}
+		wg.Add(1)
+		select {
+		case h.fanoutJobs <- job:
+		default:
+			h.deliverShard(job)
+		}
+	}
+	wg.Wait()
+
+	for _, shardOutcome := range outcomes {
+		outcome.delivered += shardOutcome.delivered
+		outcome.dropped += shardOutcome.dropped
+	}
+
+	return outcome
+}
@@ -0,0 +1,43 @@
+package ssevents
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// ErrFaultInjected fails the writes chosen by Options Faults, closing their connection
+var ErrFaultInjected = errors.New("sse fault injected")
+
+// Faults injects failures into the writes to the SSE connections, for chaos testing the consumers against delayed,
+// dropped and corrupted streams. Meant for test and staging deployments only.
+type Faults struct {
+	// WriteLatency delays every write to a connection
+	WriteLatency time.Duration
+	// DisconnectRate is the probability from 0 to 1 that a write closes its connection instead
+	DisconnectRate float64
+	// TruncateRate is the probability from 0 to 1 that a write sends only a part of its frame before closing its
+	// connection, leaving the client with an incomplete event
+	TruncateRate float64
+}
+
+// inject delays the write and fails it with ErrFaultInjected when a fault is drawn, n is the number of bytes of the
+// truncated frame written
+func (f *Faults) inject(rc *http.ResponseController, w http.ResponseWriter, data []byte) (n int, err error) {
+	if f.WriteLatency > 0 {
+		time.Sleep(f.WriteLatency)
+	}
+
+	draw := rand.Float64()
+	switch {
+	case draw < f.DisconnectRate:
+		return 0, ErrFaultInjected
+	case draw < f.DisconnectRate+f.TruncateRate && len(data) > 0:
+		n, _ = w.Write(data[:rand.IntN(len(data))])
+		_ = rc.Flush()
+		return n, ErrFaultInjected
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,107 @@
+package graphqlsse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// Result is an execution result received in a next event.
+type Result struct {
+	Data       json.RawMessage `json:"data,omitempty"`
+	Errors     []ResultError   `json:"errors,omitempty"`
+	Extensions map[string]any  `json:"extensions,omitempty"`
+}
+
+type ResultError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Client consumes GraphQL subscriptions in the distinct connections mode.
+type Client struct {
+	url    string
+	client *http.Client
+	header http.Header
+}
+
+// NewClient creates the client for the GraphQL over SSE endpoint url, a nil httpClient defaults to http.DefaultClient.
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{url: url, client: httpClient, header: make(http.Header)}
+}
+
+// Header allows setting request headers, like Authorization, sent with every operation.
+func (c *Client) Header() http.Header {
+	return c.header
+}
+
+// Subscribe executes the operation and calls the handler for every result, blocking until the server completes it,
+// the ctx is cancelled or the stream fails.
+func (c *Client) Subscribe(ctx context.Context, operation Request, handler func(result Result)) (err error) {
+	body, err := json.Marshal(operation)
+	if err != nil {
+		return fmt.Errorf("failed encoding operation: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed creating request: %w", err)
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("graphql subscription failed with status %d", resp.StatusCode)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan ssevents.Event)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(events)
+		readErr <- ssevents.ReadEvents(streamCtx, resp.Body, events)
+	}()
+
+	for evt := range events {
+		switch evt.Event {
+		case EventNext:
+			var result Result
+			if err = json.Unmarshal([]byte(evt.Data), &result); err != nil {
+				return fmt.Errorf("failed decoding result: %w", err)
+			}
+			handler(result)
+		case EventComplete:
+			return nil
+		}
+	}
+
+	if err = <-readErr; err != nil {
+		return err
+	}
+
+	// The server completes every operation, a stream ending without the complete event was cancelled or dropped
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return errors.New("graphql stream closed before the operation completed")
+}
@@ -0,0 +1,407 @@
+// Package graphqlsse implements the GraphQL over Server-Sent Events protocol, serving and consuming GraphQL
+// subscriptions as event streams of distinct next and complete events.
+//
+// Both protocol modes are served:
+//   - distinct connections, where every operation is a POST request streaming its own results
+//   - single connection, where a PUT reserves a stream token, GET opens the stream and operations are started with
+//     POST and stopped with DELETE, all results are multiplexed over the stream by their operation ID. Reservations
+//     that are not opened within the ReservationTTL expire and operations are only accepted on an open stream.
+//
+// Execution is left to the GraphQL library of choice through the SubscribeFunc.
+//
+//	handlers := graphqlsse.NewServer(subscribe, nil).Handlers("/graphql/stream")
+//	server, err := ssevents.NewServer(&ssevents.Options{Handlers: handlers})
+package graphqlsse
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	// EventNext carries an execution result of the operation
+	EventNext = "next"
+	// EventComplete signals that the operation finished and no more results will follow
+	EventComplete = "complete"
+
+	// HeaderToken carries the stream reservation token in single connection mode
+	HeaderToken = "X-GraphQL-Event-Stream-Token"
+
+	keepAliveIntervalDefault = 12 * time.Second
+	reservationTTLDefault    = 30 * time.Second
+	maxReservationsDefault   = 1024
+)
+
+// Request is a GraphQL operation.
+type Request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// SubscribeFunc executes the operation, sending execution results, e.g. {"data": ...}, on the returned channel and
+// closing it once done. The ctx is cancelled when the client stops the operation or disconnects.
+type SubscribeFunc func(ctx context.Context, req Request) (<-chan any, error)
+
+type ServerOptions struct {
+	// KeepAliveInterval defines on which interval a comment is sent keeping idle streams open, default is 12s
+	KeepAliveInterval time.Duration
+	// ReservationTTL defines how long a reserved single connection stream waits to be opened before it expires,
+	// default is 30s
+	ReservationTTL time.Duration
+	// MaxReservations limits the single connection streams, reserved or open, at a time, default is 1024
+	MaxReservations int
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Server serves GraphQL subscriptions over SSE.
+type Server struct {
+	subscribe         SubscribeFunc
+	keepAliveInterval time.Duration
+	reservationTTL    time.Duration
+	maxReservations   int
+	logger            *slog.Logger
+	mu                sync.Mutex
+	streams           map[string]*stream
+}
+
+// stream is a reserved single connection mode stream
+type stream struct {
+	events     chan ssevents.Event
+	connected  bool
+	operations map[string]context.CancelFunc
+}
+
+func NewServer(subscribe SubscribeFunc, options *ServerOptions) *Server {
+	s := &Server{
+		subscribe:         subscribe,
+		keepAliveInterval: keepAliveIntervalDefault,
+		reservationTTL:    reservationTTLDefault,
+		maxReservations:   maxReservationsDefault,
+		logger:            slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		streams:           make(map[string]*stream),
+	}
+	if options != nil {
+		if options.KeepAliveInterval > 0 {
+			s.keepAliveInterval = options.KeepAliveInterval
+		}
+		if options.ReservationTTL > 0 {
+			s.reservationTTL = options.ReservationTTL
+		}
+		if options.MaxReservations > 0 {
+			s.maxReservations = options.MaxReservations
+		}
+		if options.Logger != nil {
+			s.logger = options.Logger
+		}
+	}
+
+	return s
+}
+
+// Handlers returns the routes of both protocol modes on the given path, to be used as ssevents.Options Handlers.
+func (s *Server) Handlers(path string) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"PUT " + path:    s.reserve,
+		"GET " + path:    s.get,
+		"POST " + path:   s.post,
+		"DELETE " + path: s.stop,
+	}
+}
+
+func tokenFrom(req *http.Request) string {
+	if token := req.Header.Get(HeaderToken); token != "" {
+		return token
+	}
+	return req.URL.Query().Get("token")
+}
+
+// reserve creates a single connection mode stream and responds with its token, the reservation expires unless the
+// stream is opened within the reservation TTL
+func (s *Server) reserve(w http.ResponseWriter, _ *http.Request) {
+	token := newToken()
+
+	s.mu.Lock()
+	if len(s.streams) >= s.maxReservations {
+		s.mu.Unlock()
+		http.Error(w, "too many streams", http.StatusServiceUnavailable)
+		return
+	}
+	st := &stream{
+		events:     make(chan ssevents.Event, 16),
+		operations: make(map[string]context.CancelFunc),
+	}
+	s.streams[token] = st
+	s.mu.Unlock()
+
+	time.AfterFunc(s.reservationTTL, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !st.connected && s.streams[token] == st {
+			delete(s.streams, token)
+		}
+	})
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(token))
+}
+
+// get opens the reserved stream, or executes a distinct connections mode operation passed through query parameters
+func (s *Server) get(w http.ResponseWriter, req *http.Request) {
+	token := tokenFrom(req)
+	if token == "" {
+		query := req.URL.Query()
+		operation := Request{Query: query.Get("query"), OperationName: query.Get("operationName")}
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &operation.Variables); err != nil {
+				http.Error(w, "failed: invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		s.serveDistinct(w, req, operation)
+		return
+	}
+
+	s.mu.Lock()
+	st, ok := s.streams[token]
+	if ok && st.connected {
+		s.mu.Unlock()
+		http.Error(w, "stream already open", http.StatusConflict)
+		return
+	}
+	if ok {
+		st.connected = true
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "stream not found", http.StatusNotFound)
+		return
+	}
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, token)
+		for _, cancel := range st.operations {
+			cancel()
+		}
+		s.mu.Unlock()
+	}()
+
+	s.serveStream(w, req, st.events, nil)
+}
+
+// post starts an operation, either on a reserved stream or as its own distinct connection stream
+func (s *Server) post(w http.ResponseWriter, req *http.Request) {
+	var operation Request
+	if err := json.NewDecoder(req.Body).Decode(&operation); err != nil {
+		http.Error(w, "failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token := tokenFrom(req)
+	if token == "" {
+		s.serveDistinct(w, req, operation)
+		return
+	}
+
+	operationID, _ := operation.Extensions["operationId"].(string)
+	if operationID == "" {
+		http.Error(w, "operationId extension is required", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	st, ok := s.streams[token]
+	if !ok {
+		s.mu.Unlock()
+		http.Error(w, "stream not found", http.StatusNotFound)
+		return
+	}
+	if !st.connected {
+		s.mu.Unlock()
+		http.Error(w, "stream is not open", http.StatusConflict)
+		return
+	}
+	if _, exists := st.operations[operationID]; exists {
+		s.mu.Unlock()
+		http.Error(w, "operation with the id already exists", http.StatusConflict)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	st.operations[operationID] = cancel
+	s.mu.Unlock()
+
+	results, err := s.subscribe(ctx, operation)
+	if err != nil {
+		s.removeOperation(st, operationID)
+		http.Error(w, "failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	go func() {
+		defer s.removeOperation(st, operationID)
+		for result := range results {
+			payload, marshalErr := json.Marshal(map[string]any{"id": operationID, "payload": result})
+			if marshalErr != nil {
+				s.logger.Error("failed encoding graphql result", "err", marshalErr)
+				continue
+			}
+			select {
+			case st.events <- ssevents.Event{Event: EventNext, Data: string(payload)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		complete, _ := json.Marshal(map[string]any{"id": operationID})
+		select {
+		case st.events <- ssevents.Event{Event: EventComplete, Data: string(complete)}:
+		case <-ctx.Done():
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// stop cancels a single connection mode operation
+func (s *Server) stop(w http.ResponseWriter, req *http.Request) {
+	operationID := req.URL.Query().Get("operationId")
+
+	s.mu.Lock()
+	st, ok := s.streams[tokenFrom(req)]
+	var cancel context.CancelFunc
+	if ok {
+		cancel = st.operations[operationID]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "stream not found", http.StatusNotFound)
+		return
+	}
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (s *Server) removeOperation(st *stream, operationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := st.operations[operationID]; ok {
+		cancel()
+		delete(st.operations, operationID)
+	}
+}
+
+// serveDistinct executes the operation streaming its results on the request connection
+func (s *Server) serveDistinct(w http.ResponseWriter, req *http.Request, operation Request) {
+	if !strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "expected Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	results, err := s.subscribe(ctx, operation)
+	if err != nil {
+		http.Error(w, "failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events := make(chan ssevents.Event)
+	go func() {
+		defer close(events)
+		for result := range results {
+			payload, marshalErr := json.Marshal(result)
+			if marshalErr != nil {
+				s.logger.Error("failed encoding graphql result", "err", marshalErr)
+				continue
+			}
+			select {
+			case events <- ssevents.Event{Event: EventNext, Data: string(payload)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.serveStream(w, req, events, &ssevents.Event{Event: EventComplete})
+}
+
+// serveStream writes events until the channel closes or the client disconnects, sending last at the end
+func (s *Server) serveStream(
+	w http.ResponseWriter, req *http.Request, events <-chan ssevents.Event, last *ssevents.Event,
+) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("failed flushing graphql stream", "err", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(s.keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := write(rc, w, ":\n\n"); err != nil {
+				s.logger.Debug("failed sending graphql keep-alive", "err", err)
+				return
+			}
+		case evt, ok := <-events:
+			if !ok {
+				if last != nil {
+					if err := writeEvent(rc, w, *last); err != nil {
+						s.logger.Debug("failed sending graphql complete", "err", err)
+					}
+				}
+				return
+			}
+			if err := writeEvent(rc, w, evt); err != nil {
+				s.logger.Debug("failed sending graphql event", "err", err)
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(rc *http.ResponseController, w http.ResponseWriter, evt ssevents.Event) error {
+	if _, err := evt.WriteTo(w); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+func write(rc *http.ResponseController, w http.ResponseWriter, data string) error {
+	if _, err := fmt.Fprint(w, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
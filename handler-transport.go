@@ -0,0 +1,109 @@
+package ssevents
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// NewHandlerTransport returns a transport serving every request with the handler in-process, through a pipe instead
+// of a network connection, e.g. for hermetic tests of a Client connected to a Server handler:
+//
+//	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+//		Transport: ssevents.NewHandlerTransport(server.Handler()),
+//	})
+//
+// The response is returned once the handler writes its header, closing its body cancels the context of the request
+// the handler sees, like a client going away.
+func NewHandlerTransport(handler http.Handler) http.RoundTripper {
+	return &handlerTransport{handler: handler}
+}
+
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t *handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	serverReq := req.Clone(ctx)
+	serverReq.RequestURI = req.URL.RequestURI()
+	serverReq.RemoteAddr = "pipe"
+	serverReq.Proto, serverReq.ProtoMajor, serverReq.ProtoMinor = "HTTP/1.1", 1, 1
+	if serverReq.Body == nil {
+		serverReq.Body = http.NoBody
+	}
+
+	reader, writer := io.Pipe()
+	w := &pipeResponseWriter{header: make(http.Header), body: writer, ready: make(chan struct{})}
+	go func() {
+		defer func() {
+			w.WriteHeader(http.StatusOK)
+			_ = writer.Close()
+		}()
+		t.handler.ServeHTTP(w, serverReq)
+	}()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		cancel()
+		_ = reader.Close()
+		return nil, ctx.Err()
+	}
+
+	return &http.Response{
+		Status:     strconv.Itoa(w.status) + " " + http.StatusText(w.status),
+		StatusCode: w.status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     w.sentHeader,
+		Body:       &pipeBody{PipeReader: reader, cancel: cancel},
+		Request:    req,
+	}, nil
+}
+
+// pipeResponseWriter writes the response of the handler into the pipe read by the response body
+type pipeResponseWriter struct {
+	header     http.Header
+	sentHeader http.Header
+	body       *io.PipeWriter
+	status     int
+	once       sync.Once
+	ready      chan struct{}
+}
+
+func (w *pipeResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeResponseWriter) WriteHeader(status int) {
+	w.once.Do(func() {
+		w.status = status
+		w.sentHeader = w.header.Clone()
+		close(w.ready)
+	})
+}
+
+func (w *pipeResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// Flush sends the header, the written data is handed over to the reader by the pipe already
+func (w *pipeResponseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+// pipeBody is the response body, closing it ends the request of the handler
+type pipeBody struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (b *pipeBody) Close() error {
+	b.cancel()
+	return b.PipeReader.Close()
+}
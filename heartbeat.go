@@ -0,0 +1,87 @@
+package ssevents
+
+import (
+	"sync"
+	"time"
+)
+
+const heartbeatWheelSlots = 64
+
+// heartbeatWheel services the heartbeats of all connections with a single ticker instead of a timer per connection.
+// Connections are spread over the slots of the wheel, which advances one slot per tick and signals the connections of
+// that slot, so each connection beats once per HeartbeatInterval.
+type heartbeatWheel struct {
+	mu      sync.Mutex
+	slots   []map[chan struct{}]struct{}
+	current int
+	tick    time.Duration
+	started bool
+	done    <-chan struct{}
+}
+
+// newHeartbeatWheel creates the wheel, it starts ticking on the first registered connection and stops once done is
+// closed
+func newHeartbeatWheel(interval time.Duration, done <-chan struct{}) *heartbeatWheel {
+	slots := heartbeatWheelSlots
+	if interval < time.Duration(slots)*time.Millisecond {
+		slots = max(1, int(interval/time.Millisecond))
+	}
+
+	wheel := &heartbeatWheel{
+		slots: make([]map[chan struct{}]struct{}, slots),
+		tick:  interval / time.Duration(slots),
+		done:  done,
+	}
+	for i := range wheel.slots {
+		wheel.slots[i] = make(map[chan struct{}]struct{})
+	}
+
+	return wheel
+}
+
+// register adds a connection to the current slot, the returned channel receives its heartbeats until unregistered
+func (w *heartbeatWheel) register() (<-chan struct{}, func()) {
+	beat := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started {
+		w.started = true
+		go w.run()
+	}
+	slot := w.current
+	w.slots[slot][beat] = struct{}{}
+
+	return beat, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.slots[slot], beat)
+	}
+}
+
+func (w *heartbeatWheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.advance()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// advance moves to the next slot and signals its connections, a connection still busy with its previous heartbeat
+// is skipped the same way a time.Ticker drops ticks
+func (w *heartbeatWheel) advance() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current = (w.current + 1) % len(w.slots)
+	for beat := range w.slots[w.current] {
+		select {
+		case beat <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ssevents
+
+import "encoding/json"
+
+const (
+	eventNameHello = "hello"
+	// ProtocolVersion is the version of the SSE protocol extensions spoken by this package, announced in ServerInfo
+	ProtocolVersion = 1
+)
+
+// ServerInfo are the capabilities the server announces with the hello event when Options SendHello is set, clients
+// can adapt to them and ignore the ones they do not know, keeping the protocol backward compatible.
+type ServerInfo struct {
+	ProtocolVersion int `json:"protocolVersion"`
+	// Replay reports if the server replays missed events to clients resuming with Last-Event-ID
+	Replay bool `json:"replay"`
+	// HeartbeatInterval in milliseconds
+	HeartbeatInterval int64 `json:"heartbeatInterval"`
+	// Filters are the names of the server side event filters the client can request
+	Filters []string `json:"filters,omitempty"`
+	// SubscriberID is the ID of the connection, applications can pass it to EmitToSubscriber
+	SubscriberID string `json:"subscriberId,omitempty"`
+	// Ack reports if the server accepts acknowledgements of the received events on POST /ack
+	Ack bool `json:"ack,omitempty"`
+	// Subscriptions reports if the server accepts topic changes of the connection on POST /sse/subscriptions
+	Subscriptions bool `json:"subscriptions,omitempty"`
+}
+
+func (c *HttpController) serverInfo() ServerInfo {
+	return ServerInfo{
+		ProtocolVersion:   ProtocolVersion,
+		Replay:            c.replay != nil,
+		HeartbeatInterval: c.options.HeartbeatInterval.Milliseconds(),
+		Ack:               c.options.OnAck != nil,
+		Subscriptions:     c.options.DynamicTopics,
+	}
+}
+
+func (c *HttpController) newHelloEvent(info ConnInfo) (*Event, error) {
+	serverInfo := c.serverInfo()
+	serverInfo.SubscriberID = info.ID
+	data, err := json.Marshal(serverInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Event: eventNameHello, Data: string(data)}, nil
+}
+
+// parseServerInfo reads the capabilities of the hello event
+func parseServerInfo(e Event) (ServerInfo, error) {
+	var info ServerInfo
+	err := json.Unmarshal([]byte(e.Data), &info)
+	return info, err
+}
@@ -2,22 +2,37 @@ package ssevents
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const eventNameHeartbeat = "heartbeat"
 
+// ErrEventRejected wraps the error of the Options ValidateEvent for the emitted events it rejects
+var ErrEventRejected = errors.New("sse event rejected")
+
 //go:generate stringer -type=EmitStrategy
 type EmitStrategy int
 
 const (
 	EmitStrategyBlock EmitStrategy = iota
+	// EmitStrategyDrop drops the emitted event for subscribers with a full buffer
 	EmitStrategyDrop
+	// EmitStrategyTimeout waits a short while for the buffer of the subscriber and drops the event after
 	EmitStrategyTimeout
+	// EmitStrategyDropOldest makes room for the emitted event by dropping the oldest event in a full buffer, so slow
+	// subscribers receive the latest events
+	EmitStrategyDropOldest
+	// EmitStrategyDisconnect disconnects subscribers with a full buffer, their clients reconnect and can resume with
+	// Last-Event-ID
+	EmitStrategyDisconnect
 )
 
 type SSEHandler func(ctx context.Context, req *http.Request, res chan<- Event)
@@ -26,21 +41,46 @@ type HttpController struct {
 	log         *slog.Logger
 	shutdownCtx context.Context
 	cancel      context.CancelFunc
-	subscribers *sync.Map
+	hub         *Hub
 	options     *Options
-	emissionFn  func(e Event) func(key, value any) bool
+	metrics     ServerMetrics
+	tracer      Tracer
+	heartbeats  *heartbeatWheel
+	replay      *replayBuffer
+	connections atomic.Int64
+	discarded   atomic.Int64
 }
 
+// NewController creates the controller, options that are not set are defaulted the same way as for NewServer.
 func NewController(options *Options) *HttpController {
+	options = newUpdatedOptions(options)
+	if options.ExpvarName != "" {
+		// NewServer fails on the name taken, the controller is created regardless without publishing the counters
+		if expvarMetrics, err := newExpvarServerMetrics(options.ExpvarName); err != nil {
+			options.Logger.Error("failed publishing sse metrics through expvar", "err", err)
+		} else {
+			options.Metrics = serverMetricsGroup{options.Metrics, expvarMetrics}
+		}
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	ctrl := &HttpController{
 		shutdownCtx: ctx,
 		cancel:      cancel,
 		log:         options.Logger,
-		subscribers: &sync.Map{},
+		hub:         newHub(options, ctx.Done()),
 		options:     options,
-		emissionFn:  createEmitHandlerBasedOnStrategy(options.EmitStrategy, options.Logger),
+		metrics:     options.Metrics,
+		tracer:      options.Tracer,
+	}
+	ctrl.heartbeats = newHeartbeatWheel(options.HeartbeatInterval, ctx.Done())
+	if options.EventStore != nil {
+		ctrl.replay = newReplayBuffer(options.EventStore, options.Logger)
+	} else if options.ReplayBufferSize > 0 {
+		ctrl.replay = newReplayBuffer(NewMemoryEventStore(options.ReplayBufferSize, 0), options.Logger)
+	}
+	if options.Bridge != nil {
+		go ctrl.runBridge()
 	}
 
 	options.Logger.Debug("using emissions strategy", "strategy", options.EmitStrategy)
@@ -53,70 +93,153 @@ func (c *HttpController) Shutdown() error {
 	return nil
 }
 
-func createEmitHandlerBasedOnStrategy(strategy EmitStrategy, logger *slog.Logger) func(e Event) func(key, value any) bool {
-	switch strategy {
-	case EmitStrategyBlock:
-		return func(e Event) func(key any, value any) bool {
-			return func(_, subChannel any) bool {
-				subChannel.(chan Event) <- e
-				return true
-			}
-		}
-	case EmitStrategyDrop:
-		return func(e Event) func(key any, value any) bool {
-			return func(_, subChannel any) bool {
-				select {
-				case subChannel.(chan Event) <- e:
-				default:
-					logger.Debug("dropping event due to slow consumer", "evt", e)
-				}
-				return true
-			}
-		}
-	case EmitStrategyTimeout:
-		return func(e Event) func(key any, value any) bool {
-			return func(_, subChannel any) bool {
-				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
-				defer cancel()
-				select {
-				case subChannel.(chan Event) <- e:
-				case <-ctx.Done():
-					logger.Debug("dropping event due to timeout on slow consumer", "evt", e)
-				}
-				return true
-			}
-		}
-	default:
-		panic("using unknown emit strategy")
-	}
+// activeConnections returns the number of currently open SSE connections
+func (c *HttpController) activeConnections() int {
+	return int(c.connections.Load())
+}
+
+// queuedEvents returns the number of events waiting in the subscriber channels
+func (c *HttpController) queuedEvents() int {
+	return c.hub.queuedEvents()
+}
+
+// QueueDepths returns the number of events waiting to be sent to every connection by its ConnInfo ID, a connection
+// with a growing queue is a slow consumer, see EmitStrategy.
+func (c *HttpController) QueueDepths() map[string]int {
+	return c.hub.queueDepths()
 }
 
-func (c *HttpController) writeAndFlush(rc *http.ResponseController, w http.ResponseWriter, data string) {
-	_, err := fmt.Fprint(w, data)
+// discardedEvents returns the number of events handed to connections that were closed by the shutdown before sending
+// them
+func (c *HttpController) discardedEvents() int {
+	return int(c.discarded.Load())
+}
+
+func (c *HttpController) writeAndFlush(rc *http.ResponseController, w http.ResponseWriter, data []byte) (int, error) {
+	if c.options.WriteTimeout > 0 {
+		// Not supported by all writers, e.g. the httptest recorder, which never block anyway
+		_ = rc.SetWriteDeadline(time.Now().Add(c.options.WriteTimeout))
+	}
+	if c.options.Faults != nil {
+		if n, err := c.options.Faults.inject(rc, w, data); err != nil {
+			return n, err
+		}
+	}
+	n, err := w.Write(data)
 	if err != nil {
-		c.log.Error("sending data to client on SSE failed", "err", err)
-		return
+		c.metrics.WriteFailed()
+		return n, fmt.Errorf("sending data to client on SSE failed: %w", err)
 	}
 
 	err = rc.Flush()
 	if err != nil {
-		c.log.Error("failed flushing the SSE", "err", err)
-		return
+		c.metrics.FlushFailed()
+		return n, fmt.Errorf("failed flushing the SSE: %w", err)
 	}
+
+	return n, nil
 }
 
-func newHeartbeatEvent() *Event {
+// newHeartbeatEvent creates the heartbeat with Options HeartbeatEvent or the default one
+func (c *HttpController) newHeartbeatEvent() *Event {
+	if c.options.HeartbeatEvent != nil {
+		return c.options.HeartbeatEvent()
+	}
 	return &Event{Data: time.Now().String(), Event: eventNameHeartbeat}
 }
 
 func (c *HttpController) SendResponse(rc *http.ResponseController, w http.ResponseWriter, event *Event) error {
-	stringData, transformErr := event.ToResponseString()
-	if transformErr != nil {
-		return fmt.Errorf("failed formatting heartbeat event: %w", transformErr)
+	_, err := c.send(rc, w, event)
+	return err
+}
+
+// send writes the event returning the number of bytes written, the event is serialized into a pooled buffer
+func (c *HttpController) send(rc *http.ResponseController, w http.ResponseWriter, event *Event) (int, error) {
+	buf := getEventBuffer()
+	defer putEventBuffer(buf)
+	event.writeResponse(buf)
+
+	return c.writeAndFlush(rc, w, buf.Bytes())
+}
+
+// heartbeatComment is the keep-alive sent instead of heartbeat events with HeartbeatComment
+var heartbeatComment = []byte(": ping\n\n")
+
+// sendHeartbeat writes a heartbeat event or comment returning the number of bytes written
+func (c *HttpController) sendHeartbeat(rc *http.ResponseController, w http.ResponseWriter) (n int, err error) {
+	if c.options.HeartbeatComment {
+		n, err = c.writeAndFlush(rc, w, heartbeatComment)
+	} else {
+		n, err = c.send(rc, w, c.newHeartbeatEvent())
+	}
+	if err == nil {
+		c.metrics.HeartbeatSent()
 	}
 
-	c.writeAndFlush(rc, w, stringData)
-	return nil
+	return n, err
+}
+
+// emitFailed reports the events that could not be written to the connection to Options OnEmitError
+func (c *HttpController) emitFailed(info ConnInfo, events []Event, err error) {
+	if c.options.OnEmitError == nil {
+		return
+	}
+	for _, e := range events {
+		c.options.OnEmitError(info, e, err)
+	}
+}
+
+// sendBatch writes all the events with a single flush returning the number of bytes written
+func (c *HttpController) sendBatch(rc *http.ResponseController, w http.ResponseWriter, events []Event) (int, error) {
+	buf := getEventBuffer()
+	defer putEventBuffer(buf)
+	for i := range events {
+		events[i].writeResponse(buf)
+	}
+
+	return c.writeAndFlush(rc, w, buf.Bytes())
+}
+
+// collectBatch appends events queued on data to the batch until it holds MaxBatch events, waiting at most MaxDelay
+// for them to arrive. It returns false once data is closed.
+func (c *HttpController) collectBatch(batch []Event, data <-chan Event) ([]Event, bool) {
+	if len(batch) >= c.options.MaxBatch {
+		return batch, true
+	}
+
+	var timeout <-chan time.Time
+	if c.options.MaxDelay > 0 {
+		timer := time.NewTimer(c.options.MaxDelay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for len(batch) < c.options.MaxBatch {
+		if timeout == nil {
+			select {
+			case d, ok := <-data:
+				if !ok {
+					return batch, false
+				}
+				batch = append(batch, d)
+			default:
+				return batch, true
+			}
+			continue
+		}
+
+		select {
+		case d, ok := <-data:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, d)
+		case <-timeout:
+			return batch, true
+		}
+	}
+
+	return batch, true
 }
 
 // Middleware - creates a wrapper for sending SSE to the client with proper cancellation, heartbeat
@@ -150,54 +273,276 @@ func (c *HttpController) SendResponse(rc *http.ResponseController, w http.Respon
 //		}
 //	 }
 func (c *HttpController) Middleware(handler SSEHandler) http.HandlerFunc {
+	return c.middleware(handler, false)
+}
+
+// middleware is Middleware, awaitSubscriber holds the response back until the handler stores the subscriber of the
+// request context
+func (c *HttpController) middleware(handler SSEHandler, awaitSubscriber bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
+		if len(c.options.AllowedOrigins) > 0 && !originAllowed(req, c.options.AllowedOrigins) {
+			c.log.Warn("sse connection rejected", "remote_addr", req.RemoteAddr, "origin", req.Header.Get("Origin"))
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		claims, ok := c.authenticate(w, req, c.options.Authenticate)
+		if !ok {
+			return
+		}
+
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
+		// Connection specific headers are not allowed in HTTP/2, where streams are multiplexed on one connection
+		if req.ProtoMajor == 1 {
+			w.Header().Set("Connection", "keep-alive")
+		}
 		w.Header().Set("Access-Control-Allow-Origin", "*")             // Adjust if needed
 		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS") // not needed
 
 		// You may need this locally for CORS requests
 		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if origin := req.Header.Get("Origin"); origin != "" && len(c.options.AllowedOrigins) > 0 {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
 
-		c.log.Debug("Client connected")
-		rc := http.NewResponseController(w)
+		info := newConnInfo(req, c.options, claims)
+		req = req.WithContext(withConnInfo(req.Context(), info))
 
-		// On-connect heartbeat
-		if err := c.SendResponse(rc, w, newHeartbeatEvent()); err != nil {
-			c.log.Error("failed sending initial heartbeat", "err", err)
+		// Resuming connections get the missed events once the handler stores their subscriber
+		var resume *resumeState
+		var resumeReady <-chan struct{}
+		if lastEventID := req.Header.Get("Last-Event-ID"); lastEventID != "" && c.replay != nil {
+			resume = &resumeState{lastEventID: lastEventID, ready: make(chan struct{})}
+			resumeReady = resume.ready
+			req = req.WithContext(withResumeState(req.Context(), resume))
+		}
+		var replayedSeq uint64
+
+		// EmitStrategyDisconnect closes the connection once its subscriber falls behind, a write blocked on the slow
+		// client is interrupted by an expired deadline
+		var slowConsumer chan struct{}
+		if c.options.EmitStrategy == EmitStrategyDisconnect {
+			slowConsumer = make(chan struct{})
+			var once sync.Once
+			deadlineRC := http.NewResponseController(w)
+			req = req.WithContext(withDisconnect(req.Context(), func() {
+				once.Do(func() {
+					close(slowConsumer)
+					_ = deadlineRC.SetWriteDeadline(time.Now())
+				})
+			}))
+		}
+
+		c.metrics.Connected()
+		c.connections.Add(1)
+		defer func() {
+			c.connections.Add(-1)
+			c.metrics.Disconnected()
+		}()
+
+		connCtx, endSpan := c.tracer.StartConnection(req.Context(), info)
+		connLog := c.log.With("conn_id", info.ID)
+		connLog.Info("sse connection opened", "remote_addr", info.RemoteAddr, "user_id", info.UserID)
+
+		reason := DisconnectReasonHandlerStopped
+		var eventsSent, bytesSent int
+		defer func() {
+			endSpan(reason)
+			connLog.Info("sse connection closed",
+				"duration", time.Since(info.ConnectedAt),
+				"events_sent", eventsSent,
+				"bytes_sent", bytesSent,
+				"reason", reason,
+			)
+		}()
+		if c.options.OnConnect != nil {
+			c.options.OnConnect(info)
+		}
+		if c.options.OnDisconnect != nil {
+			defer c.options.OnDisconnect(info)
 		}
+		if c.options.EnableCompression {
+			if compressor := negotiateCompressor(req, c.options.Compressors); compressor != nil {
+				w.Header().Set("Content-Encoding", compressor.Encoding())
+				w.Header().Add("Vary", "Accept-Encoding")
+				compressed := newCompressedResponseWriter(w, compressor)
+				defer func() { _ = compressed.Close() }()
+				w = compressed
+			}
+		}
+		rc := http.NewResponseController(w)
 
-		heartbeatTicker := time.NewTicker(c.options.HeartbeatInterval)
-		defer heartbeatTicker.Stop()
+		// data is not closed, the handler may still be sending on it until it observes its cancelled ctx
+		data := make(chan Event, c.options.MaxBatch)
+		batch := make([]Event, 0, c.options.MaxBatch)
 
-		data := make(chan Event, 1)
-		defer close(data)
+		// The subscriber of the built-in handler is stored before the response reaches the client, so events emitted
+		// once the client is connected are not missed
+		var subscribed chan struct{}
+		if awaitSubscriber {
+			subscribed = make(chan struct{})
+			var once sync.Once
+			req = req.WithContext(withSubscribed(req.Context(), func() {
+				once.Do(func() { close(subscribed) })
+			}))
+		}
 
-		handlerCtx, handlerCleanup := context.WithCancel(c.shutdownCtx)
+		handlerCtx, handlerCleanup := context.WithCancel(withConnInfo(c.shutdownCtx, info))
 		defer handlerCleanup()
 		go handler(handlerCtx, req, data)
 
+		if awaitSubscriber {
+			select {
+			case <-subscribed:
+			case <-req.Context().Done():
+			case <-c.shutdownCtx.Done():
+			}
+		}
+
+		// On-connect heartbeat
+		n, err := c.sendHeartbeat(rc, w)
+		bytesSent += n
+		if err != nil {
+			c.metrics.HeartbeatFailed()
+			connLog.Error("failed sending initial heartbeat", "err", err)
+			// An injected fault leaves the stream closed or truncated, like a broken connection would
+			if errors.Is(err, ErrFaultInjected) {
+				reason = DisconnectReasonFaultInjected
+				return
+			}
+		}
+		if c.options.SendHello {
+			if hello, helloErr := c.newHelloEvent(info); helloErr != nil {
+				connLog.Error("failed creating hello event", "err", helloErr)
+			} else {
+				n, err = c.send(rc, w, hello)
+				bytesSent += n
+				if err != nil {
+					connLog.Error("failed sending hello", "err", err)
+					if errors.Is(err, ErrFaultInjected) {
+						reason = DisconnectReasonFaultInjected
+						return
+					}
+				}
+			}
+		}
+
+		heartbeat, stopHeartbeat := c.heartbeats.register()
+		defer stopHeartbeat()
+
+		// writeFailed returns the disconnect reason of a failed write, a slow consumer is disconnected by an expired
+		// deadline as well
+		writeFailed := func(err error) string {
+			select {
+			case <-slowConsumer:
+				return DisconnectReasonSlowConsumer
+			default:
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				c.metrics.WriteTimedOut()
+				return DisconnectReasonWriteTimeout
+			}
+			if errors.Is(err, ErrFaultInjected) {
+				return DisconnectReasonFaultInjected
+			}
+			return DisconnectReasonWriteFailed
+		}
+
+		sendReplay := func() bool {
+			resumeReady = nil
+			replayedSeq = resume.lastSeq
+			if !resume.found {
+				connLog.Debug("sse last event id not found for replay", "last_event_id", resume.lastEventID)
+			}
+			if len(resume.missed) == 0 {
+				return true
+			}
+			n, err = c.sendBatch(rc, w, resume.missed)
+			bytesSent += n
+			if err != nil {
+				connLog.Error("failed replaying events", "events", len(resume.missed), "err", err)
+				c.emitFailed(info, resume.missed, err)
+				reason = writeFailed(err)
+				return false
+			}
+			eventsSent += len(resume.missed)
+			return true
+		}
+
 		clientGone := req.Context().Done()
 		for {
 			select {
 			case <-clientGone:
-				c.log.Debug("Client disconnected")
+				reason = DisconnectReasonClientGone
+				return
+			case <-slowConsumer:
+				reason = DisconnectReasonSlowConsumer
 				return
 			case <-c.shutdownCtx.Done():
-				c.log.Debug("shutting down HttpController")
+				reason = DisconnectReasonShutdown
+				c.discarded.Add(int64(len(data)))
+				if c.options.ShutdownEvent != nil {
+					n, err = c.send(rc, w, c.options.ShutdownEvent)
+					bytesSent += n
+					if err != nil {
+						connLog.Error("failed sending the shutdown event", "err", err)
+					}
+				}
 				return
-			case <-heartbeatTicker.C:
-				if err := c.SendResponse(rc, w, newHeartbeatEvent()); err != nil {
-					c.log.Error("failed sending sse", "err", err)
+			case <-heartbeat:
+				n, err = c.sendHeartbeat(rc, w)
+				bytesSent += n
+				if err != nil {
+					c.metrics.HeartbeatFailed()
+					connLog.Error("failed sending heartbeat", "err", err)
+					reason = writeFailed(err)
+					return
+				}
+			case <-resumeReady:
+				if !sendReplay() {
 					return
 				}
 			case d, ok := <-data:
 				if !ok {
 					return
 				}
-				if err := c.SendResponse(rc, w, &d); err != nil {
-					c.log.Error("failed sending sse", "err", err)
+				select {
+				case <-resumeReady:
+					// The replay goes out before the live events
+					if !sendReplay() {
+						return
+					}
+				default:
+				}
+				var open bool
+				batch, open = c.collectBatch(append(batch[:0], d), data)
+				if replayedSeq > 0 {
+					batch = slices.DeleteFunc(batch, func(e Event) bool {
+						return e.seq != 0 && e.seq <= replayedSeq
+					})
+					if len(batch) == 0 {
+						if !open {
+							return
+						}
+						continue
+					}
+				}
+				n, err = c.sendBatch(rc, w, batch)
+				bytesSent += n
+				if err != nil {
+					args := append(eventLogArgs(d, c.options.RedactEvent, false), "events", len(batch), "err", err)
+					connLog.Error("failed sending events", args...)
+					c.emitFailed(info, batch, err)
+					reason = writeFailed(err)
+					return
+				}
+				eventsSent += len(batch)
+				for _, delivered := range batch {
+					c.tracer.Delivered(connCtx, delivered.Context(), delivered)
+				}
+				if !open {
 					return
 				}
 			}
@@ -208,19 +553,221 @@ func (c *HttpController) Middleware(handler SSEHandler) http.HandlerFunc {
 // Emit strategies: no-buffer (block) , buffer (block), buffer (drop)
 
 func (c *HttpController) Emit(e Event) {
-	c.log.Debug("emitting event", "event", e)
-	c.subscribers.Range(c.emissionFn(e))
+	c.EmitContext(e.Context(), e)
+}
+
+// EmitJSON sends an event with the given name and the value marshalled to JSON as its data to all subscribers, see
+// NewJSONEvent
+func (c *HttpController) EmitJSON(event string, v any) error {
+	return c.EmitEncoded(JSONCodec, event, v)
+}
+
+// EmitEncoded sends an event with the given name and the value encoded by the codec as its data to all subscribers,
+// see NewEncodedEvent
+func (c *HttpController) EmitEncoded(codec Codec, event string, v any) error {
+	e, err := NewEncodedEvent(codec, event, v)
+	if err != nil {
+		return err
+	}
+
+	return c.emitContext(e.Context(), e)
+}
+
+// EmitContext is like Emit but carries the ctx, e.g. of an incoming request, into the delivery of the event so its
+// latency can be traced end to end. Deliveries blocked on slow subscribers are dropped once the ctx is done.
+func (c *HttpController) EmitContext(ctx context.Context, e Event) {
+	_ = c.emitContext(ctx, e)
+}
+
+// emitContext is EmitContext returning the error of the Options ValidateEvent
+func (c *HttpController) emitContext(ctx context.Context, e Event) error {
+	e = c.withEventID(e)
+	if err := c.validate(e); err != nil {
+		return err
+	}
+	if c.publish(ctx, e) {
+		return nil
+	}
+	c.emitLocal(ctx, e)
+
+	return nil
+}
+
+// EmitSync sends the event to the subscribers connected to this instance, bypassing the Bridge, and reports how many
+// it was delivered to and how many dropped it due to the EmitStrategy. Deliveries still blocked when the ctx is done
+// are dropped and its error is returned.
+func (c *HttpController) EmitSync(ctx context.Context, e Event) (delivered int, dropped int, err error) {
+	if err = ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+	e = c.withEventID(e)
+	if err = c.validate(e); err != nil {
+		return 0, 0, err
+	}
+	outcome := c.emitLocal(ctx, e)
+	return outcome.delivered, outcome.dropped, ctx.Err()
+}
+
+// emitLocal sends the event to the subscribers connected to this instance
+func (c *HttpController) emitLocal(ctx context.Context, e Event) emitOutcome {
+	emitCtx, end := c.tracer.StartEmit(ctx, e)
+	defer end()
+	e = e.WithContext(emitCtx)
+	if c.options.PropagateTraceContext {
+		e = c.withTraceContext(e)
+	}
+
+	if c.replay != nil {
+		e = c.replay.add(ctx, e)
+	}
+	c.metrics.Emitted()
+	outcome := c.hub.fanout(e, nil)
+	c.logEmit(e, outcome)
+	c.audit(ctx, e, "", outcome)
+
+	return outcome
+}
+
+// validate runs the Options ValidateEvent, reporting the rejected event wrapped with ErrEventRejected
+func (c *HttpController) validate(e Event) error {
+	if c.options.ValidateEvent == nil {
+		return nil
+	}
+	if err := c.options.ValidateEvent(e); err != nil {
+		c.metrics.EventRejected()
+		c.log.Warn("sse event rejected", append(eventLogArgs(e, c.options.RedactEvent, false), "error", err)...)
+		return fmt.Errorf("%w: %w", ErrEventRejected, err)
+	}
+
+	return nil
+}
+
+// withEventID assigns an ID to the event without one when AutoEventID is set
+func (c *HttpController) withEventID(e Event) Event {
+	if e.Id == "" && c.options.AutoEventID != nil {
+		e.Id = c.options.AutoEventID()
+	}
+
+	return e
+}
+
+// withTraceContext adds the trace context of the event as its extensions, copying them as the map may be shared
+func (c *HttpController) withTraceContext(e Event) Event {
+	carrier := make(map[string]string)
+	c.tracer.Inject(e.Context(), carrier)
+	if len(carrier) == 0 {
+		return e
+	}
+
+	extensions := make(map[string]string, len(e.Extensions)+len(carrier))
+	for name, value := range e.Extensions {
+		extensions[name] = value
+	}
+	for name, value := range carrier {
+		extensions[name] = value
+	}
+	e.Extensions = extensions
+
+	return e
+}
+
+func (c *HttpController) logEmit(e Event, outcome emitOutcome) {
+	if !c.log.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	args := append(eventLogArgs(e, c.options.RedactEvent, true),
+		"delivered", outcome.delivered,
+		"dropped", outcome.dropped,
+	)
+	c.log.Debug("sse event emitted", args...)
+}
+
+// EmitToSubscriber sends an event only to the connection with the given ConnInfo ID, returns false if there is no such
+// connection on this controller.
+func (c *HttpController) EmitToSubscriber(id string, e Event) bool {
+	n, _ := c.emitWhere(e, "subscriber:"+id, func(info ConnInfo) bool {
+		return info.ID == id
+	})
+	return n > 0
+}
+
+// EmitToUser sends an event to all connections of the user resolved through Options UserIDFunc, returns the number of
+// connections the event was sent to.
+func (c *HttpController) EmitToUser(userID string, e Event) int {
+	if userID == "" {
+		return 0
+	}
+	n, _ := c.emitWhere(e, "user:"+userID, func(info ConnInfo) bool {
+		return info.UserID == userID
+	})
+	return n
+}
+
+// emitWhere sends the event to the connections accepted by match, returning their number or the error of the Options
+// ValidateEvent
+func (c *HttpController) emitWhere(e Event, target string, match func(info ConnInfo) bool) (int, error) {
+	e = c.withEventID(e)
+	if err := c.validate(e); err != nil {
+		return 0, err
+	}
+	c.metrics.Emitted()
+	outcome := c.hub.fanout(e, match)
+	c.logEmit(e, outcome)
+	c.audit(e.Context(), e, target, outcome)
+
+	return outcome.delivered + outcome.dropped, nil
 }
 
 func (c *HttpController) HasSubscriber(key any) bool {
-	_, ok := c.subscribers.Load(key)
+	_, ok := c.hub.subscribers.load(key)
 	return ok
 }
 
+// Store registers the subscriber channel under the key, when the key is the SSE request context the connection
+// information is stored alongside, enabling targeted emits.
 func (c *HttpController) Store(key any, subCh chan Event) {
-	c.subscribers.Store(key, subCh)
+	c.StoreFiltered(key, subCh, nil)
+}
+
+// StoreFiltered is Store with a filter, e.g. derived from the query parameters of the SSE request, only the events it
+// accepts are sent to the subscriber. A nil filter accepts all events.
+func (c *HttpController) StoreFiltered(key any, subCh chan Event, filter Filter) {
+	sub := &subscriber{ch: subCh, filter: filter}
+	ctx, isCtx := key.(context.Context)
+	if isCtx {
+		sub.info, _ = ConnInfoFromContext(ctx)
+		sub.disconnect, _ = disconnectFromContext(ctx)
+	}
+	if resume, ok := c.resumeState(ctx, isCtx); ok {
+		c.replay.storeAndResume(resume, filter, func() {
+			c.hub.subscribers.store(key, sub)
+		})
+		markSubscribed(ctx)
+		return
+	}
+	c.hub.subscribers.store(key, sub)
+	if isCtx {
+		markSubscribed(ctx)
+	}
+}
+
+// resumeState returns the state of a resuming connection whose missed events are not collected yet
+func (c *HttpController) resumeState(ctx context.Context, isCtx bool) (*resumeState, bool) {
+	if !isCtx || c.replay == nil {
+		return nil, false
+	}
+	resume, ok := resumeStateFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-resume.ready:
+		return nil, false
+	default:
+		return resume, true
+	}
 }
 
 func (c *HttpController) Delete(key any) {
-	c.subscribers.Delete(key)
+	c.hub.subscribers.delete(key)
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"expvar"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 func respondError(w http.ResponseWriter, err error) {
@@ -16,37 +18,104 @@ func respondError(w http.ResponseWriter, err error) {
 	}
 }
 
-func createMux(sseCtrl *HttpController, options *Options, routes map[string]http.HandlerFunc) *http.ServeMux {
-	mux := http.NewServeMux()
+// maxControlBodySize bounds the JSON bodies of the control endpoints next to the SSE endpoint, like /ack
+const maxControlBodySize = 64 << 10
 
-	sseUrl := "/sse"
-	if options.SseUrl != "" {
-		sseUrl = options.SseUrl
+// decodeBody decodes the JSON body of a control endpoint into v, responding with an error when it is invalid or
+// larger than maxControlBodySize
+func decodeBody(w http.ResponseWriter, req *http.Request, v any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxControlBodySize)).Decode(v)
+	if err == nil {
+		return true
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "failed: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return false
 	}
+	respondError(w, err)
+	return false
+}
+
+func createMux(sseCtrl *HttpController, routes map[string]http.HandlerFunc) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	sseUrl := sseCtrl.sseUrl()
 
 	for route, handler := range routes {
 		mux.HandleFunc(route, handler)
 	}
 
+	if sseCtrl.options.ExpvarName != "" && routes["GET /debug/vars"] == nil {
+		mux.Handle("GET /debug/vars", expvar.Handler())
+	}
+
+	if sseCtrl.options.MetricsHandler != nil {
+		mux.Handle("GET "+sseCtrl.options.MetricsPath, sseCtrl.options.MetricsHandler)
+	}
+
+	if sseCtrl.options.EnablePprof {
+		mountPprof(mux, sseCtrl.options.PprofPath, sseCtrl.options.PprofAuthorize)
+	}
+
 	if routes["GET /"] == nil {
 		mux.HandleFunc("GET /", func(w http.ResponseWriter, req *http.Request) {
 			// Catch unmapped requests
-			sseCtrl.log.Info(fmt.Sprintf("[Unmapped]: %s - %s", req.Method, req.URL.RawQuery))
+			sseCtrl.log.Info("unmapped request", "method", req.Method, "path", req.URL.Path, "query", req.URL.RawQuery)
 		})
 	}
 
-	mux.HandleFunc("GET "+sseUrl, sseCtrl.Middleware(func(ctx context.Context, req *http.Request, res chan<- Event) {
+	mux.Handle("GET "+sseUrl, sseCtrl.Handler())
+
+	if !sseCtrl.options.DisableEmitEndpoint && routes["POST /emit"] == nil {
+		mux.HandleFunc("POST /emit", newEmitHandler(sseCtrl))
+	}
+
+	if ackRoute := "POST " + ackPathFor(sseUrl); sseCtrl.options.OnAck != nil && routes[ackRoute] == nil {
+		mux.HandleFunc(ackRoute, newAckHandler(sseCtrl))
+	}
+
+	if sseCtrl.options.EnableStats && routes["GET "+sseUrl+statsPath] == nil {
+		mux.HandleFunc("GET "+sseUrl+statsPath, newStatsHandler(sseCtrl))
+	}
+
+	if sseCtrl.options.DynamicTopics && routes["POST "+sseUrl+subscriptionsPath] == nil {
+		mux.HandleFunc("POST "+sseUrl+subscriptionsPath, newSubscriptionsHandler(sseCtrl))
+	}
+
+	return mux
+}
+
+// Handler returns the SSE endpoint of the controller, for mounting on a path of your own router, e.g.
+// mux.Handle("GET /events", ctrl.Handler()). Every connection receives the events emitted to the controller.
+func (c *HttpController) Handler() http.Handler {
+	return c.middleware(subscribeHandler(c), true)
+}
+
+// sseUrl returns the path of the main SSE endpoint
+func (c *HttpController) sseUrl() string {
+	if c.options.SseUrl != "" {
+		return c.options.SseUrl
+	}
+	return "/sse"
+}
+
+// subscribeHandler is the SSEHandler of the SSE endpoints, it stores a subscriber of the controller for the connection
+// and forwards the events emitted to it
+func subscribeHandler(sseCtrl *HttpController) SSEHandler {
+	return func(ctx context.Context, req *http.Request, res chan<- Event) {
 		subscribeCh := make(chan Event, sseCtrl.options.BufferSize)
 		if sseCtrl.HasSubscriber(req.Context()) {
 			sseCtrl.log.Warn("existing context subscriber should not exist, overriding it")
 		}
 
-		sseCtrl.Store(req.Context(), subscribeCh)
-		defer func() {
-			sseCtrl.log.Debug("Subscriber: cleaning up")
-			sseCtrl.Delete(req.Context())
-			close(subscribeCh)
-		}()
+		var filter Filter
+		if sseCtrl.options.SubscriberFilter != nil {
+			filter = sseCtrl.options.SubscriberFilter(req)
+		}
+		sseCtrl.StoreFiltered(req.Context(), subscribeCh, filter)
+		// subscribeCh is not closed, an emit holding the subscriber may still be sending on it
+		defer sseCtrl.Delete(req.Context())
 
 		for {
 			select {
@@ -60,9 +129,43 @@ func createMux(sseCtrl *HttpController, options *Options, routes map[string]http
 				return
 			}
 		}
-	}))
+	}
+}
+
+// newEmitHandler emits the JSON or text body of the request to all subscribers, or to the ones of the topic query
+// parameter
+func newEmitHandler(sseCtrl *HttpController) http.HandlerFunc {
+	limiter := newEmitLimiter(sseCtrl.options)
+	return func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := sseCtrl.authenticate(w, req, sseCtrl.options.AuthenticateEmit); !ok {
+			return
+		}
+		if limiter != nil {
+			if ok, wait := limiter.allow(req); !ok {
+				sseCtrl.metrics.EmitRateLimited()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+		}
 
-	mux.HandleFunc("POST /emit", func(w http.ResponseWriter, req *http.Request) {
+		ctx := sseCtrl.tracer.Extract(req.Context(), req.Header)
+		if sseCtrl.options.Auditor != nil {
+			ctx = WithActor(ctx, sseCtrl.options.EmitActor(req))
+		}
+		// The topic query parameter limits the event to the subscribers of the topic
+		emit := sseCtrl.emitContext
+		if topic := req.URL.Query().Get(topicQueryParam); topic != "" {
+			emit = func(ctx context.Context, e Event) error {
+				_, err := sseCtrl.emitTo(topic, e.WithContext(ctx))
+				return err
+			}
+		}
+		emitOrReject := func(e Event) {
+			if err := emit(ctx, e); err != nil {
+				http.Error(w, "failed: "+err.Error(), http.StatusUnprocessableEntity)
+			}
+		}
 		// Handle JSON
 		if contentType := req.Header.Get("Content-Type"); contentType == "application/json" {
 			var event Event
@@ -74,8 +177,12 @@ func createMux(sseCtrl *HttpController, options *Options, routes map[string]http
 				respondError(w, errors.New("data should not be empty"))
 				return
 			}
+			if err := event.Validate(); err != nil {
+				respondError(w, err)
+				return
+			}
 
-			sseCtrl.Emit(event)
+			emitOrReject(event)
 			return
 		}
 
@@ -90,8 +197,6 @@ func createMux(sseCtrl *HttpController, options *Options, routes map[string]http
 			return
 		}
 
-		sseCtrl.Emit(Event{Data: string(data)})
-	})
-
-	return mux
+		emitOrReject(Event{Data: string(data)})
+	}
 }
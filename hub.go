@@ -0,0 +1,266 @@
+package ssevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrEventDropped is passed to Options OnEmitError for the events the emit strategy did not deliver to a subscriber
+var ErrEventDropped = errors.New("sse event dropped")
+
+// Hub fans out published events to its subscribers independently of the transport, so the same hub can power SSE,
+// WebSockets or in-process consumers. The HttpController delivers its events through a Hub of its own.
+type Hub struct {
+	log         *slog.Logger
+	subscribers *registry
+	options     *Options
+	metrics     ServerMetrics
+	deliver     deliverFn
+	fanoutJobs  chan fanoutJob
+	done        <-chan struct{}
+	cancel      context.CancelFunc
+}
+
+// NewHub creates the hub, it uses the EmitStrategy, BufferSize, FanoutWorkers, FanoutBatchSize, RegistryShards,
+// Metrics, Logger and RedactEvent options, which are defaulted the same way as for NewServer. Close stops its fanout
+// workers.
+func NewHub(options *Options) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := newHub(newUpdatedOptions(options), ctx.Done())
+	hub.cancel = cancel
+
+	return hub
+}
+
+func newHub(options *Options, done <-chan struct{}) *Hub {
+	hub := &Hub{
+		log:         options.Logger,
+		subscribers: newRegistry(options.RegistryShards),
+		options:     options,
+		metrics:     options.Metrics,
+		done:        done,
+	}
+	hub.deliver = hub.deliverFnFor(options.EmitStrategy)
+	if options.FanoutWorkers > 0 {
+		hub.startFanoutWorkers(options.FanoutWorkers)
+	}
+
+	return hub
+}
+
+// Subscribe registers a subscriber receiving the published events on the returned channel, buffered with BufferSize,
+// until the cancel function is called or the ctx is done. The channel is never closed, stop reading it once
+// unsubscribed. EmitStrategyDisconnect unsubscribes slow subscribers.
+func (h *Hub) Subscribe(ctx context.Context) (<-chan Event, func()) {
+	done := make(chan struct{})
+	sub := &subscriber{ch: make(chan Event, h.options.BufferSize), done: done}
+
+	var once sync.Once
+	remove := func() {
+		h.subscribers.delete(sub)
+		close(done)
+	}
+	stop := context.AfterFunc(ctx, func() { once.Do(remove) })
+	cancel := func() {
+		once.Do(func() {
+			stop()
+			remove()
+		})
+	}
+	sub.disconnect = cancel
+	h.subscribers.store(sub, sub)
+	// A ctx done before the subscriber was stored already removed it, delete it again to not leave it registered
+	select {
+	case <-done:
+		h.subscribers.delete(sub)
+	default:
+	}
+
+	return sub.ch, cancel
+}
+
+// Publish hands the event to all subscribers according to the EmitStrategy and returns the number it was delivered
+// to
+func (h *Hub) Publish(e Event) int {
+	h.metrics.Emitted()
+	return h.fanout(e, nil).delivered
+}
+
+// Subscribers returns the number of current subscribers
+func (h *Hub) Subscribers() int {
+	return h.subscribers.len()
+}
+
+// Close stops the fanout workers of a hub created with NewHub
+func (h *Hub) Close() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+}
+
+// queueDepths returns the number of events waiting in the channel of every subscriber with connection information by
+// its connection ID
+func (h *Hub) queueDepths() map[string]int {
+	depths := make(map[string]int)
+	h.subscribers.each(func(sub *subscriber) {
+		if sub.info.ID != "" {
+			depths[sub.info.ID] = len(sub.ch)
+		}
+	})
+	return depths
+}
+
+// queuedEvents returns the number of events waiting in the subscriber channels
+func (h *Hub) queuedEvents() int {
+	var queued int
+	h.subscribers.each(func(sub *subscriber) {
+		queued += len(sub.ch)
+	})
+	return queued
+}
+
+// subscriber is the value held by the subscribers registry
+type subscriber struct {
+	ch     chan Event
+	info   ConnInfo
+	filter Filter
+	// done is closed once the subscriber is gone, unblocking a pending delivery, it is nil for subscribers of the
+	// HttpController whose channels are drained until they are deleted
+	done <-chan struct{}
+	// disconnect closes the connection of the subscriber for EmitStrategyDisconnect, it is safe to call repeatedly
+	disconnect func()
+	// topics replace the Topics of the info once changed through the subscriptions endpoint
+	topics atomic.Pointer[[]string]
+}
+
+// connInfo returns the information of the connection with its current topics
+func (s *subscriber) connInfo() ConnInfo {
+	info := s.info
+	if topics := s.topics.Load(); topics != nil {
+		info.Topics = *topics
+	}
+	return info
+}
+
+func (s *subscriber) accepts(e Event) bool {
+	return s.filter == nil || s.filter(e)
+}
+
+// emitOutcome counts the subscribers an event was delivered to or dropped for during a single emit
+type emitOutcome struct {
+	delivered int
+	dropped   int
+}
+
+// deliverFn hands the event over to a single subscriber according to the EmitStrategy
+type deliverFn func(sub *subscriber, e Event, outcome *emitOutcome)
+
+// deliverFnFor binds the delivery method of the strategy once, so emitting does not allocate a handler per event
+func (h *Hub) deliverFnFor(strategy EmitStrategy) deliverFn {
+	switch strategy {
+	case EmitStrategyBlock:
+		return h.deliverBlock
+	case EmitStrategyDrop:
+		return h.deliverDrop
+	case EmitStrategyTimeout:
+		return h.deliverTimeout
+	case EmitStrategyDropOldest:
+		return h.deliverDropOldest
+	case EmitStrategyDisconnect:
+		return h.deliverDisconnect
+	default:
+		panic("using unknown emit strategy")
+	}
+}
+
+func (h *Hub) deliverBlock(sub *subscriber, e Event, outcome *emitOutcome) {
+	// Subscribers with room receive the event even when the emit context is done already
+	select {
+	case sub.ch <- e:
+		outcome.delivered++
+		return
+	default:
+	}
+	select {
+	case sub.ch <- e:
+		outcome.delivered++
+	case <-sub.done:
+	case <-e.Context().Done():
+		h.drop(sub, e, outcome, "emit context done")
+	}
+}
+
+func (h *Hub) deliverDrop(sub *subscriber, e Event, outcome *emitOutcome) {
+	select {
+	case sub.ch <- e:
+		outcome.delivered++
+	default:
+		h.drop(sub, e, outcome, "slow consumer")
+	}
+}
+
+func (h *Hub) deliverTimeout(sub *subscriber, e Event, outcome *emitOutcome) {
+	timer := time.NewTimer(20 * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case sub.ch <- e:
+		outcome.delivered++
+	case <-sub.done:
+	case <-e.Context().Done():
+		h.drop(sub, e, outcome, "emit context done")
+	case <-timer.C:
+		h.drop(sub, e, outcome, "timeout on slow consumer")
+	}
+}
+
+func (h *Hub) deliverDropOldest(sub *subscriber, e Event, outcome *emitOutcome) {
+	// Concurrent emits can take the freed slot, so the event is dropped after a few attempts
+	for range 3 {
+		select {
+		case sub.ch <- e:
+			outcome.delivered++
+			return
+		default:
+		}
+		select {
+		case oldest := <-sub.ch:
+			h.reportDrop(sub, oldest, "dropped oldest on slow consumer")
+		default:
+		}
+	}
+	h.drop(sub, e, outcome, "slow consumer")
+}
+
+func (h *Hub) deliverDisconnect(sub *subscriber, e Event, outcome *emitOutcome) {
+	select {
+	case sub.ch <- e:
+		outcome.delivered++
+	default:
+		if sub.disconnect != nil {
+			sub.disconnect()
+		}
+		h.drop(sub, e, outcome, "disconnected slow consumer")
+	}
+}
+
+func (h *Hub) drop(sub *subscriber, e Event, outcome *emitOutcome, reason string) {
+	outcome.dropped++
+	h.reportDrop(sub, e, reason)
+}
+
+// reportDrop reports the event that was not delivered to the subscriber
+func (h *Hub) reportDrop(sub *subscriber, e Event, reason string) {
+	h.metrics.Dropped(h.options.EmitStrategy)
+	if h.options.OnEmitError != nil {
+		h.options.OnEmitError(sub.connInfo(), e, fmt.Errorf("%w: %s", ErrEventDropped, reason))
+	}
+	if h.log.Enabled(context.Background(), slog.LevelDebug) {
+		args := append(eventLogArgs(e, h.options.RedactEvent, true), "conn_id", sub.info.ID, "reason", reason)
+		h.log.Debug("sse event dropped", args...)
+	}
+}
@@ -0,0 +1,215 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksMinRefresh limits fetches triggered by unknown key IDs, so forged tokens can not flood the JWKS endpoint
+const jwksMinRefresh = 10 * time.Second
+
+// jwksRetryBackoff delays the next fetch after failed ones, so an unavailable JWKS endpoint is not hit on every token
+var jwksRetryBackoff = ssevents.Backoff{InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2, Jitter: 0.2}
+
+// jwks caches the keys of a JWKS URL by their key ID
+type jwks struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetchedAt time.Time
+	// fetching is closed once the fetch in flight completes, nil without one
+	fetching chan struct{}
+	fetchErr error
+	failures int
+	retryAt  time.Time
+}
+
+func newJWKS(url string, client *http.Client, interval time.Duration) *jwks {
+	return &jwks{url: url, client: client, interval: interval}
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jwks) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	k.mu.Lock()
+	key, ok := k.keys[kid]
+	stale := time.Since(k.fetchedAt) > k.interval
+	due := (!ok && time.Since(k.fetchedAt) > jwksMinRefresh) || stale
+	var done chan struct{}
+	if due && time.Now().After(k.retryAt) {
+		// A single fetch runs at a time, concurrent callers wait for the one in flight
+		if done = k.fetching; done == nil {
+			done = make(chan struct{})
+			k.fetching = done
+			go k.refresh(done)
+		}
+	}
+	fetchErr := k.fetchErr
+	k.mu.Unlock()
+
+	// Known keys keep verifying tokens while stale keys are refreshed
+	if ok {
+		return key, nil
+	}
+	if done != nil {
+		<-done
+		k.mu.Lock()
+		key, ok = k.keys[kid]
+		fetchErr = k.fetchErr
+		k.mu.Unlock()
+	}
+	if !ok {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+// refresh fetches the keys without holding the lock and closes done once they are stored, failures delay the next
+// fetch with jwksRetryBackoff
+func (k *jwks) refresh(done chan struct{}) {
+	keys, err := k.fetch()
+
+	k.mu.Lock()
+	if err != nil {
+		k.failures++
+		k.retryAt = time.Now().Add(jwksRetryBackoff.Delay(k.failures - 1))
+	} else {
+		k.keys = keys
+		k.fetchedAt = time.Now()
+		k.failures = 0
+		k.retryAt = time.Time{}
+	}
+	k.fetchErr = err
+	k.fetching = nil
+	k.mu.Unlock()
+
+	close(done)
+}
+
+// fetch returns the keys of the JWKS URL, keys of unsupported types are skipped
+func (k *jwks) fetch() (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating JWKS request: %w", err)
+	}
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching JWKS: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed fetching JWKS: unexpected status %d", res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, keyErr := jwk.publicKey(); keyErr == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+func (j jsonWebKey) publicKey() (any, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
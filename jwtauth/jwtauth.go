@@ -0,0 +1,134 @@
+// Package jwtauth verifies JSON Web Tokens of SSE connections, rejecting callers without a valid token and exposing
+// the verified claims through ssevents.ConnInfo. Keys are either static or fetched from a JWKS URL.
+//
+//	auth, err := jwtauth.New(jwtauth.Options{JWKSURL: "https://issuer.example.com/.well-known/jwks.json"})
+//	server, err := ssevents.NewServer(&ssevents.Options{Authenticate: auth.Authenticate})
+//
+// Browsers can not set headers on an EventSource, so besides the Authorization bearer header the token is also read
+// from the access_token query parameter.
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	tokenQueryParamDefault = "access_token"
+	jwksRefreshDefault     = time.Hour
+)
+
+var (
+	// ErrMissingToken is returned when the request carries no token
+	ErrMissingToken = errors.New("missing token")
+	// ErrNoKey is returned when neither a Key nor a JWKSURL is configured
+	ErrNoKey = errors.New("either Key or JWKSURL is required")
+)
+
+type Options struct {
+	// Key verifies the token signatures, a crypto public key for asymmetric algorithms or []byte for HMAC
+	Key any
+	// JWKSURL is fetched for the verification keys, selected by the kid header of the token. Used when Key is not set.
+	JWKSURL string
+	// JWKSRefreshInterval defines how often the keys are fetched again, unknown key IDs also trigger a fetch.
+	// Default is 1 hour.
+	JWKSRefreshInterval time.Duration
+	// HTTPClient is used for fetching the JWKS, default is http.DefaultClient
+	HTTPClient *http.Client
+	// Algorithms are the accepted signing algorithms, default is RS256, ES256 and EdDSA for JWKS and asymmetric keys,
+	// HS256 for []byte keys.
+	Algorithms []string
+	// Issuer, when set, is required to match the iss claim
+	Issuer string
+	// Audience, when set, is required to be present in the aud claim
+	Audience string
+	// Leeway tolerates clock skew when validating exp, nbf and iat
+	Leeway time.Duration
+	// TokenQueryParam overrides the default query parameter access_token the token is read from
+	TokenQueryParam string
+}
+
+// Authenticator verifies the tokens of requests, its Authenticate method is meant for ssevents.Options Authenticate
+type Authenticator struct {
+	parser     *jwt.Parser
+	keyFunc    jwt.Keyfunc
+	queryParam string
+}
+
+// New creates the Authenticator, the JWKS, if configured, is fetched lazily on the first request
+func New(options Options) (*Authenticator, error) {
+	if options.Key == nil && options.JWKSURL == "" {
+		return nil, ErrNoKey
+	}
+	if options.TokenQueryParam == "" {
+		options.TokenQueryParam = tokenQueryParamDefault
+	}
+
+	algorithms := options.Algorithms
+	if len(algorithms) == 0 {
+		if _, ok := options.Key.([]byte); ok {
+			algorithms = []string{"HS256"}
+		} else {
+			algorithms = []string{"RS256", "ES256", "EdDSA"}
+		}
+	}
+
+	parserOptions := []jwt.ParserOption{jwt.WithValidMethods(algorithms), jwt.WithLeeway(options.Leeway)}
+	if options.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.Issuer))
+	}
+	if options.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.Audience))
+	}
+
+	auth := &Authenticator{
+		parser:     jwt.NewParser(parserOptions...),
+		queryParam: options.TokenQueryParam,
+	}
+	if options.Key != nil {
+		key := options.Key
+		auth.keyFunc = func(*jwt.Token) (any, error) {
+			return key, nil
+		}
+	} else {
+		if options.JWKSRefreshInterval <= 0 {
+			options.JWKSRefreshInterval = jwksRefreshDefault
+		}
+		if options.HTTPClient == nil {
+			options.HTTPClient = http.DefaultClient
+		}
+		auth.keyFunc = newJWKS(options.JWKSURL, options.HTTPClient, options.JWKSRefreshInterval).keyFunc
+	}
+
+	return auth, nil
+}
+
+// Authenticate verifies the token of the request returning its claims
+func (a *Authenticator) Authenticate(req *http.Request) (map[string]any, error) {
+	raw := tokenFromRequest(req, a.queryParam)
+	if raw == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(raw, claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return claims, nil
+}
+
+func tokenFromRequest(req *http.Request, queryParam string) string {
+	if header := req.Header.Get("Authorization"); header != "" {
+		if scheme, token, found := strings.Cut(header, " "); found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	return req.URL.Query().Get(queryParam)
+}
@@ -0,0 +1,162 @@
+// Package kafkasource provides a ssevents.Source that forwards the records of Kafka topics as events. It works with any
+// Kafka client through the Consumer interface, the topics and the consumer group are configured on the client:
+//
+//	source, err := kafkasource.New(kafkasource.Options{Consumer: franzConsumer{client}})
+//	server, err := ssevents.NewServer(&ssevents.Options{Sources: []ssevents.Source{source}, ReplayBufferSize: 1000})
+//
+// The ID of every event is the position of its record, e.g. orders/0/42, so browsers reconnecting with the
+// Last-Event-ID header get the missed events replayed by the server, and ParseEventID maps the ID back to the offset
+// for resuming from Kafka when the replay buffer no longer holds it.
+package kafkasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const retryDelayDefault = 2 * time.Second
+
+var ErrMissingConsumer = errors.New("kafkasource: Consumer is required")
+
+// Record is a record consumed from a partition of a topic
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// Consumer is the part of a Kafka consumer group client used by the source, an adapter over e.g. franz-go, sarama or
+// confluent-kafka-go
+type Consumer interface {
+	// Poll blocks until records are available or the ctx is cancelled
+	Poll(ctx context.Context) ([]Record, error)
+	// Commit marks the records as processed for the consumer group
+	Commit(ctx context.Context, records []Record) error
+}
+
+type Options struct {
+	// Consumer of the topics, required
+	Consumer Consumer
+	// EventName resolves the event name of a record, default is the "event" header or the topic
+	EventName func(r Record) string
+	// RetryDelay defines how long to wait before polling again after a failure, default is 2s
+	RetryDelay time.Duration
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Source is a ssevents.Source emitting Kafka records.
+type Source struct {
+	consumer   Consumer
+	eventName  func(r Record) string
+	retryDelay time.Duration
+	logger     *slog.Logger
+}
+
+var _ ssevents.Source = (*Source)(nil)
+
+func New(options Options) (*Source, error) {
+	if options.Consumer == nil {
+		return nil, ErrMissingConsumer
+	}
+	s := &Source{
+		consumer:   options.Consumer,
+		eventName:  eventNameDefault,
+		retryDelay: retryDelayDefault,
+		logger:     slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	if options.EventName != nil {
+		s.eventName = options.EventName
+	}
+	if options.RetryDelay > 0 {
+		s.retryDelay = options.RetryDelay
+	}
+	if options.Logger != nil {
+		s.logger = options.Logger
+	}
+
+	return s, nil
+}
+
+func eventNameDefault(r Record) string {
+	if name := r.Headers["event"]; name != "" {
+		return name
+	}
+	return r.Topic
+}
+
+// Run emits the polled records and commits them afterwards until the ctx is cancelled, so every record is emitted at
+// least once.
+func (s *Source) Run(ctx context.Context, emit func(e ssevents.Event)) error {
+	for {
+		records, err := s.consumer.Poll(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			for _, r := range records {
+				emit(ssevents.Event{Id: EventID(r), Event: s.eventName(r), Data: string(r.Value)})
+			}
+			if len(records) == 0 {
+				continue
+			}
+			if err = s.consumer.Commit(ctx, records); err == nil {
+				continue
+			}
+		}
+		s.logger.Error("kafka source failed", "err", err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.retryDelay):
+		}
+	}
+}
+
+// EventID returns the ID of the event of the record, its topic, partition and offset
+func EventID(r Record) string {
+	return r.Topic + "/" + strconv.FormatInt(int64(r.Partition), 10) + "/" + strconv.FormatInt(r.Offset, 10)
+}
+
+// ParseEventID returns the position of the record of the event ID, e.g. of the Last-Event-ID header, the consumer
+// resumes after it from offset+1.
+func ParseEventID(id string) (topic string, partition int32, offset int64, err error) {
+	rest, offsetPart, found := cutLast(id, "/")
+	if !found {
+		return "", 0, 0, fmt.Errorf("kafkasource: invalid event id %q", id)
+	}
+	topic, partitionPart, found := cutLast(rest, "/")
+	if !found || topic == "" {
+		return "", 0, 0, fmt.Errorf("kafkasource: invalid event id %q", id)
+	}
+	parsedPartition, err := strconv.ParseInt(partitionPart, 10, 32)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("kafkasource: invalid partition of event id %q: %w", id, err)
+	}
+	if offset, err = strconv.ParseInt(offsetPart, 10, 64); err != nil {
+		return "", 0, 0, fmt.Errorf("kafkasource: invalid offset of event id %q: %w", id, err)
+	}
+
+	return topic, int32(parsedPartition), offset, nil
+}
+
+// cutLast is strings.Cut around the last separator, topic names may not contain it but stay safe for the ones that do
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
@@ -0,0 +1,304 @@
+// Package kubewatch bridges Kubernetes watches into SSE, emitting ADDED, MODIFIED and DELETED notifications of the
+// watched resources as events with the JSON object as data, so cluster dashboards can be built directly on top of the
+// server.
+//
+// The source talks to the API server watch endpoints directly instead of depending on client-go and its informers,
+// which would pull the Kubernetes API machinery into every user of the module for a single streaming GET. Any
+// authenticated http.Client works, e.g. the one created by client-go's rest.HTTPClientFor(config), or InCluster when
+// running inside a pod:
+//
+//	source, err := kubewatch.InCluster(kubewatch.Options{
+//		Resources:     []string{"/api/v1/namespaces/default/pods", "/apis/apps/v1/deployments"},
+//		LabelSelector: "app=web",
+//	})
+//	server, err := ssevents.NewServer(&ssevents.Options{Sources: []ssevents.Source{source}})
+package kubewatch
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	EventAdded    = "ADDED"
+	EventModified = "MODIFIED"
+	EventDeleted  = "DELETED"
+
+	eventBookmark = "BOOKMARK"
+	eventError    = "ERROR"
+
+	retryDelayDefault    = 2 * time.Second
+	maxRetryDelayDefault = time.Minute
+
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+)
+
+var (
+	ErrMissingServer    = errors.New("kubewatch: Server is required")
+	ErrMissingResources = errors.New("kubewatch: at least one resource is required")
+	// errExpired is returned by the API server when the watched resource version is too old
+	errExpired = errors.New("kubewatch: resource version expired")
+)
+
+type Options struct {
+	// Server is the API server url, e.g. https://kubernetes.default.svc
+	Server string
+	// Client must be authenticated against the API server, default is http.DefaultClient
+	Client *http.Client
+	// Resources are the collection paths to watch, e.g. /api/v1/namespaces/default/pods
+	Resources []string
+	// LabelSelector restricts the watched objects by their labels
+	LabelSelector string
+	// FieldSelector restricts the watched objects by their fields
+	FieldSelector string
+	// RetryDelay defines how long to wait before watching again after the watch ended, default is 2s. It doubles
+	// with every watch ending without notifications, e.g. failing or expired ones, up to MaxRetryDelay.
+	RetryDelay time.Duration
+	// MaxRetryDelay is the longest delay between watches failing one after another, default is 1m
+	MaxRetryDelay time.Duration
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Source is a ssevents.Source emitting watch notifications of the configured resources.
+type Source struct {
+	server        string
+	client        *http.Client
+	resources     []string
+	labelSelector string
+	fieldSelector string
+	backoff       ssevents.Backoff
+	logger        *slog.Logger
+}
+
+var _ ssevents.Source = (*Source)(nil)
+
+func New(options Options) (*Source, error) {
+	if options.Server == "" {
+		return nil, ErrMissingServer
+	}
+	if len(options.Resources) == 0 {
+		return nil, ErrMissingResources
+	}
+
+	s := &Source{
+		server:        options.Server,
+		client:        http.DefaultClient,
+		resources:     options.Resources,
+		labelSelector: options.LabelSelector,
+		fieldSelector: options.FieldSelector,
+		backoff: ssevents.Backoff{
+			InitialDelay: retryDelayDefault, MaxDelay: maxRetryDelayDefault, Multiplier: 2, Jitter: 0.2,
+		},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	if options.Client != nil {
+		s.client = options.Client
+	}
+	if options.RetryDelay > 0 {
+		s.backoff.InitialDelay = options.RetryDelay
+	}
+	if options.MaxRetryDelay > 0 {
+		s.backoff.MaxDelay = options.MaxRetryDelay
+	}
+	s.backoff.MaxDelay = max(s.backoff.MaxDelay, s.backoff.InitialDelay)
+	if options.Logger != nil {
+		s.logger = options.Logger
+	}
+
+	return s, nil
+}
+
+// InCluster creates the source authenticated with the pod service account, the Server and Client options are set
+// from the environment unless provided.
+func InCluster(options Options) (*Source, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if options.Server == "" {
+		if host == "" || port == "" {
+			return nil, errors.New("kubewatch: not running inside a cluster")
+		}
+		options.Server = "https://" + host + ":" + port
+	}
+	if options.Client == nil {
+		client, err := serviceAccountClient()
+		if err != nil {
+			return nil, err
+		}
+		options.Client = client
+	}
+
+	return New(options)
+}
+
+func serviceAccountClient() (*http.Client, error) {
+	ca, err := os.ReadFile(serviceAccountDir + "ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed reading service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("kubewatch: invalid service account CA")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	return &http.Client{Transport: &tokenTransport{next: transport}}, nil
+}
+
+// tokenTransport reads the token on every request as the kubelet rotates it
+type tokenTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(serviceAccountDir + "token")
+	if err != nil {
+		return nil, fmt.Errorf("failed reading service account token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(token))
+
+	return t.next.RoundTrip(req)
+}
+
+// Run watches all resources until the ctx is cancelled, re-establishing watches that end or fail.
+func (s *Source) Run(ctx context.Context, emit func(e ssevents.Event)) error {
+	var wg sync.WaitGroup
+	for _, resource := range s.resources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.watchLoop(ctx, resource, emit)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// watchLoop watches the resource again after the watch ends, watches ending without progress one after another are
+// delayed by the backoff, so an API server answering every watch with an error, or 410 Gone, is not flooded
+func (s *Source) watchLoop(ctx context.Context, resource string, emit func(e ssevents.Event)) {
+	var resourceVersion string
+	var failures int
+	for {
+		started := resourceVersion
+		err := s.watch(ctx, resource, &resourceVersion, emit)
+		if ctx.Err() != nil {
+			return
+		}
+		// A watch which made progress starts the backoff over
+		if resourceVersion != started {
+			failures = 0
+		}
+		if errors.Is(err, errExpired) {
+			// Start from the current state, the API server sends ADDED for all existing objects
+			resourceVersion = ""
+			s.logger.Warn("kubernetes watch expired", "resource", resource)
+		} else if err != nil {
+			s.logger.Error("kubernetes watch failed", "resource", resource, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.backoff.Delay(failures)):
+		}
+		failures++
+	}
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+type objectMeta struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	// Code is set on Status objects of ERROR notifications
+	Code int `json:"code"`
+}
+
+func (s *Source) watch(
+	ctx context.Context, resource string, resourceVersion *string, emit func(e ssevents.Event),
+) (err error) {
+	query := url.Values{}
+	query.Set("watch", "true")
+	query.Set("allowWatchBookmarks", "true")
+	if *resourceVersion != "" {
+		query.Set("resourceVersion", *resourceVersion)
+	}
+	if s.labelSelector != "" {
+		query.Set("labelSelector", s.labelSelector)
+	}
+	if s.fieldSelector != "" {
+		query.Set("fieldSelector", s.fieldSelector)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.server+resource+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed creating watch request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed watching: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode == http.StatusGone {
+		return errExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("watch failed with status %d", resp.StatusCode)
+	}
+
+	// Every notification is a JSON object on its own line, objects can be large so the default limit is raised
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var evt watchEvent
+		if err = json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			return fmt.Errorf("failed decoding watch notification: %w", err)
+		}
+		var meta objectMeta
+		_ = json.Unmarshal(evt.Object, &meta)
+
+		switch evt.Type {
+		case eventError:
+			if meta.Code == http.StatusGone {
+				return errExpired
+			}
+			return fmt.Errorf("watch error: %s", evt.Object)
+		case eventBookmark:
+			*resourceVersion = meta.Metadata.ResourceVersion
+		case EventAdded, EventModified, EventDeleted:
+			*resourceVersion = meta.Metadata.ResourceVersion
+			emit(ssevents.Event{Id: meta.Metadata.ResourceVersion, Event: evt.Type, Data: string(evt.Object)})
+		}
+	}
+	if err = scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed reading watch: %w", err)
+	}
+
+	return nil
+}
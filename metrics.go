@@ -0,0 +1,187 @@
+package ssevents
+
+// ServerMetrics receives measurements of the HttpController, see the promsse package for a Prometheus implementation.
+// Implementations must be safe for concurrent use.
+type ServerMetrics interface {
+	// Connected is called when an SSE connection is established
+	Connected()
+	// Disconnected is called when an SSE connection is closed
+	Disconnected()
+	// Emitted is called once for every event passed to Emit
+	Emitted()
+	// Dropped is called for every subscriber that did not receive an event due to the emit strategy
+	Dropped(strategy EmitStrategy)
+	// WriteFailed is called when writing to a connection fails
+	WriteFailed()
+	// FlushFailed is called when flushing a connection fails
+	FlushFailed()
+	// WriteTimedOut is called when a write exceeds the WriteTimeout and its connection is closed
+	WriteTimedOut()
+	// HeartbeatSent is called for every heartbeat sent to a connection
+	HeartbeatSent()
+	// HeartbeatFailed is called when sending a heartbeat fails
+	HeartbeatFailed()
+	// EmitRateLimited is called when a request to the emit endpoint is rejected by the rate limits
+	EmitRateLimited()
+	// EventRejected is called for every emitted event rejected by the Options.ValidateEvent
+	EventRejected()
+}
+
+// noopServerMetrics is used when no metrics are configured
+type noopServerMetrics struct{}
+
+func (noopServerMetrics) Connected()             {}
+func (noopServerMetrics) Disconnected()          {}
+func (noopServerMetrics) Emitted()               {}
+func (noopServerMetrics) Dropped(_ EmitStrategy) {}
+func (noopServerMetrics) WriteFailed()           {}
+func (noopServerMetrics) FlushFailed()           {}
+func (noopServerMetrics) WriteTimedOut()         {}
+func (noopServerMetrics) HeartbeatSent()         {}
+func (noopServerMetrics) HeartbeatFailed()       {}
+func (noopServerMetrics) EmitRateLimited()       {}
+func (noopServerMetrics) EventRejected()         {}
+
+// serverMetricsGroup reports to all of its metrics
+type serverMetricsGroup []ServerMetrics
+
+func (g serverMetricsGroup) Connected() {
+	for _, m := range g {
+		m.Connected()
+	}
+}
+
+func (g serverMetricsGroup) Disconnected() {
+	for _, m := range g {
+		m.Disconnected()
+	}
+}
+
+func (g serverMetricsGroup) Emitted() {
+	for _, m := range g {
+		m.Emitted()
+	}
+}
+
+func (g serverMetricsGroup) Dropped(strategy EmitStrategy) {
+	for _, m := range g {
+		m.Dropped(strategy)
+	}
+}
+
+func (g serverMetricsGroup) WriteFailed() {
+	for _, m := range g {
+		m.WriteFailed()
+	}
+}
+
+func (g serverMetricsGroup) FlushFailed() {
+	for _, m := range g {
+		m.FlushFailed()
+	}
+}
+
+func (g serverMetricsGroup) WriteTimedOut() {
+	for _, m := range g {
+		m.WriteTimedOut()
+	}
+}
+
+func (g serverMetricsGroup) HeartbeatSent() {
+	for _, m := range g {
+		m.HeartbeatSent()
+	}
+}
+
+func (g serverMetricsGroup) HeartbeatFailed() {
+	for _, m := range g {
+		m.HeartbeatFailed()
+	}
+}
+
+func (g serverMetricsGroup) EmitRateLimited() {
+	for _, m := range g {
+		m.EmitRateLimited()
+	}
+}
+
+func (g serverMetricsGroup) EventRejected() {
+	for _, m := range g {
+		m.EventRejected()
+	}
+}
+
+// ClientMetrics receives measurements of the Client stream health, see the promsse package for a Prometheus
+// implementation. Implementations must be safe for concurrent use.
+type ClientMetrics interface {
+	// Connected is called when the connection to the server is established
+	Connected()
+	// Disconnected is called when the connection to the server is lost or closed
+	Disconnected()
+	// ReconnectAttempted is called before every reconnection attempt
+	ReconnectAttempted()
+	// EventReceived is called for every event read from the stream with its event name
+	EventReceived(name string)
+	// ParseFailed is called for every stream line that could not be parsed
+	ParseFailed()
+	// BytesRead is called with the size of every line read from the stream, including its line ending
+	BytesRead(n int)
+	// ObserverDropped is called when an event was not delivered to a slow observer
+	ObserverDropped()
+}
+
+// noopClientMetrics is used when no metrics are configured
+type noopClientMetrics struct{}
+
+func (noopClientMetrics) Connected()             {}
+func (noopClientMetrics) Disconnected()          {}
+func (noopClientMetrics) ReconnectAttempted()    {}
+func (noopClientMetrics) EventReceived(_ string) {}
+func (noopClientMetrics) ParseFailed()           {}
+func (noopClientMetrics) BytesRead(_ int)        {}
+func (noopClientMetrics) ObserverDropped()       {}
+
+// clientMetricsGroup reports to all of its metrics
+type clientMetricsGroup []ClientMetrics
+
+func (g clientMetricsGroup) Connected() {
+	for _, m := range g {
+		m.Connected()
+	}
+}
+
+func (g clientMetricsGroup) Disconnected() {
+	for _, m := range g {
+		m.Disconnected()
+	}
+}
+
+func (g clientMetricsGroup) ReconnectAttempted() {
+	for _, m := range g {
+		m.ReconnectAttempted()
+	}
+}
+
+func (g clientMetricsGroup) EventReceived(name string) {
+	for _, m := range g {
+		m.EventReceived(name)
+	}
+}
+
+func (g clientMetricsGroup) ParseFailed() {
+	for _, m := range g {
+		m.ParseFailed()
+	}
+}
+
+func (g clientMetricsGroup) BytesRead(n int) {
+	for _, m := range g {
+		m.BytesRead(n)
+	}
+}
+
+func (g clientMetricsGroup) ObserverDropped() {
+	for _, m := range g {
+		m.ObserverDropped()
+	}
+}
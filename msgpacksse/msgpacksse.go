@@ -0,0 +1,337 @@
+// Package msgpacksse provides the MessagePack ssevents.Codec, the event data is sent base64 encoded as SSE carries
+// only text:
+//
+//	err := server.EmitEncoded(msgpacksse.Codec, "order-created", order)
+//	orders := ssevents.SubscribeCodec[Order](client, nil, msgpacksse.Codec)
+//
+// The values are mapped through their JSON representation, so the json struct tags and json.Marshaler apply and
+// structs are encoded as maps. It implements the MessagePack format directly so it has no dependencies, extension
+// types like timestamps are not supported.
+package msgpacksse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// maxDepth bounds the nesting of decoded arrays and maps
+const maxDepth = 1000
+
+var errTruncated = errors.New("msgpacksse: truncated data")
+
+// Codec encodes values in the MessagePack format
+var Codec ssevents.Codec = codec{}
+
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err = dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return appendValue(nil, value)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	d := &decoder{data: data}
+	value, err := d.value(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(data) {
+		return fmt.Errorf("msgpacksse: %d bytes after the value", len(data)-d.pos)
+	}
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(jsonData, v)
+}
+
+func (codec) Binary() bool {
+	return true
+}
+
+// appendValue encodes a value decoded from JSON with numbers kept as json.Number
+func appendValue(b []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		return appendNumber(b, v)
+	case string:
+		return appendString(b, v), nil
+	case []any:
+		b = appendHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if b, err = appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		b = appendHeader(b, len(v), 0x80, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// Sorted keys keep the encoding of equal values equal
+		slices.Sort(keys)
+		for _, key := range keys {
+			b = appendString(b, key)
+			var err error
+			if b, err = appendValue(b, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpacksse: unsupported type %T", value)
+	}
+}
+
+func appendNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return appendInt(b, i), nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("msgpacksse: invalid number %q: %w", n, err)
+	}
+
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+}
+
+func appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(b, byte(i))
+	case i >= -32 && i < 0:
+		return append(b, byte(0xe0|(i+32)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+
+	return append(b, s...)
+}
+
+// appendHeader writes the length of an array or a map in the fix, 16 or 32-bit format
+func appendHeader(b []byte, n int, fix, header16, header32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, header16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, header32), uint32(n))
+	}
+}
+
+// decoder reads MessagePack into values marshalled back to JSON, binary data becomes []byte, which JSON encodes as
+// base64 like the []byte fields it was encoded from
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+// uint reads a big endian unsigned integer of the size
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+func (d *decoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpacksse: nesting too deep")
+	}
+	head, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := head[0]; {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c&0xf0 == 0x80:
+		return d.mapOf(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.arrayOf(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c := head[0]; c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.read(int(n))
+	case 0xca:
+		u, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(u))), nil
+	case 0xcb:
+		u, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(u), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatUint(u, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign extend from the size of the integer
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n), depth)
+	default:
+		return nil, fmt.Errorf("msgpacksse: unsupported format 0x%02x", c)
+	}
+}
+
+func (d *decoder) str(n int) (string, error) {
+	b, err := d.read(n)
+	return string(b), err
+}
+
+func (d *decoder) arrayOf(n, depth int) ([]any, error) {
+	// Every item takes at least a byte, which bounds the allocation of a corrupt length
+	if n > len(d.data)-d.pos {
+		return nil, errTruncated
+	}
+	items := make([]any, n)
+	for i := range items {
+		var err error
+		if items[i], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+func (d *decoder) mapOf(n, depth int) (map[string]any, error) {
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, errTruncated
+	}
+	m := make(map[string]any, n)
+	for range n {
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case string:
+			m[k] = value
+		case []byte:
+			m[string(k)] = value
+		default:
+			// JSON objects only have string keys, numbers and booleans are written as such
+			m[fmt.Sprint(k)] = value
+		}
+	}
+
+	return m, nil
+}
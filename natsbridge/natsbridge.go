@@ -0,0 +1,211 @@
+// Package natsbridge relays emitted events between server instances through NATS, so clients connected to any
+// instance behind a load balancer receive every event.
+//
+//	bridge, err := natsbridge.New(natsbridge.Options{Addr: "nats:4222"})
+//	server, err := ssevents.NewServer(&ssevents.Options{Bridge: bridge})
+//
+// Every event is published as JSON to a subject of its own name under the subject prefix, e.g. ssevents.order-created,
+// so other services can publish to or listen on single event names. Their payloads that are not an encoded event are
+// emitted as the data of an event named after the subject. It speaks the NATS protocol directly so it has no
+// dependencies.
+package natsbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	addrDefault          = "localhost:4222"
+	subjectPrefixDefault = "ssevents"
+	dialTimeoutDefault   = 5 * time.Second
+	retryDelayDefault    = 2 * time.Second
+	// unnamedSubject is the subject token of events without a name, which browsers dispatch as message events
+	unnamedSubject = "message"
+)
+
+type Options struct {
+	// Addr of the NATS server, default is localhost:4222
+	Addr string
+	// Token authenticates the connection, alternatively to Username and Password
+	Token string
+	// Username authenticates the connection together with the Password
+	Username string
+	// Password authenticates the connection together with the Username
+	Password string
+	// SubjectPrefix of the event subjects, default is ssevents
+	SubjectPrefix string
+	// DialTimeout bounds connecting and authenticating, default is 5s
+	DialTimeout time.Duration
+	// RetryDelay defines how long to wait before subscribing again after the connection failed, default is 2s
+	RetryDelay time.Duration
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Bridge is a ssevents.EventBridge over NATS subjects.
+type Bridge struct {
+	addr          string
+	token         string
+	username      string
+	password      string
+	subjectPrefix string
+	dialTimeout   time.Duration
+	retryDelay    time.Duration
+	logger        *slog.Logger
+	// mu guards the connection used for publishing, it is dialed on demand and dropped on failures
+	mu   sync.Mutex
+	conn *conn
+}
+
+var _ ssevents.EventBridge = (*Bridge)(nil)
+
+func New(options Options) (*Bridge, error) {
+	b := &Bridge{
+		addr:          addrDefault,
+		token:         options.Token,
+		username:      options.Username,
+		password:      options.Password,
+		subjectPrefix: subjectPrefixDefault,
+		dialTimeout:   dialTimeoutDefault,
+		retryDelay:    retryDelayDefault,
+		logger:        slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	if options.Addr != "" {
+		b.addr = options.Addr
+	}
+	if options.SubjectPrefix != "" {
+		b.subjectPrefix = strings.TrimSuffix(options.SubjectPrefix, ".")
+	}
+	if options.DialTimeout > 0 {
+		b.dialTimeout = options.DialTimeout
+	}
+	if options.RetryDelay > 0 {
+		b.retryDelay = options.RetryDelay
+	}
+	if options.Logger != nil {
+		b.logger = options.Logger
+	}
+	if (b.username == "") != (b.password == "") {
+		return nil, errors.New("natsbridge: username and password must be set together")
+	}
+
+	return b, nil
+}
+
+// Subject returns the subject the event is published to
+func (b *Bridge) Subject(e ssevents.Event) string {
+	name := e.Event
+	if name == "" {
+		name = unnamedSubject
+	}
+	// Whitespace separates the fields of the protocol and dots the tokens of a subject
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n', '.', '*', '>':
+			return '_'
+		default:
+			return r
+		}
+	}, name)
+
+	return b.subjectPrefix + "." + name
+}
+
+// Publish sends the event as JSON to its subject
+func (b *Bridge) Publish(ctx context.Context, e ssevents.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed encoding event: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil {
+		c, dialErr := b.dial(ctx)
+		if dialErr != nil {
+			return dialErr
+		}
+		b.conn = c
+		// The connection is read only for answering the pings of the server
+		go func() {
+			serveErr := c.serve(nil)
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.conn == c {
+				b.conn = nil
+				b.logger.Debug("nats publishing connection closed", "err", serveErr)
+			}
+			_ = c.Close()
+		}()
+	}
+	if err = b.conn.publish(ctx, b.Subject(e), payload); err != nil {
+		_ = b.conn.Close()
+		b.conn = nil
+		return fmt.Errorf("failed publishing event: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe receives the events of all subjects under the prefix until the ctx is cancelled, subscribing again after
+// failures
+func (b *Bridge) Subscribe(ctx context.Context, emit func(e ssevents.Event)) error {
+	for {
+		err := b.subscribe(ctx, emit)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			b.logger.Error("nats subscription failed", "subject", b.subjectPrefix+".>", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(b.retryDelay):
+		}
+	}
+}
+
+func (b *Bridge) subscribe(ctx context.Context, emit func(e ssevents.Event)) error {
+	c, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	defer stop()
+
+	if err = c.write("SUB "+b.subjectPrefix+".> 1", nil); err != nil {
+		return fmt.Errorf("failed subscribing: %w", err)
+	}
+
+	return c.serve(func(subject string, payload []byte) {
+		emit(b.decode(subject, payload))
+	})
+}
+
+// decode returns the bridged event, payloads published by other services that are not an encoded event become the
+// data of an event named after the subject
+func (b *Bridge) decode(subject string, payload []byte) ssevents.Event {
+	var e ssevents.Event
+	if err := json.Unmarshal(payload, &e); err == nil && e.Data != "" {
+		return e
+	}
+	name := strings.TrimPrefix(subject, b.subjectPrefix+".")
+	if name == unnamedSubject {
+		name = ""
+	}
+
+	return ssevents.Event{Event: name, Data: string(payload)}
+}
@@ -0,0 +1,163 @@
+package natsbridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// conn speaks the subset of the NATS client protocol used by the bridge
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+	// writeMu keeps the pongs of serve from interleaving with published messages
+	writeMu sync.Mutex
+}
+
+// connectOptions is the payload of the CONNECT command
+type connectOptions struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	Token    string `json:"auth_token,omitempty"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+}
+
+// dial connects, authenticates and waits for the server to confirm the connection within the DialTimeout
+func (b *Bridge) dial(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: b.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to nats: %w", err)
+	}
+	c := &conn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	_ = c.SetDeadline(time.Now().Add(b.dialTimeout))
+
+	if err = c.handshake(connectOptions{
+		Name:    "ssevents",
+		Lang:    "go",
+		Version: "1",
+		Token:   b.token,
+		User:    b.username,
+		Pass:    b.password,
+	}); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed connecting to nats: %w", err)
+	}
+	_ = c.SetDeadline(time.Time{})
+
+	return c, nil
+}
+
+// handshake reads the INFO of the server, sends CONNECT and a PING whose PONG confirms the connection was accepted
+func (c *conn) handshake(options connectOptions) error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+	payload, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if err = c.write("CONNECT "+string(payload)+"\r\nPING", nil); err != nil {
+		return err
+	}
+
+	for {
+		if line, err = c.readLine(); err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// publish sends the payload to the subject
+func (c *conn) publish(ctx context.Context, subject string, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	deadline, _ := ctx.Deadline()
+	_ = c.SetWriteDeadline(deadline)
+
+	return c.writeLocked("PUB "+subject+" "+strconv.Itoa(len(payload)), payload)
+}
+
+// write sends the command line followed by the payload, if any
+func (c *conn) write(line string, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeLocked(line, payload)
+}
+
+func (c *conn) writeLocked(line string, payload []byte) error {
+	buf := make([]byte, 0, len(line)+len(payload)+4)
+	buf = append(append(buf, line...), "\r\n"...)
+	if payload != nil {
+		buf = append(append(buf, payload...), "\r\n"...)
+	}
+	_, err := c.Write(buf)
+	return err
+}
+
+// serve reads the connection until it fails, answering pings and passing messages to onMsg when set
+func (c *conn) serve(onMsg func(subject string, payload []byte)) error {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PING":
+			if err = c.write("PONG", nil); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <size>
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				return fmt.Errorf("invalid message %q", line)
+			}
+			size, sizeErr := strconv.Atoi(fields[len(fields)-1])
+			if sizeErr != nil {
+				return fmt.Errorf("invalid message %q: %w", line, sizeErr)
+			}
+			payload := make([]byte, size+2)
+			if _, err = io.ReadFull(c.reader, payload); err != nil {
+				return err
+			}
+			if onMsg != nil {
+				onMsg(fields[1], payload[:size])
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// readLine returns the next control line without its line ending
+func (c *conn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -1,11 +1,19 @@
 package ssevents
 
+import (
+	"context"
+	"time"
+)
+
 type ObserverBuilder struct {
 	filters          []Filter
 	closeOnFirst     bool
 	limit            int
 	buffer           int
 	includeHeartbeat bool
+	ctx              context.Context
+	transforms       []func(e Event) []Event
+	debounce         time.Duration
 }
 
 // NewObserverBuilder helps in constructing an observer with builder functions to make it more flent
@@ -66,6 +74,79 @@ func (o *ObserverBuilder) Buffer(count int) *ObserverBuilder {
 	return o
 }
 
+// Map transforms the events before they land in EventCh, e.g. stripping fields, parsing data or renaming events.
+// Transforms run after the filters in the order they were added and the limit counts the transformed events.
+func (o *ObserverBuilder) Map(fn func(e Event) Event) *ObserverBuilder {
+	o.transforms = append(o.transforms, func(e Event) []Event {
+		return []Event{fn(e)}
+	})
+	return o
+}
+
+// FlatMap is Map turning every event into any number of events, e.g. splitting a batch into its items, none drops it
+func (o *ObserverBuilder) FlatMap(fn func(e Event) []Event) *ObserverBuilder {
+	o.transforms = append(o.transforms, fn)
+	return o
+}
+
+// Skip drops the first n events accepted by the previous stages
+func (o *ObserverBuilder) Skip(n int) *ObserverBuilder {
+	var skipped int
+	return o.FlatMap(func(e Event) []Event {
+		if skipped < n {
+			skipped++
+			return nil
+		}
+		return []Event{e}
+	})
+}
+
+// Throttle passes an event and drops the following ones until the interval has passed since
+func (o *ObserverBuilder) Throttle(interval time.Duration) *ObserverBuilder {
+	var last time.Time
+	return o.FlatMap(func(e Event) []Event {
+		now := time.Now()
+		if !last.IsZero() && now.Sub(last) < interval {
+			return nil
+		}
+		last = now
+		return []Event{e}
+	})
+}
+
+// Distinct drops the events whose key was seen already, e.g. their ID, the keys are kept for the lifetime of the
+// observer
+func (o *ObserverBuilder) Distinct(key func(e Event) string) *ObserverBuilder {
+	seen := make(map[string]struct{})
+	return o.FlatMap(func(e Event) []Event {
+		k := key(e)
+		if _, ok := seen[k]; ok {
+			return nil
+		}
+		seen[k] = struct{}{}
+		return []Event{e}
+	})
+}
+
+// Debounce delivers only the latest of the events arriving in quick succession, once no other one arrived for the
+// interval. It applies to the events after all the filters and transforms.
+func (o *ObserverBuilder) Debounce(interval time.Duration) *ObserverBuilder {
+	o.debounce = interval
+	return o
+}
+
+// TakeUntil completes the observer once the ctx is done, the same as Context
+func (o *ObserverBuilder) TakeUntil(ctx context.Context) *ObserverBuilder {
+	return o.Context(ctx)
+}
+
+// Context binds the observer to the ctx, e.g. of a request or a test, once it is done the observer is removed and its
+// channel closed
+func (o *ObserverBuilder) Context(ctx context.Context) *ObserverBuilder {
+	o.ctx = ctx
+	return o
+}
+
 // Build constructs the consumer with all the options set and defaulting to those that are not
 func (o *ObserverBuilder) Build() *Observer {
 	if !o.includeHeartbeat {
@@ -75,6 +156,9 @@ func (o *ObserverBuilder) Build() *Observer {
 		filters:      o.filters,
 		limit:        o.limit,
 		closeOnFirst: o.closeOnFirst,
+		ctx:          o.ctx,
+		transforms:   o.transforms,
+		debounce:     o.debounce,
 		EventCh:      make(chan Event, o.buffer),
 	}
 }
@@ -2,9 +2,14 @@ package ssevents
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 )
 
+// ErrObserverCompleted is returned by the waits of an Observer that completed before their condition was met
+var ErrObserverCompleted = errors.New("sse observer completed")
+
 type Observer struct {
 	EventCh      chan Event
 	filters      []Filter
@@ -13,6 +18,47 @@ type Observer struct {
 	// emittedCount is used for tracking the number of emitted events when used with limit field
 	emittedCount int
 	timeout      time.Duration
+	// ctx unsubscribes the observer once done, nil when it is not bound to a context
+	ctx context.Context
+	// stopCtx releases the callback of ctx once the observer is closed, it is set by the client on Subscribe
+	stopCtx func() bool
+	// transforms turn every event accepted by the filters into the events received on EventCh, in order
+	transforms []func(e Event) []Event
+	// debounce delays the delivery until no other event arrived for the interval, the pending event is guarded by
+	// debounceMu and delivered by debounceTimer
+	debounce      time.Duration
+	debounceMu    sync.Mutex
+	debounceTimer *time.Timer
+	debounced     Event
+	pending       bool
+	// mu guards closing EventCh against the sends of the client, done is closed first to unblock a pending send
+	mu        sync.Mutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// close closes EventCh once it is not being sent to, it returns false if it was already closed
+func (o *Observer) close() bool {
+	var closed bool
+	o.closeOnce.Do(func() {
+		if o.stopCtx != nil {
+			o.stopCtx()
+		}
+		close(o.done)
+		o.debounceMu.Lock()
+		if o.debounceTimer != nil {
+			o.debounceTimer.Stop()
+		}
+		o.debounceMu.Unlock()
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.closed = true
+		close(o.EventCh)
+		closed = true
+	})
+
+	return closed
 }
 
 func (o *Observer) hasSatisfiedFilters(e Event) bool {
@@ -25,6 +71,19 @@ func (o *Observer) hasSatisfiedFilters(e Event) bool {
 	return true
 }
 
+// transform passes the events through the transforms of the observer, returning the events they turned into
+func (o *Observer) transform(events []Event) []Event {
+	for _, transform := range o.transforms {
+		var next []Event
+		for _, evt := range events {
+			next = append(next, transform(evt)...)
+		}
+		events = next
+	}
+
+	return events
+}
+
 // WaitForAll blocks and starts reading from the observer until it has completed, returning all events as a result.
 func (o *Observer) WaitForAll() []Event {
 	var events []Event
@@ -59,3 +118,40 @@ func (o *Observer) WaitForAllOrTimeout(timeout time.Duration) ([]Event, error) {
 		return nil, ctx.Err()
 	}
 }
+
+// WaitForN reads the next n events of the observer, returning as soon as they are received. It returns the events
+// received so far with the ctx error once the ctx is done, or ErrObserverCompleted once the observer completes first.
+func (o *Observer) WaitForN(ctx context.Context, n int) ([]Event, error) {
+	events := make([]Event, 0, n)
+	for len(events) < n {
+		select {
+		case evt, ok := <-o.EventCh:
+			if !ok {
+				return events, ErrObserverCompleted
+			}
+			events = append(events, evt)
+		case <-ctx.Done():
+			return events, ctx.Err()
+		}
+	}
+
+	return events, nil
+}
+
+// WaitForMatch reads the events of the observer until one matches, returning it. The events read before it are
+// discarded, use a filter of the observer instead to keep them for later reads.
+func (o *Observer) WaitForMatch(ctx context.Context, match func(e Event) bool) (Event, error) {
+	for {
+		select {
+		case evt, ok := <-o.EventCh:
+			if !ok {
+				return Event{}, ErrObserverCompleted
+			}
+			if match(evt) {
+				return evt, nil
+			}
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		}
+	}
+}
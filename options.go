@@ -1,31 +1,185 @@
 package ssevents
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"log/slog"
 	"net/http"
 	"os"
 	"time"
 )
 
-const heartbeatIntervalDefault = 20 * time.Second
+const (
+	heartbeatIntervalDefault = 20 * time.Second
+	metricsPathDefault       = "/metrics"
+)
 
 type Options struct {
 	// Port defines the port on which to run the server
 	Port int
 	// Handlers are used for adding new endpoints
 	Handlers map[string]http.HandlerFunc
+	// Middlewares wrap all the routes, e.g. for logging, recovery or request IDs, the first one being the outermost.
+	// Response writers they wrap must implement Unwrap or http.Flusher for the SSE stream to be flushed.
+	Middlewares []func(http.Handler) http.Handler
 	// HeartbeatInterval defines on which interval a heartbeat is sent to connected clients
 	HeartbeatInterval time.Duration
+	// EnableCompression compresses the SSE stream with the first of the Compressors accepted by the Accept-Encoding
+	// header of the client, every event is flushed through the compressor so it is not held back. Default is false.
+	EnableCompression bool
+	// Compressors are negotiated in order, default is GzipCompressor, see the brotlisse package for brotli
+	Compressors []Compressor
+	// HeartbeatComment sends the heartbeats as ": ping" comment lines instead of heartbeat events, browsers and the
+	// client skip them so they keep the connection alive without reaching the event stream. Default is false.
+	HeartbeatComment bool
+	// HeartbeatEvent creates the heartbeat events, e.g. with a name of your own or without data for clients treating
+	// the timestamp as a message. Default is a "heartbeat" event with the current time, which FilterNoHeartbeat
+	// skips. Ignored with HeartbeatComment.
+	HeartbeatEvent func() *Event
 	// Logger to be used, default is stdout text
 	Logger *slog.Logger
+	// RedactEvent rewrites events before they are logged, e.g. RedactEventData, default logs them verbatim. Event data
+	// is logged only at debug level.
+	RedactEvent func(e Event) Event
 	// Overrides the default SSE url /sse
 	SseUrl string
+	// ReplayBufferSize keeps the given number of the latest events with an ID emitted to all subscribers, replaying
+	// the ones missed by clients reconnecting with the Last-Event-ID header. Default is 0 which disables replay.
+	ReplayBufferSize int
+	// EventStore replaces the in-memory replay buffer, e.g. NewMemoryEventStore with a max age or a persistent
+	// sqlstore which replays missed events across restarts. Default is nil.
+	EventStore EventStore
+	// AutoEventID assigns the ID it returns to emitted events without one, e.g. SequentialEventIDs() or
+	// RandomEventID, enabling replay and de-duplication by the clients. Default leaves the ID empty.
+	AutoEventID func() string
+	// ValidateEvent checks every emitted event, including the ones of the emit endpoint, after AutoEventID assigned its
+	// ID. The events it returns an error for are not sent, are counted by the Metrics EventRejected and the emit
+	// endpoint responds to them with 422 Unprocessable Entity. Default accepts all events.
+	ValidateEvent func(e Event) error
+	// SubscriberFilter creates the filter of every connection, e.g. FilterEventsFromQuery, only the events it accepts
+	// are sent to the connection. Default sends all events.
+	SubscriberFilter func(req *http.Request) Filter
+	// SendHello sends a hello event with the ServerInfo capabilities to every new connection, after the initial
+	// heartbeat. The client exposes them through ServerInfo.
+	SendHello bool
 	// EmitStrategy option defines what to do on slow consumers as they can block/slow emission to others,
 	// default is EmitStrategyBlock.
 	EmitStrategy EmitStrategy
 	// BufferSize defines how big the channel for each connection is as slow consumers will get their messages dropped.
 	// Default value is 1 and is used in conjunction with EmitStrategy when buffering is set.
 	BufferSize int
+	// WriteTimeout is the deadline of every write to a connection, a client that stopped reading stalls the write
+	// until it expires and closes the connection, the timed out stream cannot be written to anymore. Default is 0
+	// which waits on writes indefinitely.
+	WriteTimeout time.Duration
+	// MaxBatch is the most events queued for a connection that are written together with a single flush, reducing
+	// syscalls under bursty load. Default value is 1 which flushes every event.
+	MaxBatch int
+	// MaxDelay is how long a write waits for more events to fill the batch, default is 0 which writes only the events
+	// already queued. Used only in conjunction with MaxBatch.
+	MaxDelay time.Duration
+	// FanoutWorkers delivers an emit to large numbers of subscribers concurrently on a bounded pool of workers, default
+	// is 0 which delivers synchronously on the emitting goroutine.
+	FanoutWorkers int
+	// FanoutBatchSize is the number of subscribers up to which emits are delivered synchronously, larger ones hand
+	// every registry shard to a worker. Default value is 256 and is used only in conjunction with FanoutWorkers.
+	FanoutBatchSize int
+	// RegistryShards is the number of shards the subscribers are split into, each with a lock of its own and delivered
+	// in parallel when FanoutWorkers are set. Default value is 32.
+	RegistryShards int
+	// Faults injects write latency, disconnects and truncated frames into the connections for chaos testing, default
+	// is nil which injects none. Never set it in production.
+	Faults *Faults
+	// DisableEmitEndpoint does not mount POST /emit, recommended in production when events are emitted only from code
+	DisableEmitEndpoint bool
+	// EmitRateLimit limits the requests to POST /emit of all producers together, rejected ones get 429 Too Many
+	// Requests with a Retry-After header. Default is unlimited.
+	EmitRateLimit RateLimit
+	// EmitKeyRateLimit limits the requests to POST /emit of every producer, see EmitRateLimitKey. Default is unlimited.
+	EmitKeyRateLimit RateLimit
+	// EmitRateLimitKey identifies the producer for EmitKeyRateLimit, default is the X-API-Key header or the client IP
+	EmitRateLimitKey func(req *http.Request) string
+	// Auditor receives a record of every emit attributed to its actor, see WithActor, default does not audit
+	Auditor Auditor
+	// EmitActor identifies the caller of POST /emit for the Auditor, default is the client IP
+	EmitActor func(req *http.Request) string
+	// Bridge relays the events emitted to all subscribers through a broker to every instance of the deployment, only
+	// the events received from it are sent to the local subscribers. When publishing fails the event is emitted
+	// locally. Targeted emits are not relayed. Default emits locally.
+	Bridge EventBridge
+	// Sources are started together with the server and everything they produce is emitted to all subscribers.
+	Sources []Source
+	// SubscriberIDFunc identifies the connection for EmitToSubscriber instead of a random ID, e.g. with an ID generated
+	// by the client and sent as a query parameter. Connections sharing an ID all receive its events, an empty ID falls
+	// back to a random one.
+	SubscriberIDFunc func(req *http.Request) string
+	// UserIDFunc resolves the user owning the SSE connection, e.g. from a header or a cookie, enabling EmitToUser.
+	// Default is the "sub" claim returned by Authenticate.
+	UserIDFunc func(req *http.Request) string
+	// AllowedOrigins rejects browser connections with 403 Forbidden unless their Origin header matches an exact origin
+	// like https://app.example.com, a wildcard subdomain like https://*.example.com or *. Requests without an Origin
+	// are always allowed. Default allows any origin.
+	AllowedOrigins []string
+	// TLSConfig, when set, makes the server serve HTTPS with the certificates of the config
+	TLSConfig *tls.Config
+	// EnableH2C serves HTTP/2 without TLS (h2c) next to HTTP/1.1, e.g. behind a proxy terminating TLS. Servers with
+	// TLSConfig, or started with ListenAndServeTLS, negotiate HTTP/2 on their own.
+	EnableH2C bool
+	// ClientCAs requires clients to present a certificate signed by one of the pool's authorities, mutual TLS. The
+	// verified certificate is exposed through ConnInfo. Used only in conjunction with TLSConfig.
+	ClientCAs *x509.CertPool
+	// Authenticate verifies the caller before the SSE connection is established, an error rejects it with
+	// 401 Unauthorized, or 403 Forbidden when it is ErrForbidden. The returned claims are exposed through ConnInfo, see
+	// the jwtauth package.
+	Authenticate func(req *http.Request) (map[string]any, error)
+	// AuthenticateEmit verifies the callers of POST /emit the same way, e.g. the same function as Authenticate.
+	// Default accepts all callers.
+	AuthenticateEmit func(req *http.Request) (map[string]any, error)
+	// ShutdownEvent is sent to every connection when the server shuts down, letting browsers and the Client tell a
+	// graceful shutdown from a network failure and adjust their reconnection, e.g. NewShutdownEvent(5 * time.Second).
+	// Default is nil which closes the connections without it.
+	ShutdownEvent *Event
+	// OnConnect is called when a new SSE connection is established
+	OnConnect func(info ConnInfo)
+	// OnDisconnect is called once the SSE connection is closed
+	OnDisconnect func(info ConnInfo)
+	// OnAck receives the acknowledgements of the events received by the clients, enabling POST /ack next to the SSE
+	// url, e.g. /api/ack for /api/sse, to track the delivery per subscriber and re-send unacknowledged events for
+	// at-least-once semantics. Clients acknowledge events with an ID, see AutoEventID, with the ClientOptions AutoAck
+	// and require the SendHello option. Only the callers owning a connected subscriber can acknowledge its events.
+	OnAck func(ack Ack)
+	// DynamicTopics enables POST /sse/subscriptions below the SSE url, changing the topics of a connection by its ID
+	// without reconnecting, see Client AddTopic. The callers are verified with Options Authenticate and require the
+	// SendHello option.
+	DynamicTopics bool
+	// OnEmitError is called for every event not delivered to a connection, with ErrEventDropped when the emit strategy
+	// dropped it or with the write error. It is called from the fanout and connection goroutines and must not block.
+	OnEmitError func(info ConnInfo, e Event, err error)
+	// Metrics receives measurements of connections and emitted events, default discards them
+	Metrics ServerMetrics
+	// Tracer creates spans for connections and emitted events, default does not trace
+	Tracer Tracer
+	// PropagateTraceContext writes the trace context of every emit, e.g. traceparent, as extension fields of the event
+	// so traces can follow it into the consumers. Requires a Tracer.
+	PropagateTraceContext bool
+	// ExpvarName, when set, publishes the server counters under the name through expvar and mounts GET /debug/vars,
+	// NewServer fails with ErrExpvarNameTaken when another variable is published under it
+	ExpvarName string
+	// MetricsHandler is mounted on GET MetricsPath, e.g. promsse.Handler(registry), default mounts nothing
+	MetricsHandler http.Handler
+	// MetricsPath overrides the default metrics path /metrics
+	MetricsPath string
+	// EnablePprof mounts the net/http/pprof handlers on PprofPath, useful for diagnosing leaked connections
+	EnablePprof bool
+	// PprofPath overrides the default pprof path /debug/pprof/
+	PprofPath string
+	// PprofAuthorize guards the pprof handlers, default allows only requests from loopback addresses
+	PprofAuthorize func(req *http.Request) bool
+	// EnableStats mounts GET /sse/stats below the SSE url, serving the connected subscribers and their topics as JSON,
+	// see Stats
+	EnableStats bool
+	// StatsAuthorize guards the stats endpoint, default allows only requests from loopback addresses
+	StatsAuthorize func(req *http.Request) bool
 }
 
 func newUpdatedOptions(options *Options) *Options {
@@ -35,7 +189,18 @@ func newUpdatedOptions(options *Options) *Options {
 		HeartbeatInterval: heartbeatIntervalDefault,
 		Logger:            slog.New(slog.NewTextHandler(os.Stdout, nil)),
 		BufferSize:        1,
+		MaxBatch:          1,
+		FanoutBatchSize:   fanoutBatchSizeDefault,
+		RegistryShards:    registryShardsDefault,
 		EmitStrategy:      EmitStrategyBlock,
+		Metrics:           noopServerMetrics{},
+		Tracer:            noopTracer{},
+		MetricsPath:       metricsPathDefault,
+		Compressors:       []Compressor{GzipCompressor{}},
+		PprofPath:         pprofPathDefault,
+		PprofAuthorize:    isLoopbackRequest,
+		StatsAuthorize:    isLoopbackRequest,
+		EmitActor:         clientIP,
 	}
 
 	if options != nil {
@@ -55,9 +220,95 @@ func newUpdatedOptions(options *Options) *Options {
 			updatedOptions.BufferSize = options.BufferSize
 		}
 
+		if options.WriteTimeout > 0 {
+			updatedOptions.WriteTimeout = options.WriteTimeout
+		}
+
+		if options.MaxBatch > 0 {
+			updatedOptions.MaxBatch = options.MaxBatch
+		}
+
+		if options.MaxDelay > 0 {
+			updatedOptions.MaxDelay = options.MaxDelay
+		}
+
+		if options.FanoutWorkers > 0 {
+			updatedOptions.FanoutWorkers = options.FanoutWorkers
+		}
+
+		if options.FanoutBatchSize > 0 {
+			updatedOptions.FanoutBatchSize = options.FanoutBatchSize
+		}
+
+		if options.RegistryShards > 0 {
+			updatedOptions.RegistryShards = options.RegistryShards
+		}
+
+		updatedOptions.Faults = options.Faults
+		updatedOptions.EmitRateLimit = options.EmitRateLimit
+		updatedOptions.EmitKeyRateLimit = options.EmitKeyRateLimit
+		updatedOptions.EmitRateLimitKey = options.EmitRateLimitKey
+		updatedOptions.DisableEmitEndpoint = options.DisableEmitEndpoint
+		updatedOptions.RedactEvent = options.RedactEvent
+		updatedOptions.Auditor = options.Auditor
+		if options.EmitActor != nil {
+			updatedOptions.EmitActor = options.EmitActor
+		}
+		updatedOptions.SendHello = options.SendHello
+		updatedOptions.HeartbeatComment = options.HeartbeatComment
+		updatedOptions.OnAck = options.OnAck
+		updatedOptions.DynamicTopics = options.DynamicTopics
+		updatedOptions.Middlewares = options.Middlewares
+		updatedOptions.HeartbeatEvent = options.HeartbeatEvent
+		updatedOptions.EnableCompression = options.EnableCompression
+		if len(options.Compressors) > 0 {
+			updatedOptions.Compressors = options.Compressors
+		}
+		updatedOptions.SubscriberFilter = options.SubscriberFilter
+		updatedOptions.AutoEventID = options.AutoEventID
+		updatedOptions.ValidateEvent = options.ValidateEvent
+		updatedOptions.SubscriberIDFunc = options.SubscriberIDFunc
+		updatedOptions.ReplayBufferSize = options.ReplayBufferSize
+		updatedOptions.EventStore = options.EventStore
 		updatedOptions.Handlers = options.Handlers
 		updatedOptions.SseUrl = options.SseUrl
 		updatedOptions.EmitStrategy = options.EmitStrategy
+		updatedOptions.Sources = options.Sources
+		updatedOptions.Bridge = options.Bridge
+		updatedOptions.UserIDFunc = options.UserIDFunc
+		updatedOptions.AllowedOrigins = options.AllowedOrigins
+		updatedOptions.TLSConfig = options.TLSConfig
+		updatedOptions.EnableH2C = options.EnableH2C
+		updatedOptions.ClientCAs = options.ClientCAs
+		updatedOptions.Authenticate = options.Authenticate
+		updatedOptions.AuthenticateEmit = options.AuthenticateEmit
+		updatedOptions.ShutdownEvent = options.ShutdownEvent
+		updatedOptions.OnConnect = options.OnConnect
+		updatedOptions.OnDisconnect = options.OnDisconnect
+		updatedOptions.OnEmitError = options.OnEmitError
+		if options.Metrics != nil {
+			updatedOptions.Metrics = options.Metrics
+		}
+		if options.Tracer != nil {
+			updatedOptions.Tracer = options.Tracer
+		}
+		updatedOptions.PropagateTraceContext = options.PropagateTraceContext
+		updatedOptions.ExpvarName = options.ExpvarName
+		updatedOptions.MetricsHandler = options.MetricsHandler
+		if options.MetricsPath != "" {
+			updatedOptions.MetricsPath = options.MetricsPath
+		}
+		updatedOptions.EnablePprof = options.EnablePprof
+		if options.PprofPath != "" {
+			updatedOptions.PprofPath = options.PprofPath
+		}
+		if options.PprofAuthorize != nil {
+			updatedOptions.PprofAuthorize = options.PprofAuthorize
+		}
+		updatedOptions.EnableStats = options.EnableStats
+		if options.StatsAuthorize != nil {
+			updatedOptions.StatsAuthorize = options.StatsAuthorize
+		}
 	}
 
 	return updatedOptions
@@ -0,0 +1,44 @@
+package ssevents
+
+import (
+	"net/http"
+	"strings"
+)
+
+// originAllowed reports if the Origin header of the request matches one of the allowed patterns. Requests without an
+// Origin, like those of non-browser clients, are allowed. A pattern is an exact origin like https://app.example.com,
+// a wildcard subdomain like https://*.example.com or * allowing any origin.
+func originAllowed(req *http.Request, allowed []string) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, pattern := range allowed {
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" || strings.EqualFold(pattern, origin) {
+		return true
+	}
+
+	prefix, suffix, found := strings.Cut(pattern, "*.")
+	if !found {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	prefix, suffix = strings.ToLower(prefix), "."+strings.ToLower(suffix)
+	if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	// The wildcard matches one or more subdomain labels, never the scheme or port separators
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+
+	return subdomain != "" && !strings.ContainsAny(subdomain, "/:")
+}
@@ -0,0 +1,140 @@
+// Package otelsse implements the ssevents.Tracer with OpenTelemetry, creating a span per SSE connection, a span per
+// emit and linking every delivery to both of them, so event latency can be traced from the /emit request to the write
+// on each connection.
+//
+//	server, err := ssevents.NewServer(&ssevents.Options{Tracer: otelsse.NewTracer(nil)})
+package otelsse
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/doppelganger113/ssevents/otelsse"
+
+type Options struct {
+	// TracerProvider default is the global otel.GetTracerProvider()
+	TracerProvider trace.TracerProvider
+	// Propagator used for extracting the trace from /emit requests, default is the global otel.GetTextMapPropagator()
+	Propagator propagation.TextMapPropagator
+}
+
+// Tracer is the OpenTelemetry ssevents.Tracer.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ ssevents.Tracer = (*Tracer)(nil)
+
+// NewTracer creates the tracer, nil options use the global provider and propagator.
+func NewTracer(options *Options) *Tracer {
+	provider := otel.GetTracerProvider()
+	propagator := otel.GetTextMapPropagator()
+	if options != nil {
+		if options.TracerProvider != nil {
+			provider = options.TracerProvider
+		}
+		if options.Propagator != nil {
+			propagator = options.Propagator
+		}
+	}
+
+	return &Tracer{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagator,
+	}
+}
+
+func (t *Tracer) Extract(ctx context.Context, header http.Header) context.Context {
+	return t.propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+func (t *Tracer) StartConnection(ctx context.Context, info ssevents.ConnInfo) (context.Context, func(reason string)) {
+	attributes := []attribute.KeyValue{
+		attribute.String("sse.connection.id", info.ID),
+		attribute.String("client.address", info.RemoteAddr),
+	}
+	if info.UserID != "" {
+		attributes = append(attributes, attribute.String("enduser.id", info.UserID))
+	}
+
+	connCtx, span := t.tracer.Start(ctx, "sse.connection",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attributes...),
+	)
+
+	return connCtx, func(reason string) {
+		span.SetAttributes(attribute.String("sse.disconnect.reason", reason))
+		if reason == ssevents.DisconnectReasonWriteFailed {
+			span.SetStatus(codes.Error, reason)
+		}
+		span.End()
+	}
+}
+
+func (t *Tracer) StartEmit(ctx context.Context, e ssevents.Event) (context.Context, func()) {
+	emitCtx, span := t.tracer.Start(ctx, "sse.emit",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(eventAttributes(e)...),
+	)
+
+	return emitCtx, func() {
+		span.End()
+	}
+}
+
+// Delivered records the delivery as an event on the connection span, linked to the emit span, so the time between
+// emitting and writing to the connection is visible on both.
+func (t *Tracer) Delivered(connCtx, emitCtx context.Context, e ssevents.Event) {
+	connSpan := trace.SpanFromContext(connCtx)
+	emitSpan := trace.SpanFromContext(emitCtx)
+
+	attributes := eventAttributes(e)
+	if emitSpan.SpanContext().IsValid() {
+		attributes = append(attributes,
+			attribute.String("sse.emit.trace_id", emitSpan.SpanContext().TraceID().String()),
+			attribute.String("sse.emit.span_id", emitSpan.SpanContext().SpanID().String()),
+		)
+	}
+	connSpan.AddEvent("sse.delivered", trace.WithAttributes(attributes...), trace.WithTimestamp(time.Now()))
+
+	_, span := t.tracer.Start(emitCtx, "sse.deliver",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithLinks(trace.LinkFromContext(connCtx)),
+		trace.WithAttributes(attributes...),
+	)
+	span.End()
+}
+
+func (t *Tracer) Inject(ctx context.Context, carrier map[string]string) {
+	t.propagator.Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+// ExtractEvent returns the context carrying the trace propagated through the event Extensions, use it on the consumer
+// side for continuing the trace of the emit.
+func (t *Tracer) ExtractEvent(ctx context.Context, e ssevents.Event) context.Context {
+	if len(e.Extensions) == 0 {
+		return ctx
+	}
+	return t.propagator.Extract(ctx, propagation.MapCarrier(e.Extensions))
+}
+
+func eventAttributes(e ssevents.Event) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	if e.Event != "" {
+		attributes = append(attributes, attribute.String("sse.event.name", e.Event))
+	}
+	if e.Id != "" {
+		attributes = append(attributes, attribute.String("sse.event.id", e.Id))
+	}
+	return attributes
+}
@@ -0,0 +1,233 @@
+// Package otlp receives OpenTelemetry logs over OTLP/HTTP with JSON encoding and emits every log record as an SSE
+// event, enabling live "debug console" streaming of application telemetry to the browser during development.
+//
+// Mount the Handler on the OTLP logs path and point an SDK or the collector otlphttp exporter, with encoding: json,
+// to the server. The Options Handlers are mounted when the server is created, so the handler emits through an
+// ssevents.EmitterFunc bound to the server afterwards:
+//
+//	var server *ssevents.Server
+//	emitter := ssevents.EmitterFunc(func(e ssevents.Event) { server.Emit(e) })
+//	server, err := ssevents.NewServer(&ssevents.Options{Handlers: map[string]http.HandlerFunc{
+//		otlp.Route: otlp.Handler(emitter),
+//	}})
+package otlp
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	// Route is the default OTLP/HTTP logs route
+	Route = "POST /v1/logs"
+	// EventLog is the name of emitted log record events
+	EventLog = "log"
+	// MaxBodySize is the largest accepted export request, after decompression
+	MaxBodySize = 4 << 20
+)
+
+// Record is the flattened log record sent as the event data.
+type Record struct {
+	Time           time.Time      `json:"time"`
+	SeverityText   string         `json:"severityText,omitempty"`
+	SeverityNumber int            `json:"severityNumber,omitempty"`
+	Body           any            `json:"body,omitempty"`
+	Attributes     map[string]any `json:"attributes,omitempty"`
+	Resource       map[string]any `json:"resource,omitempty"`
+	Scope          string         `json:"scope,omitempty"`
+	TraceID        string         `json:"traceId,omitempty"`
+	SpanID         string         `json:"spanId,omitempty"`
+}
+
+// exportLogsRequest mirrors the OTLP ExportLogsServiceRequest JSON encoding
+type exportLogsRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []keyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano         string     `json:"timeUnixNano"`
+				ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+				SeverityNumber       int        `json:"severityNumber"`
+				SeverityText         string     `json:"severityText"`
+				Body                 anyValue   `json:"body"`
+				Attributes           []keyValue `json:"attributes"`
+				TraceID              string     `json:"traceId"`
+				SpanID               string     `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue"`
+	BoolValue   *bool   `json:"boolValue"`
+	// IntValue is a string in the JSON encoding as it is 64-bit
+	IntValue    json.Number `json:"intValue"`
+	DoubleValue *float64    `json:"doubleValue"`
+	BytesValue  []byte      `json:"bytesValue"`
+	ArrayValue  *struct {
+		Values []anyValue `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []keyValue `json:"values"`
+	} `json:"kvlistValue"`
+}
+
+func (v anyValue) value() any {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != "":
+		if i, err := v.IntValue.Int64(); err == nil {
+			return i
+		}
+		return v.IntValue.String()
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BytesValue != nil:
+		return v.BytesValue
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, item := range v.ArrayValue.Values {
+			values = append(values, item.value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		return toMap(v.KvlistValue.Values)
+	default:
+		return nil
+	}
+}
+
+func toMap(values []keyValue) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(values))
+	for _, kv := range values {
+		m[kv.Key] = kv.Value.value()
+	}
+	return m
+}
+
+func parseUnixNano(value string) time.Time {
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos).UTC()
+}
+
+// ToEvents converts an OTLP/HTTP JSON logs export request into EventLog events with Record data.
+func ToEvents(body []byte) ([]ssevents.Event, error) {
+	var request exportLogsRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("failed decoding OTLP logs: %w", err)
+	}
+
+	var events []ssevents.Event
+	for _, resourceLogs := range request.ResourceLogs {
+		resource := toMap(resourceLogs.Resource.Attributes)
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			for _, log := range scopeLogs.LogRecords {
+				record := Record{
+					Time:           parseUnixNano(log.TimeUnixNano),
+					SeverityText:   log.SeverityText,
+					SeverityNumber: log.SeverityNumber,
+					Body:           log.Body.value(),
+					Attributes:     toMap(log.Attributes),
+					Resource:       resource,
+					Scope:          scopeLogs.Scope.Name,
+					TraceID:        log.TraceID,
+					SpanID:         log.SpanID,
+				}
+				if record.Time.IsZero() {
+					record.Time = parseUnixNano(log.ObservedTimeUnixNano)
+				}
+
+				data, err := json.Marshal(record)
+				if err != nil {
+					return nil, fmt.Errorf("failed encoding log record: %w", err)
+				}
+				events = append(events, ssevents.Event{Event: EventLog, Data: string(data)})
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// Handler receives OTLP/HTTP JSON logs export requests and emits every log record. Protobuf encoded requests are
+// rejected with 415 Unsupported Media Type, gzip compressed ones are decoded and requests larger than MaxBodySize are
+// rejected with 413 Request Entity Too Large.
+func Handler(emitter ssevents.Emitter) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+			http.Error(w, "failed: only application/json OTLP encoding is supported", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body, err := readBody(w, req)
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, "failed: "+err.Error(), status)
+			return
+		}
+		events, err := ToEvents(body)
+		if err != nil {
+			http.Error(w, "failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, evt := range events {
+			emitter.Emit(evt)
+		}
+
+		// Empty ExportLogsServiceResponse signals full success
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}
+}
+
+// readBody reads the request body up to MaxBodySize, decoding the gzip content coding used by the OTLP exporters
+func readBody(w http.ResponseWriter, req *http.Request) ([]byte, error) {
+	var body io.ReadCloser = http.MaxBytesReader(w, req.Body, MaxBodySize)
+	switch encoding := req.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding gzip body: %w", err)
+		}
+		// The decompressed size is bounded as well, so small compressed bodies can not expand without limit
+		body = http.MaxBytesReader(w, gz, MaxBodySize)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	return io.ReadAll(body)
+}
@@ -0,0 +1,228 @@
+// Package outbox provides a ssevents.Source that polls a transactional outbox table and emits its rows as events,
+// allowing applications to write events in the same database transaction as their business data.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	pollIntervalDefault = time.Second
+	cursorColumnDefault = "id"
+	eventColumnDefault  = "event"
+	dataColumnDefault   = "data"
+)
+
+var (
+	ErrMissingDB    = errors.New("outbox: DB is required")
+	ErrMissingQuery = errors.New("outbox: Query is required")
+)
+
+type Options struct {
+	// DB is the database holding the outbox table
+	DB *sql.DB
+	// Query selects the rows that were not yet dispatched, it receives the last seen cursor as its only argument, e.g.
+	//
+	//	SELECT id, event, data FROM outbox WHERE id > $1 AND dispatched_at IS NULL ORDER BY id LIMIT 100
+	Query string
+	// MarkDispatched is executed with the cursor of every emitted row, e.g.
+	//
+	//	UPDATE outbox SET dispatched_at = now() WHERE id = $1
+	//
+	// When empty rows are not marked and only the in-memory cursor prevents them from being emitted again.
+	MarkDispatched string
+	// CursorColumn is the column used for ordering rows and as the event id, default is "id"
+	CursorColumn string
+	// EventColumn holds the event name, default is "event". The column is optional in the query result.
+	EventColumn string
+	// DataColumn holds the event data, default is "data"
+	DataColumn string
+	// StartCursor is the initial cursor value passed to the Query, default is 0
+	StartCursor any
+	// PollInterval defines how often the table is polled, default is 1s
+	PollInterval time.Duration
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Poller is a ssevents.Source emitting new outbox rows.
+type Poller struct {
+	db             *sql.DB
+	query          string
+	markDispatched string
+	cursorColumn   string
+	eventColumn    string
+	dataColumn     string
+	cursor         any
+	interval       time.Duration
+	logger         *slog.Logger
+}
+
+var _ ssevents.Source = (*Poller)(nil)
+
+// NewPoller creates the outbox source, pass it to ssevents.Options Sources to have it started with the server.
+func NewPoller(options Options) (*Poller, error) {
+	if options.DB == nil {
+		return nil, ErrMissingDB
+	}
+	if options.Query == "" {
+		return nil, ErrMissingQuery
+	}
+
+	p := &Poller{
+		db:             options.DB,
+		query:          options.Query,
+		markDispatched: options.MarkDispatched,
+		cursorColumn:   cursorColumnDefault,
+		eventColumn:    eventColumnDefault,
+		dataColumn:     dataColumnDefault,
+		cursor:         options.StartCursor,
+		interval:       pollIntervalDefault,
+		logger:         slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	if options.CursorColumn != "" {
+		p.cursorColumn = options.CursorColumn
+	}
+	if options.EventColumn != "" {
+		p.eventColumn = options.EventColumn
+	}
+	if options.DataColumn != "" {
+		p.dataColumn = options.DataColumn
+	}
+	if p.cursor == nil {
+		p.cursor = int64(0)
+	}
+	if options.PollInterval > 0 {
+		p.interval = options.PollInterval
+	}
+	if options.Logger != nil {
+		p.logger = options.Logger
+	}
+
+	return p, nil
+}
+
+// Run polls the outbox table until the ctx is cancelled. Failed polls are logged and retried on the next tick.
+func (p *Poller) Run(ctx context.Context, emit func(e ssevents.Event)) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Poll(ctx, emit); err != nil && ctx.Err() == nil {
+			p.logger.Error("failed polling outbox", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type row struct {
+	cursor any
+	event  ssevents.Event
+}
+
+// Poll executes a single poll, emitting and marking every returned row.
+func (p *Poller) Poll(ctx context.Context, emit func(e ssevents.Event)) error {
+	rows, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		emit(r.event)
+		p.cursor = r.cursor
+
+		if p.markDispatched == "" {
+			continue
+		}
+		if _, err = p.db.ExecContext(ctx, p.markDispatched, r.cursor); err != nil {
+			return fmt.Errorf("failed marking outbox row %s as dispatched: %w", r.event.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Poller) fetch(ctx context.Context) (result []row, err error) {
+	rows, err := p.db.QueryContext(ctx, p.query, p.cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying outbox: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading outbox columns: %w", err)
+	}
+
+	cursorIdx, eventIdx, dataIdx := -1, -1, -1
+	for i, column := range columns {
+		switch column {
+		case p.cursorColumn:
+			cursorIdx = i
+		case p.eventColumn:
+			eventIdx = i
+		case p.dataColumn:
+			dataIdx = i
+		}
+	}
+	if cursorIdx < 0 || dataIdx < 0 {
+		return nil, fmt.Errorf(
+			"outbox query must select the %q and %q columns, got %v", p.cursorColumn, p.dataColumn, columns,
+		)
+	}
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed scanning outbox row: %w", err)
+		}
+
+		r := row{
+			cursor: values[cursorIdx],
+			event: ssevents.Event{
+				Id:   asString(values[cursorIdx]),
+				Data: asString(values[dataIdx]),
+			},
+		}
+		if eventIdx >= 0 {
+			r.event.Event = asString(values[eventIdx])
+		}
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+func asString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,52 @@
+package ssevents
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+const pprofPathDefault = "/debug/pprof/"
+
+// mountPprof registers the net/http/pprof handlers under the path, every request is first checked by the authorize
+// function
+func mountPprof(mux *http.ServeMux, path string, authorize func(req *http.Request) bool) {
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	guard := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if !authorize(req) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			handler(w, req)
+		}
+	}
+
+	// Index serves the named profiles, like goroutine and heap, and expects to be mounted on /debug/pprof/
+	mux.HandleFunc("GET "+path, guard(func(w http.ResponseWriter, req *http.Request) {
+		if name, found := strings.CutPrefix(req.URL.Path, path); found && name != "" {
+			pprof.Handler(name).ServeHTTP(w, req)
+			return
+		}
+		pprof.Index(w, req)
+	}))
+	mux.HandleFunc("GET "+path+"cmdline", guard(pprof.Cmdline))
+	mux.HandleFunc("GET "+path+"profile", guard(pprof.Profile))
+	mux.HandleFunc("GET "+path+"symbol", guard(pprof.Symbol))
+	mux.HandleFunc("POST "+path+"symbol", guard(pprof.Symbol))
+	mux.HandleFunc("GET "+path+"trace", guard(pprof.Trace))
+}
+
+// isLoopbackRequest is the default pprof and stats guard allowing only requests coming from the same machine
+func isLoopbackRequest(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
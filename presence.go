@@ -0,0 +1,118 @@
+package ssevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// statsPath is the endpoint serving the Stats, below the SSE endpoint, e.g. /sse/stats
+const statsPath = "/stats"
+
+// Stats is the body of GET /sse/stats, it leaves out the headers, claims and certificates of the connections
+type Stats struct {
+	// Subscribers is the number of subscribers, see HttpController.SubscriberCount
+	Subscribers int `json:"subscribers"`
+	// Topics holds the number of connections subscribed to every topic
+	Topics map[string]int `json:"topics"`
+	// Connections describe the SSE connections ordered by the time they were established
+	Connections []ConnStats `json:"connections"`
+}
+
+// ConnStats describes a single SSE connection of the Stats
+type ConnStats struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId,omitempty"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	Protocol    string    `json:"protocol"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	Topics      []string  `json:"topics,omitempty"`
+	// QueueDepth is the number of events waiting to be sent to the connection
+	QueueDepth int `json:"queueDepth"`
+}
+
+// Subscribers returns the information of the SSE connections ordered by the time they were established, with the
+// topics they are currently subscribed to
+func (c *HttpController) Subscribers() []ConnInfo {
+	var infos []ConnInfo
+	c.hub.subscribers.each(func(sub *subscriber) {
+		if sub.info.ID != "" {
+			infos = append(infos, sub.connInfo())
+		}
+	})
+	slices.SortFunc(infos, func(a, b ConnInfo) int {
+		return a.ConnectedAt.Compare(b.ConnectedAt)
+	})
+
+	return infos
+}
+
+// SubscriberCount returns the number of subscribers, including the ones stored without a connection
+func (c *HttpController) SubscriberCount() int {
+	return c.hub.subscribers.len()
+}
+
+// TopicSubscriberCount returns the number of connections subscribed to the topic
+func (c *HttpController) TopicSubscriberCount(topic string) int {
+	var count int
+	c.hub.subscribers.each(func(sub *subscriber) {
+		if sub.connInfo().Subscribed(topic) {
+			count++
+		}
+	})
+
+	return count
+}
+
+func (c *HttpController) stats() Stats {
+	stats := Stats{
+		Subscribers: c.SubscriberCount(),
+		Topics:      make(map[string]int),
+		Connections: []ConnStats{},
+	}
+	depths := c.QueueDepths()
+	for _, info := range c.Subscribers() {
+		for _, topic := range info.Topics {
+			stats.Topics[topic]++
+		}
+		stats.Connections = append(stats.Connections, ConnStats{
+			ID:          info.ID,
+			UserID:      info.UserID,
+			RemoteAddr:  info.RemoteAddr,
+			Protocol:    info.Protocol,
+			ConnectedAt: info.ConnectedAt,
+			Topics:      info.Topics,
+			QueueDepth:  depths[info.ID],
+		})
+	}
+
+	return stats
+}
+
+// newStatsHandler serves the Stats as JSON to the callers allowed by Options StatsAuthorize
+func newStatsHandler(sseCtrl *HttpController) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !sseCtrl.options.StatsAuthorize(req) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sseCtrl.stats())
+	}
+}
+
+// Subscribers returns the information of the SSE connections, see HttpController.Subscribers
+func (s *Server) Subscribers() []ConnInfo {
+	return s.sseCtrl.Subscribers()
+}
+
+// SubscriberCount returns the number of subscribers, see HttpController.SubscriberCount
+func (s *Server) SubscriberCount() int {
+	return s.sseCtrl.SubscriberCount()
+}
+
+// TopicSubscriberCount returns the number of connections subscribed to the topic
+func (s *Server) TopicSubscriberCount(topic string) int {
+	return s.sseCtrl.TopicSubscriberCount(topic)
+}
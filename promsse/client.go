@@ -0,0 +1,127 @@
+package promsse
+
+import (
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const subsystemClient = "sse_client"
+
+// ClientCollector is a prometheus.Collector for the client measurements.
+type ClientCollector struct {
+	mu             sync.Mutex
+	connectedAt    time.Time
+	connected      prometheus.Gauge
+	uptime         prometheus.GaugeFunc
+	reconnects     prometheus.Counter
+	eventsReceived *prometheus.CounterVec
+	parseErrors    prometheus.Counter
+	bytesRead      prometheus.Counter
+	observerDrops  prometheus.Counter
+}
+
+var (
+	_ ssevents.ClientMetrics = (*ClientCollector)(nil)
+	_ prometheus.Collector   = (*ClientCollector)(nil)
+)
+
+// NewClientCollector creates the collector with all metrics prefixed by the namespace, which can be empty.
+func NewClientCollector(namespace string) *ClientCollector {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{Namespace: namespace, Subsystem: subsystemClient, Name: name, Help: help}
+	}
+
+	c := &ClientCollector{
+		connected: prometheus.NewGauge(prometheus.GaugeOpts(
+			opts("connected", "Whether the client is currently connected to the server, 1 or 0."),
+		)),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("reconnect_attempts_total", "Total number of reconnection attempts."),
+		)),
+		eventsReceived: prometheus.NewCounterVec(prometheus.CounterOpts(
+			opts("events_received_total", "Total number of received events, per event name."),
+		), []string{"event"}),
+		parseErrors: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("parse_errors_total", "Total number of stream lines that could not be parsed."),
+		)),
+		bytesRead: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("read_bytes_total", "Total number of bytes read from the stream."),
+		)),
+		observerDrops: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("observer_drops_total", "Total number of events not delivered to slow observers."),
+		)),
+	}
+	c.uptime = prometheus.NewGaugeFunc(prometheus.GaugeOpts(
+		opts("connection_uptime_seconds", "Duration of the current connection, 0 when disconnected."),
+	), c.uptimeSeconds)
+
+	return c
+}
+
+func (c *ClientCollector) uptimeSeconds() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connectedAt.IsZero() {
+		return 0
+	}
+	return time.Since(c.connectedAt).Seconds()
+}
+
+func (c *ClientCollector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.connected, c.uptime, c.reconnects, c.eventsReceived, c.parseErrors, c.bytesRead, c.observerDrops,
+	}
+}
+
+func (c *ClientCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+func (c *ClientCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func (c *ClientCollector) Connected() {
+	c.mu.Lock()
+	c.connectedAt = time.Now()
+	c.mu.Unlock()
+	c.connected.Set(1)
+}
+
+func (c *ClientCollector) Disconnected() {
+	c.mu.Lock()
+	c.connectedAt = time.Time{}
+	c.mu.Unlock()
+	c.connected.Set(0)
+}
+
+func (c *ClientCollector) ReconnectAttempted() {
+	c.reconnects.Inc()
+}
+
+func (c *ClientCollector) EventReceived(name string) {
+	if name == "" {
+		// Unnamed events are dispatched as message by the browser EventSource
+		name = "message"
+	}
+	c.eventsReceived.WithLabelValues(name).Inc()
+}
+
+func (c *ClientCollector) ParseFailed() {
+	c.parseErrors.Inc()
+}
+
+func (c *ClientCollector) BytesRead(n int) {
+	c.bytesRead.Add(float64(n))
+}
+
+func (c *ClientCollector) ObserverDropped() {
+	c.observerDrops.Inc()
+}
@@ -0,0 +1,44 @@
+package promsse
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves the metrics of the gatherer in the Prometheus exposition format, pass it to ssevents.Options
+// MetricsHandler. A nil gatherer serves the prometheus.DefaultGatherer.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// RegisterServerCollector creates the server collector and registers it with the registerer, a nil registerer uses
+// the prometheus.DefaultRegisterer.
+func RegisterServerCollector(registerer prometheus.Registerer, namespace string) (*ServerCollector, error) {
+	collector := NewServerCollector(namespace)
+	if err := register(registerer, collector); err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
+
+// RegisterClientCollector creates the client collector and registers it with the registerer, a nil registerer uses
+// the prometheus.DefaultRegisterer.
+func RegisterClientCollector(registerer prometheus.Registerer, namespace string) (*ClientCollector, error) {
+	collector := NewClientCollector(namespace)
+	if err := register(registerer, collector); err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
+
+func register(registerer prometheus.Registerer, collector prometheus.Collector) error {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	return registerer.Register(collector)
+}
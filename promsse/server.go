@@ -0,0 +1,146 @@
+// Package promsse provides Prometheus collectors implementing the ssevents metrics interfaces, register them with any
+// prometheus.Registerer and pass them through the options:
+//
+//	collector := promsse.NewServerCollector("myapp")
+//	prometheus.MustRegister(collector)
+//	server, err := ssevents.NewServer(&ssevents.Options{Metrics: collector})
+package promsse
+
+import (
+	"github.com/doppelganger113/ssevents"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const subsystemServer = "sse_server"
+
+// ServerCollector is a prometheus.Collector for the server measurements.
+type ServerCollector struct {
+	activeConnections prometheus.Gauge
+	connects          prometheus.Counter
+	disconnects       prometheus.Counter
+	emitted           prometheus.Counter
+	dropped           *prometheus.CounterVec
+	writeErrors       prometheus.Counter
+	flushErrors       prometheus.Counter
+	writeTimeouts     prometheus.Counter
+	heartbeats        prometheus.Counter
+	heartbeatFailures prometheus.Counter
+	emitRateLimited   prometheus.Counter
+	rejected          prometheus.Counter
+}
+
+var (
+	_ ssevents.ServerMetrics = (*ServerCollector)(nil)
+	_ prometheus.Collector   = (*ServerCollector)(nil)
+)
+
+// NewServerCollector creates the collector with all metrics prefixed by the namespace, which can be empty.
+func NewServerCollector(namespace string) *ServerCollector {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{Namespace: namespace, Subsystem: subsystemServer, Name: name, Help: help}
+	}
+
+	return &ServerCollector{
+		activeConnections: prometheus.NewGauge(prometheus.GaugeOpts(
+			opts("active_connections", "Number of currently connected SSE subscribers."),
+		)),
+		connects: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("connects_total", "Total number of established SSE connections."),
+		)),
+		disconnects: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("disconnects_total", "Total number of closed SSE connections."),
+		)),
+		emitted: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("events_emitted_total", "Total number of emitted events."),
+		)),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts(
+			opts("events_dropped_total", "Total number of events not delivered to a subscriber, per emit strategy."),
+		), []string{"strategy"}),
+		writeErrors: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("write_errors_total", "Total number of failed writes to SSE connections."),
+		)),
+		flushErrors: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("flush_errors_total", "Total number of failed flushes of SSE connections."),
+		)),
+		writeTimeouts: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("write_timeouts_total", "Total number of SSE connections closed by a write exceeding the timeout."),
+		)),
+		heartbeats: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("heartbeats_sent_total", "Total number of heartbeats sent to SSE connections."),
+		)),
+		heartbeatFailures: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("heartbeat_failures_total", "Total number of heartbeats that failed to be sent."),
+		)),
+		emitRateLimited: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("emit_rate_limited_total", "Total number of emit requests rejected by the rate limits."),
+		)),
+		rejected: prometheus.NewCounter(prometheus.CounterOpts(
+			opts("events_rejected_total", "Total number of emitted events rejected by the event validation."),
+		)),
+	}
+}
+
+func (c *ServerCollector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.activeConnections, c.connects, c.disconnects, c.emitted, c.dropped, c.writeErrors, c.flushErrors,
+		c.writeTimeouts, c.heartbeats, c.heartbeatFailures, c.emitRateLimited, c.rejected,
+	}
+}
+
+func (c *ServerCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+func (c *ServerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func (c *ServerCollector) Connected() {
+	c.activeConnections.Inc()
+	c.connects.Inc()
+}
+
+func (c *ServerCollector) Disconnected() {
+	c.activeConnections.Dec()
+	c.disconnects.Inc()
+}
+
+func (c *ServerCollector) Emitted() {
+	c.emitted.Inc()
+}
+
+func (c *ServerCollector) Dropped(strategy ssevents.EmitStrategy) {
+	c.dropped.WithLabelValues(strategy.String()).Inc()
+}
+
+func (c *ServerCollector) WriteFailed() {
+	c.writeErrors.Inc()
+}
+
+func (c *ServerCollector) FlushFailed() {
+	c.flushErrors.Inc()
+}
+
+func (c *ServerCollector) WriteTimedOut() {
+	c.writeTimeouts.Inc()
+}
+
+func (c *ServerCollector) HeartbeatSent() {
+	c.heartbeats.Inc()
+}
+
+func (c *ServerCollector) HeartbeatFailed() {
+	c.heartbeatFailures.Inc()
+}
+
+func (c *ServerCollector) EmitRateLimited() {
+	c.emitRateLimited.Inc()
+}
+
+func (c *ServerCollector) EventRejected() {
+	c.rejected.Inc()
+}
@@ -0,0 +1,61 @@
+// Package protosse provides the Protocol Buffers ssevents.Codec, the event data is sent base64 encoded as SSE carries
+// only text:
+//
+//	err := server.EmitEncoded(protosse.Codec, "order-created", &pb.Order{Id: 1})
+//	orders := ssevents.SubscribeCodec[*pb.Order](client, nil, protosse.Codec)
+package protosse
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/doppelganger113/ssevents"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes proto.Message values in the protobuf wire format
+var Codec ssevents.Codec = codec{}
+
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protosse: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes into a proto.Message, or a pointer to one which is allocated when nil as done by the typed
+// observers of message pointers
+func (codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		m, ok = messageOf(v)
+	}
+	if !ok {
+		return fmt.Errorf("protosse: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (codec) Binary() bool {
+	return true
+}
+
+// messageOf returns the proto.Message pointed to by v, allocating it when nil
+func messageOf(v any) (proto.Message, bool) {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Pointer {
+		return nil, false
+	}
+	elem := ptr.Elem()
+	if _, ok := elem.Interface().(proto.Message); !ok {
+		return nil, false
+	}
+	if elem.IsNil() {
+		elem.Set(reflect.New(elem.Type().Elem()))
+	}
+	m, ok := elem.Interface().(proto.Message)
+	return m, ok
+}
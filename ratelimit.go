@@ -0,0 +1,121 @@
+package ssevents
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval defines how often idle per key buckets are removed
+const rateLimitSweepInterval = time.Minute
+
+// RateLimit is a token bucket refilled with Rate tokens per second up to Burst tokens, a zero Rate is unlimited
+type RateLimit struct {
+	// Rate is the number of allowed requests per second
+	Rate float64
+	// Burst is the number of requests allowed at once, default is Rate rounded up and at least 1
+	Burst int
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket and takes a token, returning how long until one is available when the bucket is empty
+func (b *tokenBucket) take(limit RateLimit, now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(limit.burst(), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+}
+
+// emitLimiter applies the global and per key rate limits to the emit endpoint
+type emitLimiter struct {
+	global    RateLimit
+	perKey    RateLimit
+	keyFunc   func(req *http.Request) string
+	mu        sync.Mutex
+	bucket    *tokenBucket
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newEmitLimiter(options *Options) *emitLimiter {
+	if options.EmitRateLimit.Rate <= 0 && options.EmitKeyRateLimit.Rate <= 0 {
+		return nil
+	}
+	now := time.Now()
+	limiter := &emitLimiter{
+		global:    options.EmitRateLimit,
+		perKey:    options.EmitKeyRateLimit,
+		keyFunc:   options.EmitRateLimitKey,
+		bucket:    &tokenBucket{tokens: options.EmitRateLimit.burst(), last: now},
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: now,
+	}
+	if limiter.keyFunc == nil {
+		limiter.keyFunc = emitRateLimitKey
+	}
+
+	return limiter
+}
+
+// allow takes a token for the request returning how long to wait before retrying when it is rejected
+func (l *emitLimiter) allow(req *http.Request) (bool, time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.perKey.Rate > 0 {
+		l.sweep(now)
+		key := l.keyFunc(req)
+		bucket, ok := l.buckets[key]
+		if !ok {
+			bucket = &tokenBucket{tokens: l.perKey.burst(), last: now}
+			l.buckets[key] = bucket
+		}
+		if ok, wait := bucket.take(l.perKey, now); !ok {
+			return false, wait
+		}
+	}
+	if l.global.Rate > 0 {
+		return l.bucket.take(l.global, now)
+	}
+
+	return true, 0
+}
+
+// sweep removes buckets that refilled completely, they are the same as new ones
+func (l *emitLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(l.perKey.burst() / l.perKey.Rate * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// emitRateLimitKey identifies the producer by its X-API-Key header, otherwise by its IP address
+func emitRateLimitKey(req *http.Request) string {
+	if key := req.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return clientIP(req)
+}
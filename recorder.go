@@ -0,0 +1,146 @@
+package ssevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxRecordingSize bounds a line of a recording file, the events are read from it whole
+const maxRecordingSize = 1 << 20
+
+// Recording is an event captured by a Recorder, a recording file holds one JSON encoded Recording per line
+type Recording struct {
+	Time  time.Time `json:"time"`
+	Event Event     `json:"event"`
+}
+
+// Recorder writes the received events with the time of their arrival to a golden file, it is a Sink so a client
+// records a real upstream with:
+//
+//	file, err := os.Create("testdata/orders.jsonl")
+//	client, err := ssevents.NewSSEClient(url, &ssevents.ClientOptions{
+//		Sinks: []ssevents.Sink{ssevents.NewRecorder(file)},
+//	})
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var _ Sink = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder writing to w, closing w is left to the caller
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Record writes the event received now
+func (r *Recorder) Record(e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(Recording{Time: time.Now().UTC(), Event: e}); err != nil {
+		return fmt.Errorf("failed recording event: %w", err)
+	}
+
+	return nil
+}
+
+// Publish records the event consumed by a client
+func (r *Recorder) Publish(_ context.Context, e Event) error {
+	return r.Record(e)
+}
+
+// Replayer plays back the events of a recording file, keeping the delays between them, e.g. through a server:
+//
+//	replayer, err := ssevents.NewReplayer(file)
+//	err = replayer.Replay(ctx, server.Emit)
+//
+// or as the upstream of a client under test, without a network connection:
+//
+//	client, err := ssevents.NewSSEClient("http://upstream.test/sse", &ssevents.ClientOptions{
+//		Transport: ssevents.NewHandlerTransport(replayer.Handler()),
+//	})
+type Replayer struct {
+	Recordings []Recording
+	// Speed divides the recorded delays between the events, e.g. 2 plays them back twice as fast, default 0 plays them
+	// back without delays
+	Speed float64
+}
+
+// NewReplayer reads the recordings written by a Recorder
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	replayer := &Replayer{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordingSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var recording Recording
+		if err := json.Unmarshal(scanner.Bytes(), &recording); err != nil {
+			return nil, fmt.Errorf("failed reading recording on line %d: %w", line, err)
+		}
+		replayer.Recordings = append(replayer.Recordings, recording)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading recordings: %w", err)
+	}
+
+	return replayer, nil
+}
+
+// Replay passes the recorded events to emit in order, returning the ctx error once it is done before all of them
+// were played
+func (p *Replayer) Replay(ctx context.Context, emit func(e Event)) error {
+	for i, recording := range p.Recordings {
+		if i > 0 && p.Speed > 0 {
+			delay := time.Duration(float64(recording.Time.Sub(p.Recordings[i-1].Time)) / p.Speed)
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		emit(recording.Event)
+	}
+
+	return nil
+}
+
+// Handler serves the recording as an SSE stream to every connecting client, the stream stays open once played
+// back, so the clients don't reconnect and receive it again
+func (p *Replayer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		var writeErr error
+		err := p.Replay(req.Context(), func(e Event) {
+			if writeErr != nil {
+				return
+			}
+			if _, writeErr = e.WriteTo(w); writeErr == nil {
+				writeErr = rc.Flush()
+			}
+		})
+		if err != nil || writeErr != nil {
+			return
+		}
+		<-req.Context().Done()
+	})
+}
@@ -0,0 +1,23 @@
+package ssevents
+
+const redactedData = "[REDACTED]"
+
+// RedactEventData replaces the data of the event, use it as the RedactEvent option to keep payloads out of the logs
+func RedactEventData(e Event) Event {
+	if e.Data != "" {
+		e.Data = redactedData
+	}
+	return e
+}
+
+// eventLogArgs describes the event for the logger after passing it through the redact function, when set. The data is
+// included only when withData is set, which is reserved for debug logs.
+func eventLogArgs(e Event, redact func(e Event) Event, withData bool) []any {
+	if redact != nil {
+		e = redact(e)
+	}
+	if withData {
+		return []any{"event", e.Event, "event_id", e.Id, "data", e.Data}
+	}
+	return []any{"event", e.Event, "event_id", e.Id}
+}
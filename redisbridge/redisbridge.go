@@ -0,0 +1,199 @@
+// Package redisbridge relays emitted events between server instances through Redis Pub/Sub, so clients connected to
+// any instance behind a load balancer receive every event.
+//
+//	bridge, err := redisbridge.New(redisbridge.Options{Addr: "redis:6379"})
+//	server, err := ssevents.NewServer(&ssevents.Options{Bridge: bridge})
+//
+// Events are published as JSON to a single channel, it speaks the Redis protocol directly so it has no dependencies.
+package redisbridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	addrDefault        = "localhost:6379"
+	channelDefault     = "ssevents"
+	dialTimeoutDefault = 5 * time.Second
+	retryDelayDefault  = 2 * time.Second
+)
+
+type Options struct {
+	// Addr of the Redis server, default is localhost:6379
+	Addr string
+	// Username for the AUTH command, only with a Password
+	Username string
+	// Password for the AUTH command, default does not authenticate
+	Password string
+	// Channel the events are published to and received from, default is ssevents
+	Channel string
+	// DialTimeout bounds connecting and authenticating, default is 5s
+	DialTimeout time.Duration
+	// RetryDelay defines how long to wait before subscribing again after the connection failed, default is 2s
+	RetryDelay time.Duration
+	// Logger to be used, default is stdout text
+	Logger *slog.Logger
+}
+
+// Bridge is a ssevents.EventBridge over Redis Pub/Sub.
+type Bridge struct {
+	addr        string
+	username    string
+	password    string
+	channel     string
+	dialTimeout time.Duration
+	retryDelay  time.Duration
+	logger      *slog.Logger
+	// mu guards the connection used for publishing, it is dialed on demand and dropped on failures
+	mu   sync.Mutex
+	conn *conn
+}
+
+var _ ssevents.EventBridge = (*Bridge)(nil)
+
+func New(options Options) (*Bridge, error) {
+	b := &Bridge{
+		addr:        addrDefault,
+		username:    options.Username,
+		password:    options.Password,
+		channel:     channelDefault,
+		dialTimeout: dialTimeoutDefault,
+		retryDelay:  retryDelayDefault,
+		logger:      slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	if options.Addr != "" {
+		b.addr = options.Addr
+	}
+	if options.Channel != "" {
+		b.channel = options.Channel
+	}
+	if options.DialTimeout > 0 {
+		b.dialTimeout = options.DialTimeout
+	}
+	if options.RetryDelay > 0 {
+		b.retryDelay = options.RetryDelay
+	}
+	if options.Logger != nil {
+		b.logger = options.Logger
+	}
+	if b.username != "" && b.password == "" {
+		return nil, errors.New("redisbridge: username requires a password")
+	}
+
+	return b, nil
+}
+
+// Publish sends the event as JSON to the channel
+func (b *Bridge) Publish(ctx context.Context, e ssevents.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed encoding event: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil {
+		if b.conn, err = b.dial(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = b.conn.SetDeadline(deadline)
+	} else {
+		_ = b.conn.SetDeadline(time.Time{})
+	}
+	if _, err = b.conn.do("PUBLISH", b.channel, string(payload)); err != nil {
+		_ = b.conn.Close()
+		b.conn = nil
+		return fmt.Errorf("failed publishing event: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe receives the events of the channel until the ctx is cancelled, subscribing again after failures
+func (b *Bridge) Subscribe(ctx context.Context, emit func(e ssevents.Event)) error {
+	for {
+		err := b.subscribe(ctx, emit)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			b.logger.Error("redis subscription failed", "channel", b.channel, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(b.retryDelay):
+		}
+	}
+}
+
+func (b *Bridge) subscribe(ctx context.Context, emit func(e ssevents.Event)) error {
+	c, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	defer stop()
+
+	if err = c.write("SUBSCRIBE", b.channel); err != nil {
+		return fmt.Errorf("failed subscribing: %w", err)
+	}
+	for {
+		reply, readErr := c.read()
+		if readErr != nil {
+			return fmt.Errorf("failed reading subscription: %w", readErr)
+		}
+		message, ok := reply.([]any)
+		if !ok || len(message) != 3 || message[0] != "message" {
+			continue
+		}
+		payload, _ := message[2].(string)
+		var e ssevents.Event
+		if err = json.Unmarshal([]byte(payload), &e); err != nil {
+			b.logger.Error("failed decoding bridged event", "channel", b.channel, "err", err)
+			continue
+		}
+		emit(e)
+	}
+}
+
+// dial connects and authenticates within the DialTimeout
+func (b *Bridge) dial(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: b.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to redis: %w", err)
+	}
+	c := &conn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	if b.password == "" {
+		return c, nil
+	}
+
+	_ = c.SetDeadline(time.Now().Add(b.dialTimeout))
+	args := []string{"AUTH", b.password}
+	if b.username != "" {
+		args = []string{"AUTH", b.username, b.password}
+	}
+	if _, err = c.do(args...); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed authenticating to redis: %w", err)
+	}
+	_ = c.SetDeadline(time.Time{})
+
+	return c, nil
+}
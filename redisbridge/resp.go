@@ -0,0 +1,79 @@
+package redisbridge
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// conn speaks the subset of the Redis serialization protocol used by the bridge
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// do sends the command and reads its reply, error replies are returned as errors
+func (c *conn) do(args ...string) (any, error) {
+	if err := c.write(args...); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// write sends the command as an array of bulk strings
+func (c *conn) write(args ...string) error {
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := c.Write(buf)
+	return err
+}
+
+// read returns the next reply, strings, integers, nil and arrays of them
+func (c *conn) read() (any, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return nil, errors.New(value)
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		size, parseErr := strconv.Atoi(value)
+		if parseErr != nil || size < 0 {
+			return nil, parseErr
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		size, parseErr := strconv.Atoi(value)
+		if parseErr != nil || size < 0 {
+			return nil, parseErr
+		}
+		items := make([]any, size)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported reply %q", line)
+	}
+}
@@ -0,0 +1,110 @@
+package ssevents
+
+import (
+	"fmt"
+	"hash/maphash"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+const registryShardsDefault = 32
+
+// registry holds the subscribers split into shards with a lock of their own, so registering and removing
+// subscribers does not contend with each other nor with emits, which collect every shard independently
+type registry struct {
+	seed   maphash.Seed
+	shards []registryShard
+	// count of the subscribers of all shards, so it is read on every emit without locking them
+	count atomic.Int64
+}
+
+type registryShard struct {
+	mu   sync.RWMutex
+	subs map[any]*subscriber
+}
+
+func newRegistry(shards int) *registry {
+	r := &registry{seed: maphash.MakeSeed(), shards: make([]registryShard, shards)}
+	for i := range r.shards {
+		r.shards[i].subs = make(map[any]*subscriber)
+	}
+	return r
+}
+
+// shardFor returns the shard of the key, keys are usually request contexts or subscribers so pointers are hashed by
+// their address and other keys by their value
+func (r *registry) shardFor(key any) *registryShard {
+	var hash uint64
+	switch v := reflect.ValueOf(key); v.Kind() {
+	case reflect.Pointer, reflect.Chan, reflect.Map, reflect.Func, reflect.UnsafePointer:
+		// Addresses are aligned, the multiplication spreads the higher bits to the lower ones
+		hash = uint64(v.Pointer()) * 0x9E3779B97F4A7C15 >> 32
+	case reflect.String:
+		hash = maphash.String(r.seed, v.String())
+	default:
+		hash = maphash.String(r.seed, fmt.Sprintf("%T:%v", key, key))
+	}
+	return &r.shards[hash%uint64(len(r.shards))]
+}
+
+func (r *registry) store(key any, sub *subscriber) {
+	shard := r.shardFor(key)
+	shard.mu.Lock()
+	if _, ok := shard.subs[key]; !ok {
+		r.count.Add(1)
+	}
+	shard.subs[key] = sub
+	shard.mu.Unlock()
+}
+
+func (r *registry) load(key any) (*subscriber, bool) {
+	shard := r.shardFor(key)
+	shard.mu.RLock()
+	sub, ok := shard.subs[key]
+	shard.mu.RUnlock()
+	return sub, ok
+}
+
+func (r *registry) delete(key any) {
+	shard := r.shardFor(key)
+	shard.mu.Lock()
+	if _, ok := shard.subs[key]; ok {
+		r.count.Add(-1)
+		delete(shard.subs, key)
+	}
+	shard.mu.Unlock()
+}
+
+// len returns the number of subscribers
+func (r *registry) len() int {
+	return int(r.count.Load())
+}
+
+// each calls fn for every subscriber, the shards are not locked while fn runs so it may block or modify the registry
+func (r *registry) each(fn func(sub *subscriber)) {
+	for i := range r.shards {
+		for _, sub := range r.shards[i].collect(nil) {
+			fn(sub)
+		}
+	}
+}
+
+// subscribersPool reuses the slices the shards are collected into, so emitting does not allocate one per shard
+var subscribersPool = sync.Pool{
+	New: func() any {
+		subs := make([]*subscriber, 0, 16)
+		return &subs
+	},
+}
+
+// collect appends the subscribers of the shard to subs. Filtering and deliveries happen on the returned copy, neither
+// a blocked subscriber nor a slow filter must hold the lock the cleanup of a connection needs.
+func (s *registryShard) collect(subs []*subscriber) []*subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
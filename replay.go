@@ -0,0 +1,86 @@
+package ssevents
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type resumeCtxKey struct{}
+
+// resumeState carries the Last-Event-ID of a resuming connection to Store, which fills in the missed events
+type resumeState struct {
+	lastEventID string
+	// ready is closed once the subscriber is stored and missed holds the events to replay
+	ready   chan struct{}
+	missed  []Event
+	found   bool
+	lastSeq uint64
+}
+
+func withResumeState(ctx context.Context, state *resumeState) context.Context {
+	return context.WithValue(ctx, resumeCtxKey{}, state)
+}
+
+func resumeStateFromContext(ctx context.Context) (*resumeState, bool) {
+	state, ok := ctx.Value(resumeCtxKey{}).(*resumeState)
+	return state, ok
+}
+
+// replayBuffer writes the broadcast events with an ID to the EventStore, so connections resuming with Last-Event-ID
+// receive the ones they missed. Every stored event gets a sequence number, letting a connection skip live events
+// already replayed.
+type replayBuffer struct {
+	mu    sync.Mutex
+	store EventStore
+	seq   uint64
+	log   *slog.Logger
+}
+
+func newReplayBuffer(store EventStore, log *slog.Logger) *replayBuffer {
+	return &replayBuffer{store: store, log: log}
+}
+
+// add stores the event returning it with its sequence number, events without an ID can not be resumed from and are
+// not stored so they do not take the place of ones that can.
+func (b *replayBuffer) add(ctx context.Context, e Event) Event {
+	if e.Id == "" {
+		// Without a sequence number the event is never taken for a replayed one
+		return e
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e.seq = b.seq
+
+	stored := e
+	stored.ctx = nil
+	stored.seq = 0
+	if err := b.store.Append(ctx, stored); err != nil {
+		b.log.Error("failed storing event for replay", "event_id", e.Id, "err", err)
+	}
+
+	return e
+}
+
+// storeAndResume stores the subscriber and collects the events it missed atomically with respect to add, every event
+// is then either replayed or delivered live, or both which the connection detects by the sequence number. Missed
+// events rejected by the filter of the subscriber are skipped.
+func (b *replayBuffer) storeAndResume(state *resumeState, filter Filter, store func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	store()
+
+	missed, found, err := b.store.After(context.Background(), state.lastEventID)
+	if err != nil {
+		b.log.Error("failed reading events for replay", "last_event_id", state.lastEventID, "err", err)
+	}
+	state.found = found
+	for _, e := range missed {
+		if filter == nil || filter(e) {
+			state.missed = append(state.missed, e)
+		}
+	}
+	state.lastSeq = b.seq
+	close(state.ready)
+}
@@ -2,41 +2,155 @@ package ssevents
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type Server struct {
 	httpServer *http.Server
+	mux        *http.ServeMux
 	sseCtrl    *HttpController
 	logger     *slog.Logger
+	sources    []Source
+	sourcesWg  sync.WaitGroup
+	sseUrl     string
+	routesMu   sync.Mutex
+	routes     []sseRoute
+}
+
+// sseRoute is an SSE endpoint added with AddSSERoute
+type sseRoute struct {
+	path string
+	ctrl *HttpController
 }
 
 func NewServer(options *Options) (*Server, error) {
 	updatedOptions := newUpdatedOptions(options)
+	if updatedOptions.ExpvarName != "" {
+		if _, err := expvarMap(updatedOptions.ExpvarName); err != nil {
+			return nil, err
+		}
+	}
 
 	sseCtrl := NewController(updatedOptions)
+	mux := createMux(sseCtrl, updatedOptions.Handlers)
 	httpServer := &http.Server{
-		Addr:    ":" + strconv.Itoa(updatedOptions.Port),
-		Handler: createMux(sseCtrl, options, updatedOptions.Handlers),
+		Addr:      ":" + strconv.Itoa(updatedOptions.Port),
+		Handler:   withH2C(withMiddlewares(mux, updatedOptions), updatedOptions),
+		TLSConfig: newTLSConfig(updatedOptions),
 	}
 
 	return &Server{
 		httpServer: httpServer,
+		mux:        mux,
 		sseCtrl:    sseCtrl,
-		logger:     options.Logger,
+		sseUrl:     sseCtrl.sseUrl(),
+		logger:     updatedOptions.Logger,
+		sources:    updatedOptions.Sources,
 	}, nil
 }
 
+// Handler returns the handler of the routes NewServer would serve, the SSE endpoint at SseUrl, POST /emit and the
+// Handlers, for mounting into a server of your own, e.g. with http.StripPrefix. The returned controller emits to the
+// SSE endpoint and its Shutdown closes the connections, Sources are not started.
+func Handler(options *Options) (http.Handler, *HttpController) {
+	sseCtrl := NewController(options)
+	handler := withMiddlewares(createMux(sseCtrl, sseCtrl.options.Handlers), sseCtrl.options)
+	return withH2C(handler, sseCtrl.options), sseCtrl
+}
+
+// withMiddlewares wraps the handler with the Middlewares, the first one being the outermost
+func withMiddlewares(handler http.Handler, options *Options) http.Handler {
+	for _, middleware := range slices.Backward(options.Middlewares) {
+		handler = middleware(handler)
+	}
+	return handler
+}
+
+// withH2C serves HTTP/2 without TLS next to HTTP/1.1 with EnableH2C
+func withH2C(handler http.Handler, options *Options) http.Handler {
+	if options.EnableH2C {
+		return h2c.NewHandler(handler, &http2.Server{})
+	}
+	return handler
+}
+
+// Handler returns the handler serving the routes of the server, e.g. for NewHandlerTransport
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// AddSSERoute adds an SSE endpoint at the path with a controller of its own, e.g. with a different heartbeat
+// interval, buffer size, emit strategy or replay buffer. Options that are not set are defaulted the same way as for
+// NewServer and a nil handler subscribes the connections to the returned controller, which emits to the endpoint.
+// The controller is shut down together with the server, POST /emit always emits to the main SSE endpoint.
+func (s *Server) AddSSERoute(path string, options *Options, handler SSEHandler) (*HttpController, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("sse route %q must start with /", path)
+	}
+
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	if path == s.sseUrl || slices.ContainsFunc(s.routes, func(route sseRoute) bool { return route.path == path }) {
+		return nil, fmt.Errorf("sse route %q already exists", path)
+	}
+
+	ctrl := NewController(options)
+	if handler == nil {
+		s.mux.Handle("GET "+path, ctrl.Handler())
+	} else {
+		s.mux.Handle("GET "+path, ctrl.Middleware(handler))
+	}
+	s.routes = append(s.routes, sseRoute{path: path, ctrl: ctrl})
+
+	return ctrl, nil
+}
+
+// controllers returns the controller of the main SSE endpoint followed by the ones of AddSSERoute
+func (s *Server) controllers() []*HttpController {
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	ctrls := []*HttpController{s.sseCtrl}
+	for _, route := range s.routes {
+		ctrls = append(ctrls, route.ctrl)
+	}
+	return ctrls
+}
+
 // ListenAndServe starts serving HTTP requests and returns an error on unknown failure. Returns nil error when server
 // is closed or shut down.
 func (s *Server) ListenAndServe() error {
-	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+	s.runSources()
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		// Certificates are provided by the TLSConfig
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ListenAndServeTLS is ListenAndServe over HTTPS with the certificate and its private key read from the PEM files,
+// which can be left empty when the certificates are provided by the Options TLSConfig.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	s.runSources()
+	if err := s.httpServer.ListenAndServeTLS(certFile, keyFile); !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 
@@ -46,6 +160,17 @@ func (s *Server) ListenAndServe() error {
 // ListenAndServeOnRandomPort starts a server on a random available port, but does not block so you can use
 // the url address of the server for connecting your client to. The returned channel is used when the server closes.
 func (s *Server) ListenAndServeOnRandomPort() (string, chan error, error) {
+	// The server configures its TLS settings once serving, decide on them before
+	return s.serveOnRandomPort(s.httpServer.TLSConfig != nil, "", "")
+}
+
+// ListenAndServeTLSOnRandomPort is ListenAndServeOnRandomPort over HTTPS, see ListenAndServeTLS, the returned url
+// uses the https scheme.
+func (s *Server) ListenAndServeTLSOnRandomPort(certFile, keyFile string) (string, chan error, error) {
+	return s.serveOnRandomPort(true, certFile, keyFile)
+}
+
+func (s *Server) serveOnRandomPort(useTLS bool, certFile, keyFile string) (string, chan error, error) {
 	errCh := make(chan error)
 
 	listener, err := net.Listen("tcp", ":0") // ":0" picks a random available port
@@ -55,29 +180,39 @@ func (s *Server) ListenAndServeOnRandomPort() (string, chan error, error) {
 
 	// Get the actual bound address
 	addr := listener.Addr().String()
+	s.runSources()
 
 	go func() {
 		defer func() {
 			close(errCh)
 			if closerErr := listener.Close(); closerErr != nil {
-				s.logger.Error("failed closing listener", "err", errCh)
+				s.logger.Error("failed closing listener", "err", closerErr)
 			}
 		}()
-		if err = s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			errCh <- err
+		var serveErr error
+		if useTLS {
+			serveErr = s.httpServer.ServeTLS(listener, certFile, keyFile)
+		} else {
+			serveErr = s.httpServer.Serve(listener)
+		}
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			errCh <- serveErr
 			return
 		}
 		errCh <- nil
 	}()
 
-	return normalizeAddress(addr), errCh, nil
+	url := normalizeAddress(addr)
+	if useTLS {
+		url = "https" + strings.TrimPrefix(url, "http")
+	}
+
+	return url, errCh, nil
 }
 
+// Shutdown gracefully stops the server, use ShutdownWithReport for the details of how clean the shutdown was
 func (s *Server) Shutdown(ctx context.Context) error {
-	return errors.Join(
-		s.sseCtrl.Shutdown(),
-		s.httpServer.Shutdown(ctx),
-	)
+	return s.ShutdownWithReport(ctx).Err()
 }
 
 // Emit sends an event to all TCP connections listening on the sse endpoint
@@ -85,6 +220,55 @@ func (s *Server) Emit(e Event) {
 	s.sseCtrl.Emit(e)
 }
 
+// EmitContext sends an event to all subscribers carrying the ctx into its delivery, see HttpController.EmitContext
+func (s *Server) EmitContext(ctx context.Context, e Event) {
+	s.sseCtrl.EmitContext(ctx, e)
+}
+
+// EmitSync sends an event to all subscribers reporting how many received it, see HttpController.EmitSync
+func (s *Server) EmitSync(ctx context.Context, e Event) (delivered int, dropped int, err error) {
+	return s.sseCtrl.EmitSync(ctx, e)
+}
+
+// EmitJSON sends an event with the given name and the value marshalled to JSON as its data to all subscribers
+func (s *Server) EmitJSON(event string, v any) error {
+	return s.sseCtrl.EmitJSON(event, v)
+}
+
+// EmitEncoded sends an event with the given name and the value encoded by the codec as its data to all subscribers
+func (s *Server) EmitEncoded(codec Codec, event string, v any) error {
+	return s.sseCtrl.EmitEncoded(codec, event, v)
+}
+
+// QueueDepths returns the number of events waiting to be sent to every connection, see HttpController.QueueDepths
+func (s *Server) QueueDepths() map[string]int {
+	return s.sseCtrl.QueueDepths()
+}
+
+// EmitToSubscriber sends an event only to the connection with the given ConnInfo ID
+func (s *Server) EmitToSubscriber(id string, e Event) bool {
+	return s.sseCtrl.EmitToSubscriber(id, e)
+}
+
+// EmitToUser sends an event to all connections of the given user, see Options UserIDFunc
+func (s *Server) EmitToUser(userID string, e Event) int {
+	return s.sseCtrl.EmitToUser(userID, e)
+}
+
+// newTLSConfig returns the TLS config of the server, requiring verified client certificates when ClientCAs are set
+func newTLSConfig(options *Options) *tls.Config {
+	if options.TLSConfig == nil {
+		return nil
+	}
+	config := options.TLSConfig.Clone()
+	if options.ClientCAs != nil {
+		config.ClientCAs = options.ClientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config
+}
+
 // normalizeAddress converts a net.Listener address into a client-accessible URL
 func normalizeAddress(addr string) string {
 	// Check if the address is in the format [::]:port
@@ -0,0 +1,131 @@
+package ssevents
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EventNameShutdown is the name of the event created by NewShutdownEvent
+const EventNameShutdown = "server-shutdown"
+
+// NewShutdownEvent creates the event for Options ShutdownEvent, asking the clients to reconnect after the retry
+// interval, e.g. the time the server takes to restart. A zero retry keeps the interval of the clients.
+func NewShutdownEvent(retry time.Duration) *Event {
+	return &Event{Event: EventNameShutdown, Data: "shutdown", Retry: int(retry.Milliseconds())}
+}
+
+// ComponentShutdown is the outcome of shutting down a single component of the server
+type ComponentShutdown struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// ShutdownReport describes how the server shut down, telling operators whether a deploy was clean
+type ShutdownReport struct {
+	// Duration of the whole shutdown
+	Duration time.Duration
+	// Components are the controller, the sources and the HTTP server in the order they were shut down
+	Components []ComponentShutdown
+	// ConnectionsDrained is the number of SSE connections closed gracefully
+	ConnectionsDrained int
+	// ConnectionsForceClosed is the number of SSE connections still open when the shutdown context expired
+	ConnectionsForceClosed int
+	// EventsDiscarded is the number of events queued for subscribers that were never sent
+	EventsDiscarded int
+}
+
+// Err joins the errors of all components, nil when every component shut down without an error
+func (r *ShutdownReport) Err() error {
+	errs := make([]error, 0, len(r.Components))
+	for _, component := range r.Components {
+		errs = append(errs, component.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// Clean reports if the shutdown had no errors, closed no connections forcefully and discarded no events
+func (r *ShutdownReport) Clean() bool {
+	return r.Err() == nil && r.ConnectionsForceClosed == 0 && r.EventsDiscarded == 0
+}
+
+func (r *ShutdownReport) run(name string, shutdown func() error) {
+	started := time.Now()
+	err := shutdown()
+	r.Components = append(r.Components, ComponentShutdown{Name: name, Duration: time.Since(started), Err: err})
+}
+
+// ShutdownWithReport is Shutdown reporting the outcome of every component, connections still open when ctx expires are
+// closed forcefully.
+func (s *Server) ShutdownWithReport(ctx context.Context) *ShutdownReport {
+	started := time.Now()
+	report := &ShutdownReport{}
+	ctrls := s.controllers()
+	var connections, queued int
+	for _, ctrl := range ctrls {
+		connections += ctrl.activeConnections()
+		queued += ctrl.queuedEvents()
+	}
+
+	report.run("controller", func() error {
+		errs := make([]error, 0, len(ctrls))
+		for _, ctrl := range ctrls {
+			errs = append(errs, ctrl.Shutdown())
+		}
+		return errors.Join(errs...)
+	})
+	report.run("sources", func() error {
+		return waitContext(ctx, s.sourcesWg.Wait)
+	})
+	report.run("http", func() error {
+		err := s.httpServer.Shutdown(ctx)
+		if err != nil {
+			for _, ctrl := range ctrls {
+				report.ConnectionsForceClosed += ctrl.activeConnections()
+			}
+			err = errors.Join(err, s.httpServer.Close())
+		}
+		return err
+	})
+
+	report.ConnectionsDrained = max(0, connections-report.ConnectionsForceClosed)
+	report.EventsDiscarded = queued
+	for _, ctrl := range ctrls {
+		report.EventsDiscarded += ctrl.discardedEvents()
+	}
+	report.Duration = time.Since(started)
+	s.logger.Info("sse server shut down",
+		"duration", report.Duration,
+		"connections_drained", report.ConnectionsDrained,
+		"connections_force_closed", report.ConnectionsForceClosed,
+		"events_discarded", report.EventsDiscarded,
+		"clean", report.Clean(),
+	)
+
+	return report
+}
+
+// waitContext runs wait returning the ctx error if it expires first
+func waitContext(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ClientShutdownReport describes how the client shut down
+type ClientShutdownReport struct {
+	Duration time.Duration
+	// ObserversClosed is the number of observers that had not completed yet
+	ObserversClosed int
+	// EventsDiscarded is the number of events queued for observers that were never read
+	EventsDiscarded int
+}
@@ -1,13 +1,17 @@
 package ssevents
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
 // WatchSigTerm - sends an error on termination signal, eg ctrl+c, on second signal panics
+//
+// Deprecated: use NotifyContext, it composes with servers and tests and does not leak a goroutine.
 func WatchSigTerm() <-chan error {
 	err := make(chan error)
 	c := make(chan os.Signal, 1)
@@ -20,3 +24,53 @@ func WatchSigTerm() <-chan error {
 
 	return err
 }
+
+// NotifyContext returns a copy of parent that is cancelled on the first of the signals, SIGINT and SIGTERM when none
+// are given, context.Cause of the returned context reports the received signal. A second signal while shutting down
+// force exits the process with status 1, see NotifyContextWithForce.
+// Calling stop releases the resources and stops watching the signals, it should be called as soon as possible.
+func NotifyContext(parent context.Context, signals ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	return NotifyContextWithForce(parent, func(os.Signal) {
+		os.Exit(1)
+	}, signals...)
+}
+
+// NotifyContextWithForce is NotifyContext calling force on a second signal instead of exiting, e.g. for logging or
+// cancelling the graceful shutdown. A nil force ignores the second signal.
+func NotifyContextWithForce(
+	parent context.Context, force func(sig os.Signal), signals ...os.Signal,
+) (ctx context.Context, stop context.CancelFunc) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, signals...)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer signal.Stop(sigCh)
+		select {
+		case sig := <-sigCh:
+			cancel(fmt.Errorf("received signal %s", sig))
+		case <-ctx.Done():
+			return
+		case <-stopped:
+			return
+		}
+
+		select {
+		case sig := <-sigCh:
+			if force != nil {
+				force(sig)
+			}
+		case <-stopped:
+		}
+	}()
+
+	closeStopped := sync.OnceFunc(func() { close(stopped) })
+	return ctx, func() {
+		closeStopped()
+		cancel(context.Canceled)
+	}
+}
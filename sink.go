@@ -0,0 +1,58 @@
+package ssevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Sink receives every event consumed by the client, turning it into an ingestion worker that forwards third-party
+// SSE streams into internal event infrastructure like Kafka, NATS or Redis.
+type Sink interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// SinkFunc is an adapter allowing the use of ordinary functions as a Sink.
+type SinkFunc func(ctx context.Context, e Event) error
+
+// Publish calls f(ctx, e).
+func (f SinkFunc) Publish(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// NewTopicSink creates a Sink for brokers publishing raw messages to a topic, subject or channel, the event is JSON
+// encoded and published to the topic resolved for it, e.g. with NATS:
+//
+//	ssevents.NewTopicSink(func(_ context.Context, subject string, payload []byte) error {
+//		return nc.Publish(subject, payload)
+//	}, func(e ssevents.Event) string {
+//		return "sse." + e.Event
+//	})
+func NewTopicSink(
+	publish func(ctx context.Context, topic string, payload []byte) error, topic func(e Event) string,
+) Sink {
+	return SinkFunc(func(ctx context.Context, e Event) error {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed encoding event for sink: %w", err)
+		}
+		return publish(ctx, topic(e), payload)
+	})
+}
+
+// publishToSinks forwards the event to all sinks, failures are reported on the errors channel. Heartbeats are
+// specific to the connection and are not forwarded.
+func (c *Client) publishToSinks(evt Event) {
+	if !FilterNoHeartbeat(evt) {
+		return
+	}
+	for _, sink := range c.sinks {
+		if err := sink.Publish(c.shutdownCtx, evt); err != nil {
+			select {
+			case c.errorCh <- fmt.Errorf("failed publishing event to sink: %w", err):
+			default:
+				c.logger.Error("dropping error, channel full", "err", err)
+			}
+		}
+	}
+}
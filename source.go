@@ -0,0 +1,63 @@
+package ssevents
+
+import (
+	"context"
+	"errors"
+)
+
+// Source is an external producer of events, like a database table, a message broker or a webhook, that runs for the
+// whole lifetime of the Server and emits everything it produces to the connected subscribers.
+//
+// Run should block until the ctx is cancelled or the source fails, returning nil or the context error when stopped.
+type Source interface {
+	Run(ctx context.Context, emit func(e Event)) error
+}
+
+// Emitter sends events to subscribers, it is implemented by both Server and HttpController and is what push-based
+// integrations, like webhooks, use for emitting.
+type Emitter interface {
+	Emit(e Event)
+}
+
+// EmitterFunc is an adapter allowing the use of ordinary functions as an Emitter, e.g. for handing a webhook handler
+// to the Options Handlers before the Server it emits to is created:
+//
+//	var server *ssevents.Server
+//	emitter := ssevents.EmitterFunc(func(e ssevents.Event) { server.Emit(e) })
+//	server, err := ssevents.NewServer(&ssevents.Options{Handlers: map[string]http.HandlerFunc{
+//		"POST /alertmanager": alertmanager.Handler(emitter),
+//	}})
+type EmitterFunc func(e Event)
+
+// Emit calls f(e).
+func (f EmitterFunc) Emit(e Event) {
+	f(e)
+}
+
+// SourceFunc is an adapter allowing the use of ordinary functions as a Source.
+type SourceFunc func(ctx context.Context, emit func(e Event)) error
+
+// Run calls f(ctx, emit).
+func (f SourceFunc) Run(ctx context.Context, emit func(e Event)) error {
+	return f(ctx, emit)
+}
+
+var (
+	_ Emitter = (*Server)(nil)
+	_ Emitter = (*HttpController)(nil)
+	_ Emitter = EmitterFunc(nil)
+)
+
+// runSources starts every configured source in its own goroutine, they are stopped when the controller shuts down.
+func (s *Server) runSources() {
+	for _, source := range s.sources {
+		s.sourcesWg.Add(1)
+		go func() {
+			defer s.sourcesWg.Done()
+			err := source.Run(s.sseCtrl.shutdownCtx, s.Emit)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				s.logger.Error("source stopped with an error", "err", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,177 @@
+// Package sqlstore provides a ssevents.EventStore persisting events in a SQL table, so connections resuming with the
+// Last-Event-ID header get the missed events replayed across server restarts. Any database/sql driver works, e.g.
+// SQLite or PostgreSQL, with the table created upfront:
+//
+//	-- SQLite
+//	CREATE TABLE sse_events (seq INTEGER PRIMARY KEY AUTOINCREMENT, id TEXT NOT NULL, event TEXT NOT NULL,
+//		data TEXT NOT NULL, created_at INTEGER NOT NULL);
+//	-- PostgreSQL
+//	CREATE TABLE sse_events (seq BIGSERIAL PRIMARY KEY, id TEXT NOT NULL, event TEXT NOT NULL,
+//		data TEXT NOT NULL, created_at BIGINT NOT NULL);
+//	CREATE INDEX sse_events_id ON sse_events (id);
+//
+// Every server instance needs its own table, as instances connected with a Bridge each store the events they emit.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+const (
+	tableDefault         = "sse_events"
+	pruneIntervalDefault = time.Minute
+)
+
+var ErrMissingDB = errors.New("sqlstore: DB is required")
+
+type Options struct {
+	// DB is the database holding the table
+	DB *sql.DB
+	// Table holding the events, default is "sse_events"
+	Table string
+	// Placeholder returns the n-th (1 based) query parameter placeholder, default is $n which SQLite and PostgreSQL
+	// accept, use func(int) string { return "?" } for MySQL
+	Placeholder func(n int) string
+	// MaxCount is the number of latest events kept, default is 0 which keeps all
+	MaxCount int
+	// MaxAge defines how long events are kept, default is 0 which keeps them forever
+	MaxAge time.Duration
+	// PruneInterval defines how often events exceeding MaxCount or MaxAge are deleted, default is 1m
+	PruneInterval time.Duration
+}
+
+// Store is a ssevents.EventStore backed by a SQL table.
+type Store struct {
+	db            *sql.DB
+	maxCount      int
+	maxAge        time.Duration
+	pruneInterval time.Duration
+
+	insertQuery     string
+	seqQuery        string
+	afterQuery      string
+	pruneAgeQuery   string
+	pruneCountQuery string
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+var _ ssevents.EventStore = (*Store)(nil)
+
+func New(options Options) (*Store, error) {
+	if options.DB == nil {
+		return nil, ErrMissingDB
+	}
+	table := tableDefault
+	if options.Table != "" {
+		table = options.Table
+	}
+	p := func(n int) string { return "$" + strconv.Itoa(n) }
+	if options.Placeholder != nil {
+		p = options.Placeholder
+	}
+
+	s := &Store{
+		db:            options.DB,
+		maxCount:      options.MaxCount,
+		maxAge:        options.MaxAge,
+		pruneInterval: pruneIntervalDefault,
+		insertQuery: fmt.Sprintf(
+			"INSERT INTO %s (id, event, data, created_at) VALUES (%s, %s, %s, %s)", table, p(1), p(2), p(3), p(4),
+		),
+		seqQuery: fmt.Sprintf(
+			"SELECT seq FROM %s WHERE id = %s AND created_at >= %s ORDER BY seq DESC LIMIT 1", table, p(1), p(2),
+		),
+		afterQuery:    fmt.Sprintf("SELECT id, event, data FROM %s WHERE seq > %s ORDER BY seq", table, p(1)),
+		pruneAgeQuery: fmt.Sprintf("DELETE FROM %s WHERE created_at < %s", table, p(1)),
+		pruneCountQuery: fmt.Sprintf(
+			"DELETE FROM %s WHERE seq <= (SELECT MAX(seq) FROM %s) - %s", table, table, p(1),
+		),
+	}
+	if options.PruneInterval > 0 {
+		s.pruneInterval = options.PruneInterval
+	}
+
+	return s, nil
+}
+
+// Append inserts the event, deleting the ones exceeding the retention at most once per PruneInterval
+func (s *Store) Append(ctx context.Context, e ssevents.Event) error {
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx, s.insertQuery, e.Id, e.Event, e.Data, now.UnixNano()); err != nil {
+		return fmt.Errorf("sqlstore: failed inserting event %s: %w", e.Id, err)
+	}
+	if s.maxAge == 0 && s.maxCount == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastPrune) < s.pruneInterval {
+		return nil
+	}
+	s.lastPrune = now
+
+	return s.Prune(ctx)
+}
+
+// Prune deletes the events exceeding MaxCount or MaxAge
+func (s *Store) Prune(ctx context.Context) error {
+	if s.maxAge > 0 {
+		if _, err := s.db.ExecContext(ctx, s.pruneAgeQuery, time.Now().Add(-s.maxAge).UnixNano()); err != nil {
+			return fmt.Errorf("sqlstore: failed deleting expired events: %w", err)
+		}
+	}
+	if s.maxCount > 0 {
+		if _, err := s.db.ExecContext(ctx, s.pruneCountQuery, s.maxCount); err != nil {
+			return fmt.Errorf("sqlstore: failed deleting events over the max count: %w", err)
+		}
+	}
+	return nil
+}
+
+// After returns the events following the latest one with the ID, events older than MaxAge which are not pruned yet
+// are treated as missing.
+func (s *Store) After(ctx context.Context, id string) (events []ssevents.Event, found bool, err error) {
+	var cutoff int64
+	if s.maxAge > 0 {
+		cutoff = time.Now().Add(-s.maxAge).UnixNano()
+	}
+	var seq int64
+	err = s.db.QueryRowContext(ctx, s.seqQuery, id, cutoff).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("sqlstore: failed looking up event %s: %w", id, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.afterQuery, seq)
+	if err != nil {
+		return nil, false, fmt.Errorf("sqlstore: failed querying events after %s: %w", id, err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+	for rows.Next() {
+		var e ssevents.Event
+		if err = rows.Scan(&e.Id, &e.Event, &e.Data); err != nil {
+			return nil, false, fmt.Errorf("sqlstore: failed scanning event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("sqlstore: failed reading events after %s: %w", id, err)
+	}
+
+	return events, true, nil
+}
@@ -0,0 +1,84 @@
+package ssetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// Matcher reports whether the event is the expected one
+type Matcher func(e ssevents.Event) bool
+
+// ByName matches the events of the type, e.g. "order-created"
+func ByName(name string) Matcher {
+	return func(e ssevents.Event) bool {
+		return e.Event == name
+	}
+}
+
+// DataContains matches the events with the substring in their data
+func DataContains(substr string) Matcher {
+	return func(e ssevents.Event) bool {
+		return strings.Contains(e.Data, substr)
+	}
+}
+
+// JSONField matches the events with JSON data holding the value at the dot separated path, e.g. "order.items.0.id",
+// the values are compared by their JSON encoding so JSONField("count", 2) matches {"count":2.0}
+func JSONField(path string, value any) Matcher {
+	want, err := json.Marshal(value)
+	return func(e ssevents.Event) bool {
+		if err != nil {
+			return false
+		}
+		var data any
+		if json.Unmarshal([]byte(e.Data), &data) != nil {
+			return false
+		}
+		got, ok := lookup(data, strings.Split(path, "."))
+		if !ok {
+			return false
+		}
+		encoded, encodeErr := json.Marshal(got)
+		return encodeErr == nil && bytes.Equal(encoded, want)
+	}
+}
+
+// lookup walks the decoded JSON by the object keys and the array indexes of the path
+func lookup(data any, path []string) (any, bool) {
+	for _, key := range path {
+		switch node := data.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			data = value
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			data = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+// All matches the events matched by every one of the matchers
+func All(matchers ...Matcher) Matcher {
+	return func(e ssevents.Event) bool {
+		for _, match := range matchers {
+			if !match(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,68 @@
+// Package ssetest provides assertions on the events received by ssevents observers, for the tests of services
+// streaming them:
+//
+//	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+//		Transport: ssevents.NewHandlerTransport(server.Handler()),
+//	})
+//	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+//	client.Start()
+//
+//	server.Emit(ssevents.Event{Event: "order-created", Data: `{"order":{"id":"1"}}`})
+//	ssetest.ExpectEvent(t, observer, ssetest.JSONField("order.id", "1"), time.Second)
+package ssetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// CollectTimeout bounds the wait of Collect
+var CollectTimeout = 5 * time.Second
+
+// ExpectEvent waits for the next event of the observer that matches, failing the test once the timeout passes or the
+// observer completes first. The events not matching are discarded and listed in the failure.
+func ExpectEvent(t testing.TB, obs *ssevents.Observer, match Matcher, timeout time.Duration) ssevents.Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var skipped []ssevents.Event
+	evt, err := obs.WaitForMatch(ctx, func(e ssevents.Event) bool {
+		if match(e) {
+			return true
+		}
+		skipped = append(skipped, e)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("expected a matching event: %s, received instead %v", failure(err, timeout), skipped)
+	}
+
+	return evt
+}
+
+// Collect waits for the next n events of the observer, failing the test once CollectTimeout passes or the observer
+// completes first
+func Collect(t testing.TB, obs *ssevents.Observer, n int) []ssevents.Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), CollectTimeout)
+	defer cancel()
+
+	events, err := obs.WaitForN(ctx, n)
+	if err != nil {
+		t.Fatalf("expected %d events: %s, received %d: %v", n, failure(err, CollectTimeout), len(events), events)
+	}
+
+	return events
+}
+
+func failure(err error, timeout time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out after " + timeout.String()
+	}
+	return err.Error()
+}
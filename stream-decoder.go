@@ -0,0 +1,89 @@
+package ssevents
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	mediaTypeEventStream = "text/event-stream"
+	// MediaTypeJSONLines is the media type of newline delimited JSON streams, see NewJSONLinesDecoder
+	MediaTypeJSONLines = "application/x-ndjson"
+)
+
+// StreamDecoder reads the events of a stream, returning io.EOF once it ends. The Decoder of SSE streams implements
+// it, others are set through ClientOptions Decoders for the streams of other formats.
+type StreamDecoder interface {
+	Decode() (Event, error)
+}
+
+var (
+	_ StreamDecoder = (*Decoder)(nil)
+	_ StreamDecoder = (*JSONLinesDecoder)(nil)
+)
+
+// JSONLinesDecoder reads the events of a newline delimited JSON stream, every line is an event, empty lines are
+// skipped
+type JSONLinesDecoder struct {
+	// Map turns the line into an event, e.g. taking its name and ID from the JSON fields, default is an event with
+	// the line as its data
+	Map    func(line []byte) (Event, error)
+	reader *bufio.Reader
+	line   []byte
+}
+
+// NewJSONLinesDecoder creates a JSONLinesDecoder reading from r, for the client use it as:
+//
+//	client, err := ssevents.NewSSEClient(url, &ssevents.ClientOptions{
+//		Decoders: map[string]func(r io.Reader) ssevents.StreamDecoder{
+//			ssevents.MediaTypeJSONLines: func(r io.Reader) ssevents.StreamDecoder {
+//				return ssevents.NewJSONLinesDecoder(r)
+//			},
+//		},
+//	})
+func NewJSONLinesDecoder(r io.Reader) *JSONLinesDecoder {
+	return &JSONLinesDecoder{reader: bufio.NewReader(r)}
+}
+
+// Decode returns the event of the next non-empty line, a final line without a line ending is decoded before io.EOF
+func (d *JSONLinesDecoder) Decode() (Event, error) {
+	for {
+		line, err := d.reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			d.line = append(d.line[:0], line...)
+			for errors.Is(err, bufio.ErrBufferFull) {
+				line, err = d.reader.ReadSlice('\n')
+				d.line = append(d.line, line...)
+			}
+			line = d.line
+		}
+		if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+			return Event{}, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if d.Map != nil {
+			return d.Map(line)
+		}
+		return Event{Data: string(line)}, nil
+	}
+}
+
+// countingReader reports the bytes read through it, for the decoders not reporting to the metrics themselves
+type countingReader struct {
+	reader  io.Reader
+	metrics ClientMetrics
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.metrics.BytesRead(n)
+	}
+	return n, err
+}
@@ -0,0 +1,208 @@
+package ssevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// subscriptionsPath is the control endpoint changing the topics of a connection, below the SSE endpoint, e.g.
+// /sse/subscriptions
+const subscriptionsPath = "/subscriptions"
+
+// ErrSubscriptionsUnsupported is returned by Client AddTopic and RemoveTopic when the server did not announce the
+// subscriptions endpoint in its hello, the topics apply once the client reconnects
+var ErrSubscriptionsUnsupported = errors.New("sse server does not support changing subscriptions")
+
+// SubscriptionChange is the body of POST /sse/subscriptions, adding and removing topics of the connection with the
+// subscriber ID
+type SubscriptionChange struct {
+	SubscriberID string   `json:"subscriberId"`
+	Add          []string `json:"add,omitempty"`
+	Remove       []string `json:"remove,omitempty"`
+}
+
+// Subscriptions is the response of POST /sse/subscriptions, the topics of the connection after the change
+type Subscriptions struct {
+	Topics []string `json:"topics"`
+}
+
+// apply returns the topics with the added ones appended and the removed ones removed, keeping them unique
+func (s SubscriptionChange) apply(topics []string) []string {
+	topics = slices.Clone(topics)
+	for _, topic := range s.Add {
+		if topic = strings.TrimSpace(topic); topic != "" && !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	return slices.DeleteFunc(topics, func(topic string) bool {
+		return slices.Contains(s.Remove, topic)
+	})
+}
+
+// newSubscriptionsHandler changes the topics of the connections with the subscriber ID, the callers are verified with
+// Options Authenticate like the SSE connections and can change only the connections of their own user
+func newSubscriptionsHandler(sseCtrl *HttpController) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		claims, ok := sseCtrl.authenticate(w, req, sseCtrl.options.Authenticate)
+		if !ok {
+			return
+		}
+
+		var change SubscriptionChange
+		if !decodeBody(w, req, &change) {
+			return
+		}
+		if change.SubscriberID == "" {
+			respondError(w, errors.New("missing subscriberId"))
+			return
+		}
+
+		subs, status := sseCtrl.ownedSubscribers(req, claims, change.SubscriberID)
+		if status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		var topics []string
+		for _, sub := range subs {
+			topics = sub.updateTopics(change)
+		}
+
+		sseCtrl.log.Debug("sse subscriptions changed", "conn_id", change.SubscriberID, "topics", topics)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Subscriptions{Topics: topics})
+	}
+}
+
+// updateTopics applies the change to the topics of the subscriber, concurrent changes are applied one after another
+func (s *subscriber) updateTopics(change SubscriptionChange) []string {
+	for {
+		current := s.topics.Load()
+		topics := s.info.Topics
+		if current != nil {
+			topics = *current
+		}
+		updated := change.apply(topics)
+		if s.topics.CompareAndSwap(current, &updated) {
+			return updated
+		}
+	}
+}
+
+// subscriptionsURL is the subscriptions endpoint below the SSE endpoint of the url, e.g. /api/sse/subscriptions for
+// /api/sse
+func subscriptionsURL(sseURL string) (string, error) {
+	u, err := url.Parse(sseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing sse url: %w", err)
+	}
+	u.Path = path.Join(u.Path, subscriptionsPath)
+	u.RawPath = ""
+	u.RawQuery = ""
+
+	return u.String(), nil
+}
+
+// topicsFromURL returns the topics of the topic query parameter of the SSE url
+func topicsFromURL(sseURL string) ([]string, error) {
+	u, err := url.Parse(sseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing sse url: %w", err)
+	}
+
+	return topicsFromQuery(u.Query()), nil
+}
+
+// AddTopic subscribes the connection to the topics without reconnecting, when the server announced the subscriptions
+// endpoint in its hello, see Options DynamicTopics. The topics are kept for the next connections in the topic query
+// parameter, so they apply after a reconnect even when the change fails.
+func (c *Client) AddTopic(ctx context.Context, topics ...string) error {
+	return c.changeTopics(ctx, SubscriptionChange{Add: topics})
+}
+
+// RemoveTopic unsubscribes the connection from the topics, see AddTopic
+func (c *Client) RemoveTopic(ctx context.Context, topics ...string) error {
+	return c.changeTopics(ctx, SubscriptionChange{Remove: topics})
+}
+
+// Topics returns the topics the client subscribes to, from the topic query parameter of the url and AddTopic
+func (c *Client) Topics() []string {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	return slices.Clone(c.topics)
+}
+
+func (c *Client) changeTopics(ctx context.Context, change SubscriptionChange) error {
+	c.infoMu.Lock()
+	c.topics = change.apply(c.topics)
+	c.topicsChanged = true
+	info := c.serverInfo
+	c.infoMu.Unlock()
+
+	// Without a connection the topics apply once connected
+	if info == nil {
+		return nil
+	}
+	if !info.Subscriptions {
+		return ErrSubscriptionsUnsupported
+	}
+	change.SubscriberID = info.SubscriberID
+
+	return c.sendSubscriptionChange(ctx, change)
+}
+
+func (c *Client) sendSubscriptionChange(ctx context.Context, change SubscriptionChange) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.subscriptionsURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed creating subscriptions request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err = c.prepareRequest(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected subscriptions response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// requestURL is the url with the topic query parameter replaced by the topics once they were changed
+func (c *Client) requestURL() (string, error) {
+	c.infoMu.Lock()
+	topics, changed := slices.Clone(c.topics), c.topicsChanged
+	c.infoMu.Unlock()
+	if !changed {
+		return c.url, nil
+	}
+
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing sse url: %w", err)
+	}
+	query := u.Query()
+	query.Del(topicQueryParam)
+	if len(topics) > 0 {
+		query.Set(topicQueryParam, strings.Join(topics, ","))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenAutoAck_whenEventsReceived_thenServerNotifiedOfAcks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	acks := make(chan ssevents.Ack, 10)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:      errorLogger(),
+		SendHello:   true,
+		AutoEventID: ssevents.SequentialEventIDs(),
+		OnAck:       func(ack ssevents.Ack) { acks <- ack },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+		AutoAck:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	events := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	info, ok := client.ServerInfo()
+	for ; !ok && ctx.Err() == nil; info, ok = client.ServerInfo() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !info.Ack {
+		t.Fatalf("expected the hello to announce acks, got %+v", info)
+	}
+
+	var emitted []string
+	for i := 0; i < 3; i++ {
+		delivered, _, emitErr := server.EmitSync(ctx, ssevents.Event{Data: "payload"})
+		if emitErr != nil || delivered != 1 {
+			t.Fatalf("expected the event delivered, got %d: %v", delivered, emitErr)
+		}
+		evt := <-events.EventCh
+		emitted = append(emitted, evt.Id)
+	}
+
+	var acked []string
+	for len(acked) < len(emitted) {
+		select {
+		case ack := <-acks:
+			if ack.SubscriberID != info.SubscriberID {
+				t.Fatalf("expected acks of subscriber %s, got %+v", info.SubscriberID, ack)
+			}
+			acked = append(acked, ack.EventIDs...)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for acks, got %v of %v", acked, emitted)
+		}
+	}
+	if !slices.Equal(acked, emitted) {
+		t.Fatalf("expected acks %v, got %v", emitted, acked)
+	}
+}
+
+func Test_givenNestedSseUrl_whenAcking_thenOnlyOwnSubscriberAcknowledged(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	acks := make(chan ssevents.Ack, 10)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:      errorLogger(),
+		SseUrl:      "/api/sse",
+		SendHello:   true,
+		AutoEventID: ssevents.SequentialEventIDs(),
+		OnAck:       func(ack ssevents.Ack) { acks <- ack },
+		Authenticate: func(req *http.Request) (map[string]any, error) {
+			return map[string]any{"sub": req.Header.Get("X-User")}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	client, err := ssevents.NewSSEClient("http://ssevents.test/api/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+		Headers:   http.Header{"X-User": {"alice"}},
+		AutoAck:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	client.SubscribeFunc(nil, func(ssevents.Event) {})
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := client.ServerInfo()
+	for ; !ok && ctx.Err() == nil; info, ok = client.ServerInfo() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	server.Emit(ssevents.Event{Data: "payload"})
+	select {
+	case ack := <-acks:
+		if ack.SubscriberID != info.SubscriberID {
+			t.Fatalf("expected the ack of subscriber %s, got %+v", info.SubscriberID, ack)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the ack on the nested path")
+	}
+
+	postAck := func(user, subscriberID string) int {
+		body := `{"subscriberId":"` + subscriberID + `","eventIds":["1"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/ack", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if status := postAck("bob", info.SubscriberID); status != http.StatusForbidden {
+		t.Fatalf("expected 403 acking the subscriber of another user, got %d", status)
+	}
+	if status := postAck("alice", "unknown"); status != http.StatusNotFound {
+		t.Fatalf("expected 404 acking an unknown subscriber, got %d", status)
+	}
+	if status := postAck("alice", info.SubscriberID); status != http.StatusNoContent {
+		t.Fatalf("expected 204 acking the own subscriber, got %d", status)
+	}
+}
+
+func Test_givenOversizedAck_whenPosted_thenRejected(t *testing.T) {
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(),
+		OnAck:  func(ssevents.Ack) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventIDs := make([]string, 101)
+	for i := range eventIDs {
+		eventIDs[i] = `"` + strconv.Itoa(i) + `"`
+	}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{
+			name:   "too many event IDs",
+			body:   `{"subscriberId":"s-1","eventIds":[` + strings.Join(eventIDs, ",") + `]}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "body too large",
+			body:   `{"subscriberId":"` + strings.Repeat("s", 1<<20) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/ack", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
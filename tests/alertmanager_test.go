@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/alertmanager"
+)
+
+const alertmanagerNotification = `{"version":"4","status":"firing","receiver":"sse","alerts":[
+{"status":"firing","labels":{"alertname":"HighLatency"},"fingerprint":"abc","startsAt":"2026-01-01T00:00:00Z"},
+{"status":"resolved","labels":{"alertname":"DiskFull"},"fingerprint":"def","startsAt":"2026-01-01T00:00:00Z"}]}`
+
+func Test_givenAlertmanagerNotification_whenReceived_thenEmitFiringAndResolvedAlerts(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/alertmanager", strings.NewReader(alertmanagerNotification))
+	res := httptest.NewRecorder()
+	emitter := &collectingEmitter{}
+
+	alertmanager.Handler(emitter)(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", res.Code, res.Body.String())
+	}
+	if len(emitter.events) != 2 {
+		t.Fatalf("expected 2 events got %v", emitter.events)
+	}
+	firing, resolved := emitter.events[0], emitter.events[1]
+	if firing.Event != alertmanager.EventFiring || firing.Id != "abc-firing" {
+		t.Errorf("expected firing event abc-firing got %s %s", firing.Event, firing.Id)
+	}
+	if resolved.Event != alertmanager.EventResolved || resolved.Id != "def-resolved" {
+		t.Errorf("expected resolved event def-resolved got %s %s", resolved.Event, resolved.Id)
+	}
+	var alert alertmanager.Alert
+	if err := json.Unmarshal([]byte(firing.Data), &alert); err != nil {
+		t.Fatal(err)
+	}
+	if alert.Labels["alertname"] != "HighLatency" {
+		t.Errorf("expected the alert as data got %s", firing.Data)
+	}
+}
+
+func Test_givenInvalidAlertmanagerNotification_whenReceived_thenBadRequest(t *testing.T) {
+	for _, body := range []string{"not json", `{"version":"4","alerts":[]}`} {
+		req := httptest.NewRequest(http.MethodPost, "/alertmanager", strings.NewReader(body))
+		res := httptest.NewRecorder()
+		emitter := &collectingEmitter{}
+
+		alertmanager.Handler(emitter)(res, req)
+
+		if res.Code != http.StatusBadRequest || len(emitter.events) != 0 {
+			t.Errorf("expected 400 without events for %q got %d and %v", body, res.Code, emitter.events)
+		}
+	}
+}
+
+func Test_givenAlertmanagerHandlerMountedOnServer_whenNotified_thenClientReceivesAlerts(t *testing.T) {
+	var server *ssevents.Server
+	emitter := ssevents.EmitterFunc(func(e ssevents.Event) { server.Emit(e) })
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(),
+		Handlers: map[string]http.HandlerFunc{
+			"POST /alertmanager": alertmanager.Handler(emitter),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On(alertmanager.EventResolved).First().Build())
+	client.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for server.SubscriberCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the client to connect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	res, err := http.Post(url+"/alertmanager", "application/json", strings.NewReader(alertmanagerNotification))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected the mounted handler to accept the notification, got %d", res.StatusCode)
+	}
+	events, err := observer.WaitForAllOrTimeout(2 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Id != "def-resolved" {
+		t.Fatalf("expected the resolved alert, got %v", events)
+	}
+}
+
+func Test_givenOversizedAlertmanagerNotification_whenReceived_thenRejectTooLarge(t *testing.T) {
+	body := `{"alerts":[{"status":"firing","labels":{"big":"` + strings.Repeat("x", alertmanager.MaxBodySize) + `"}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/alertmanager", strings.NewReader(body))
+	res := httptest.NewRecorder()
+	emitter := &collectingEmitter{}
+
+	alertmanager.Handler(emitter)(res, req)
+
+	if res.Code != http.StatusRequestEntityTooLarge || len(emitter.events) != 0 {
+		t.Fatalf("expected 413 without events got %d and %d events", res.Code, len(emitter.events))
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenAuditor_whenEmitting_thenRecordActorAndDeliveries(t *testing.T) {
+	var mu sync.Mutex
+	var records []ssevents.AuditRecord
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(),
+		Auditor: ssevents.AuditorFunc(func(_ context.Context, record ssevents.AuditRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			records = append(records, record)
+		}),
+		EmitActor: func(req *http.Request) string {
+			return req.Header.Get("X-Producer")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	server.EmitContext(ssevents.WithActor(context.Background(), "billing"), ssevents.Event{Id: "1", Data: "paid"})
+	server.EmitToUser("alice", ssevents.Event{Id: "2", Event: "notice", Data: "hello"})
+
+	req, err := http.NewRequest(http.MethodPost, url+"/emit", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Producer", "deploy-bot")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 3 {
+		t.Fatalf("expected 3 audit records got %d", len(records))
+	}
+	if records[0].Actor != "billing" || records[0].EventID != "1" || records[0].Target != "" {
+		t.Errorf("unexpected record of EmitContext %+v", records[0])
+	}
+	if records[1].Target != "user:alice" || records[1].EventName != "notice" || records[1].Delivered != 0 {
+		t.Errorf("unexpected record of EmitToUser %+v", records[1])
+	}
+	if records[2].Actor != "deploy-bot" || records[2].Time.IsZero() {
+		t.Errorf("unexpected record of the emit endpoint %+v", records[2])
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenBackoff_whenDelay_thenGrowExponentiallyUpToMaxDelay(t *testing.T) {
+	backoff := ssevents.Backoff{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second,
+	}
+	for attempt, want := range expected {
+		if delay := backoff.Delay(attempt); delay != want {
+			t.Fatalf("expected delay %s for attempt %d, got %s", want, attempt, delay)
+		}
+	}
+}
+
+func Test_givenBackoffWithJitter_whenDelay_thenStayWithinJitterRange(t *testing.T) {
+	backoff := ssevents.Backoff{InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		if delay := backoff.Delay(1); delay < 1600*time.Millisecond || delay > 2400*time.Millisecond {
+			t.Fatalf("expected delay within 2s ±20%%, got %s", delay)
+		}
+	}
+}
+
+func Test_givenBackoffWithoutJitter_whenDelay_thenExact(t *testing.T) {
+	backoff := ssevents.Backoff{
+		InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2, Jitter: ssevents.BackoffNoJitter,
+	}
+
+	for i := 0; i < 100; i++ {
+		if delay := backoff.Delay(1); delay != 2*time.Second {
+			t.Fatalf("expected delay of exactly 2s, got %s", delay)
+		}
+	}
+}
+
+func Test_givenKeepRetrying_whenRetriesExhausted_thenErrorReportedAndClientReconnects(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	// The first attempts fail, exhausting the retries, before the server becomes available
+	var attempts atomic.Int32
+	unavailable := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if attempts.Add(1) <= 5 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.Handler().ServeHTTP(w, req)
+	})
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(unavailable),
+		Backoff: &ssevents.Backoff{
+			InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, MaxRetries: 2, KeepRetrying: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	go func(errs <-chan error) {
+		for range errs {
+		}
+	}(client.Errors())
+
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatalf("expected the client to keep retrying until connected, got %v", err)
+	}
+	if n := attempts.Load(); n != 6 {
+		t.Fatalf("expected the client connected on the 6th attempt, got %d attempts", n)
+	}
+	if state := client.State(); state != ssevents.ConnStateConnected {
+		t.Fatalf("expected the client connected, got %s", state)
+	}
+}
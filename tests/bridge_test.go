@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/natsbridge"
+	"github.com/doppelganger113/ssevents/redisbridge"
+)
+
+func Test_givenServersSharingRedisBridge_whenEmit_thenDeliveredByEveryInstance(t *testing.T) {
+	bridge, err := redisbridge.New(redisbridge.Options{
+		Addr: startFakeRedis(t), Logger: errorLogger(), RetryDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBridgedEmit(t, bridge)
+}
+
+func Test_givenServersSharingNATSBridge_whenEmit_thenDeliveredByEveryInstance(t *testing.T) {
+	bridge, err := natsbridge.New(natsbridge.Options{
+		Addr: startFakeNATS(t), Logger: errorLogger(), RetryDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject := bridge.Subject(ssevents.Event{Event: "order created"}); subject != "ssevents.order_created" {
+		t.Fatalf("unexpected subject %s", subject)
+	}
+	testBridgedEmit(t, bridge)
+}
+
+// testBridgedEmit connects a client to each of two servers sharing the bridge and expects both to receive the events
+// emitted on one of them
+func testBridgedEmit(t *testing.T, bridge ssevents.EventBridge) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	subscribe := func() (*ssevents.Server, *ssevents.Observer) {
+		server, serverErr := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), Bridge: bridge})
+		if serverErr != nil {
+			t.Fatal(serverErr)
+		}
+		url, _, serverErr := server.ListenAndServeOnRandomPort()
+		if serverErr != nil {
+			t.Fatal(serverErr)
+		}
+		t.Cleanup(func() { _ = server.Shutdown(context.Background()) })
+
+		client, clientErr := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{Logger: errorLogger()})
+		if clientErr != nil {
+			t.Fatal(clientErr)
+		}
+		t.Cleanup(client.Shutdown)
+		observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(100).Build())
+		client.Start()
+		return server, observer
+	}
+	first, firstEvents := subscribe()
+	_, secondEvents := subscribe()
+
+	// Emit until both bridge subscriptions are established
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	received := map[*ssevents.Observer]bool{}
+	for len(received) < 2 {
+		select {
+		case <-ticker.C:
+			first.Emit(ssevents.Event{Event: "order", Data: "bridged"})
+		case evt := <-firstEvents.EventCh:
+			received[firstEvents] = evt.Data == "bridged"
+		case evt := <-secondEvents.EventCh:
+			received[secondEvents] = evt.Data == "bridged"
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for bridged events, received %v", received)
+		}
+	}
+}
+
+// startFakeRedis serves PUBLISH and SUBSCRIBE of the Redis protocol
+func startFakeRedis(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	var mu sync.Mutex
+	subscribers := map[string][]net.Conn{}
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				reader := bufio.NewReader(conn)
+				for {
+					args, readErr := readCommand(reader)
+					if readErr != nil {
+						return
+					}
+					switch strings.ToUpper(args[0]) {
+					case "SUBSCRIBE":
+						mu.Lock()
+						subscribers[args[1]] = append(subscribers[args[1]], conn)
+						mu.Unlock()
+						_, _ = fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+					case "PUBLISH":
+						mu.Lock()
+						for _, sub := range subscribers[args[1]] {
+							_, _ = fmt.Fprintf(sub, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n",
+								len(args[1]), args[1], len(args[2]), args[2])
+						}
+						_, _ = fmt.Fprintf(conn, ":%d\r\n", len(subscribers[args[1]]))
+						mu.Unlock()
+					default:
+						_, _ = fmt.Fprintf(conn, "-ERR unknown command\r\n")
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, sizeErr := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if sizeErr != nil {
+			return nil, sizeErr
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+// startFakeNATS serves CONNECT, PING, SUB and PUB of the NATS protocol, subscriptions match subjects by prefix
+func startFakeNATS(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	type subscription struct {
+		prefix string
+		sid    string
+		conn   net.Conn
+	}
+	var mu sync.Mutex
+	var subscriptions []subscription
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+				reader := bufio.NewReader(conn)
+				for {
+					line, readErr := reader.ReadString('\n')
+					if readErr != nil {
+						return
+					}
+					fields := strings.Fields(line)
+					if len(fields) == 0 {
+						continue
+					}
+					switch fields[0] {
+					case "PING":
+						_, _ = fmt.Fprintf(conn, "PONG\r\n")
+					case "SUB":
+						mu.Lock()
+						prefix := strings.TrimSuffix(fields[1], ">")
+						subscriptions = append(subscriptions, subscription{prefix: prefix, sid: fields[2], conn: conn})
+						mu.Unlock()
+					case "PUB":
+						size, _ := strconv.Atoi(fields[2])
+						payload := make([]byte, size+2)
+						if _, readErr = io.ReadFull(reader, payload); readErr != nil {
+							return
+						}
+						mu.Lock()
+						for _, sub := range subscriptions {
+							if strings.HasPrefix(fields[1], sub.prefix) {
+								_, _ = fmt.Fprintf(sub.conn, "MSG %s %s %d\r\n%s", fields[1], sub.sid, size, payload)
+							}
+						}
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
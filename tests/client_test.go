@@ -6,7 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/doppelganger113/ssevents"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -318,3 +325,856 @@ func Test_givenObserverNoBuffer_whenOnEvents_thenReturnSpecifiedEventTypesOnly(t
 		t.Error(timeoutCtx.Err())
 	}
 }
+
+func Test_givenClientWithSink_whenEventsEmitted_thenForwardAllToSink(t *testing.T) {
+	const numberOfSentMessages = 3
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = server.Shutdown(context.Background())
+	}()
+
+	published := make(chan ssevents.Event, numberOfSentMessages)
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{
+		Logger: errorLogger(),
+		Sinks: []ssevents.Sink{ssevents.SinkFunc(func(_ context.Context, e ssevents.Event) error {
+			published <- e
+			return nil
+		})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	client.Start()
+
+	for i := 0; i < numberOfSentMessages; i++ {
+		server.Emit(ssevents.Event{Data: fmt.Sprintf("Message {%d}", i)})
+	}
+
+	for i := 0; i < numberOfSentMessages; i++ {
+		select {
+		case evt := <-published:
+			if evt.Data != fmt.Sprintf("Message {%d}", i) {
+				t.Errorf("unexpected event forwarded to sink %v", evt)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %d events in sink, got %d", numberOfSentMessages, i)
+		}
+	}
+}
+
+func Test_givenServerSendingHello_whenClientConnects_thenServerInfoAvailable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:            errorLogger(),
+		SendHello:         true,
+		HeartbeatInterval: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	hellos := client.Subscribe(ssevents.NewObserverBuilder().On("hello").Buffer(1).Build())
+	client.Start()
+
+	info, ok := client.ServerInfo()
+	for ; !ok && ctx.Err() == nil; info, ok = client.ServerInfo() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("expected the server info to be received")
+	}
+	if len(hellos.EventCh) != 0 {
+		t.Fatal("expected the hello event to be consumed by the client")
+	}
+	if info.ProtocolVersion != ssevents.ProtocolVersion || info.HeartbeatInterval != 5000 {
+		t.Fatalf("unexpected server info %+v", info)
+	}
+}
+
+func Test_givenClientHeadersAndRequestModifier_whenConnecting_thenAuthenticated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(),
+		Authenticate: func(req *http.Request) (map[string]any, error) {
+			if req.Header.Get("Authorization") != "Bearer secret" || req.Header.Get("X-Tenant") != "acme" {
+				return nil, errors.New("unauthenticated")
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{
+		Logger:  errorLogger(),
+		Headers: http.Header{"X-Tenant": []string{"acme"}},
+		RequestModifier: func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer secret")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	started := make(chan struct{})
+	go func() {
+		client.Start()
+		close(started)
+	}()
+
+	select {
+	case <-started:
+	case err = <-client.Errors():
+		t.Fatalf("expected the client to be authenticated, got %v", err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the client to connect")
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_givenClientTransport_whenConnecting_thenRequestsSentThroughIt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	var requests []string
+	var mu sync.Mutex
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{
+		Logger: errorLogger(),
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			requests = append(requests, req.URL.Path)
+			mu.Unlock()
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	client.Start()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 || requests[0] != "/sse" {
+		t.Fatalf("expected the SSE request to go through the transport, got %v", requests)
+	}
+}
+
+func Test_givenUnreachableServer_whenStartContext_thenReturnOnceContextDone(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + listener.Addr().String() + "/sse"
+	_ = listener.Close()
+
+	client, err := ssevents.NewSSEClient(url, &ssevents.ClientOptions{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func(errs <-chan error) {
+		for range errs {
+		}
+	}(client.Errors())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err = client.StartContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	client, err = ssevents.NewSSEClient(url, &ssevents.ClientOptions{
+		Logger:  errorLogger(),
+		Backoff: &ssevents.Backoff{InitialDelay: time.Millisecond, MaxRetries: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range client.Errors() {
+		}
+	}()
+	if err = client.StartContext(context.Background()); !errors.Is(err, ssevents.ErrClientClosed) {
+		t.Fatalf("expected the client closed after failed reconnects, got %v", err)
+	}
+}
+
+func Test_givenBlockedObserver_whenUnsubscribe_thenClosedAndOthersReceiveEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	blocked := client.Subscribe(ssevents.NewObserverBuilder().Build())
+	reading := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	client.Start()
+
+	// The unread observer without a buffer blocks the delivery to the other one until it is unsubscribed
+	server.Emit(ssevents.Event{Data: "first"})
+	server.Emit(ssevents.Event{Data: "second"})
+	time.Sleep(50 * time.Millisecond)
+	if len(reading.EventCh) != 0 {
+		t.Fatal("expected the delivery to be blocked by the unread observer")
+	}
+
+	if !client.Unsubscribe(blocked) {
+		t.Fatal("expected the observer to be unsubscribed")
+	}
+	if client.Unsubscribe(blocked) {
+		t.Fatal("expected the second unsubscribe to report it was not subscribed")
+	}
+	for range blocked.EventCh {
+	}
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case evt := <-reading.EventCh:
+			if evt.Data != expected {
+				t.Fatalf("expected the %s event, got %s", expected, evt)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the %s event", expected)
+		}
+	}
+}
+
+func Test_givenObserverWithContext_whenContextCanceled_thenUnsubscribed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observerCtx, cancelObserver := context.WithCancel(ctx)
+	scoped := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Context(observerCtx).Build())
+	client.Start()
+
+	server.Emit(ssevents.Event{Data: "first"})
+	if evt := <-scoped.EventCh; evt.Data != "first" {
+		t.Fatalf("expected the first event, got %s", evt)
+	}
+
+	cancelObserver()
+	for range scoped.EventCh {
+	}
+	if client.Unsubscribe(scoped) {
+		t.Fatal("expected the observer to be unsubscribed by its context")
+	}
+}
+
+func Test_givenObserverMapAndFlatMap_whenEventsEmitted_thenTransformedEventsReceived(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().
+		On("batch").
+		FlatMap(func(e ssevents.Event) []ssevents.Event {
+			var items []ssevents.Event
+			for _, item := range strings.Split(e.Data, ",") {
+				items = append(items, ssevents.Event{Event: e.Event, Data: item})
+			}
+			return items
+		}).
+		Map(func(e ssevents.Event) ssevents.Event {
+			e.Event = "item"
+			return e
+		}).
+		Limit(3).
+		Build(),
+	)
+	client.Start()
+
+	server.Emit(ssevents.Event{Event: "batch", Data: "a,b,c,d"})
+
+	var received []string
+	for evt := range observer.EventCh {
+		if evt.Event != "item" {
+			t.Fatalf("expected the renamed event, got %s", evt)
+		}
+		received = append(received, evt.Data)
+	}
+	if strings.Join(received, ",") != "a,b,c" {
+		t.Fatalf("expected the first 3 items, got %v", received)
+	}
+}
+
+func Test_givenObserverSkipAndDistinct_whenEventsEmitted_thenFirstAndRepeatedDropped(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().
+		On("item").
+		Skip(1).
+		Distinct(func(e ssevents.Event) string { return e.Data }).
+		Limit(2).
+		Build(),
+	)
+	client.Start()
+
+	for _, data := range []string{"a", "b", "b", "a", "c"} {
+		server.Emit(ssevents.Event{Event: "item", Data: data})
+	}
+
+	var received []string
+	for evt := range observer.EventCh {
+		received = append(received, evt.Data)
+	}
+	if strings.Join(received, ",") != "b,a" {
+		t.Fatalf("expected b,a, got %v", received)
+	}
+}
+
+func Test_givenObserverDebounce_whenEventsEmittedInBurst_thenOnlyLatestReceived(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().
+		On("progress").
+		Debounce(100 * time.Millisecond).
+		Buffer(10).
+		Build(),
+	)
+	client.Start()
+
+	for _, data := range []string{"1", "2", "3"} {
+		server.Emit(ssevents.Event{Event: "progress", Data: data})
+	}
+
+	select {
+	case evt := <-observer.EventCh:
+		if evt.Data != "3" {
+			t.Fatalf("expected the latest event, got %s", evt)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the debounced event")
+	}
+	select {
+	case evt := <-observer.EventCh:
+		t.Fatalf("expected a single event, got %s", evt)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func Test_givenObserver_whenWaitingForNAndMatch_thenReturnOnceConditionMet(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("step").Buffer(10).Build())
+	client.Start()
+
+	for _, data := range []string{"1", "2", "3", "4", "5"} {
+		server.Emit(ssevents.Event{Event: "step", Data: data})
+	}
+
+	events, err := observer.WaitForN(ctx, 2)
+	if err != nil || len(events) != 2 || events[0].Data != "1" || events[1].Data != "2" {
+		t.Fatalf("expected the first 2 events, got %v: %v", events, err)
+	}
+	evt, err := observer.WaitForMatch(ctx, func(e ssevents.Event) bool { return e.Data == "4" })
+	if err != nil || evt.Data != "4" {
+		t.Fatalf("expected the matching event, got %s: %v", evt, err)
+	}
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelWait()
+	events, err = observer.WaitForN(waitCtx, 2)
+	if !errors.Is(err, context.DeadlineExceeded) || len(events) != 1 || events[0].Data != "5" {
+		t.Fatalf("expected the remaining event with a deadline error, got %v: %v", events, err)
+	}
+
+	client.Unsubscribe(observer)
+	_, err = observer.WaitForMatch(ctx, func(ssevents.Event) bool { return true })
+	if !errors.Is(err, ssevents.ErrObserverCompleted) {
+		t.Fatalf("expected ErrObserverCompleted, got %v", err)
+	}
+}
+
+func Test_givenStartedClient_whenSubscribingLater_thenObserverReceivesEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+	client.Start()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	for {
+		server.Emit(ssevents.Event{Data: "late"})
+		select {
+		case evt := <-observer.EventCh:
+			if evt.Data != "late" {
+				t.Fatalf("expected the late event, got %s", evt)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the observer subscribed after start")
+		}
+	}
+}
+
+func Test_givenSubscribeFunc_whenHandlerPanics_thenRecoveredAndNextEventsHandled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	handled := make(chan string, 10)
+	client.SubscribeFunc(func(e ssevents.Event) bool {
+		return e.Event == "order"
+	}, func(e ssevents.Event) {
+		if e.Data == "panic" {
+			panic("handler failed")
+		}
+		handled <- e.Data
+	})
+	client.Start()
+
+	server.Emit(ssevents.Event{Event: "order", Data: "panic"})
+	server.Emit(ssevents.Event{Event: "payment", Data: "skipped"})
+	server.Emit(ssevents.Event{Event: "order", Data: "handled"})
+
+	select {
+	case data := <-handled:
+		if data != "handled" {
+			t.Fatalf("expected the handled order, got %s", data)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the handler")
+	}
+}
+
+type order struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+}
+
+func Test_givenJSONEvents_whenSubscribeJSON_thenDecodedOrError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	orders := ssevents.SubscribeJSON[order](client, ssevents.NewObserverBuilder().On("order").Buffer(10))
+	client.Start()
+
+	server.Emit(ssevents.Event{Event: "order", Data: "not json"})
+	server.Emit(ssevents.Event{Event: "order", Data: `{"id":"o-1","total":9.5}`})
+
+	select {
+	case decodeErr := <-orders.ErrCh:
+		if decodeErr == nil {
+			t.Fatal("expected a decode error")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the decode error")
+	}
+	select {
+	case o := <-orders.EventCh:
+		if o.ID != "o-1" || o.Total != 9.5 {
+			t.Fatalf("unexpected order %+v", o)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the order")
+	}
+
+	client.Unsubscribe(orders.Observer)
+	if _, ok := <-orders.EventCh; ok {
+		t.Fatal("expected the typed observer to be closed")
+	}
+}
+
+func Test_givenUnreadTypedObserver_whenUnsubscribed_thenClosed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	orders := ssevents.SubscribeJSON[order](client, ssevents.NewObserverBuilder().On("order").Buffer(1))
+	client.Start()
+
+	for i := 0; i < 3; i++ {
+		server.Emit(ssevents.Event{Event: "order", Data: fmt.Sprintf(`{"id":"o-%d"}`, i)})
+	}
+	for len(orders.EventCh) == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the order")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// The decoding goroutine is blocked on the full EventCh which is never read
+	client.Unsubscribe(orders.Observer)
+	select {
+	case _, ok := <-orders.ErrCh:
+		if ok {
+			t.Fatal("expected no decode error")
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the typed observer to be closed without reading its events")
+	}
+}
+
+func Test_givenClient_whenConnectionLost_thenStatesNotified(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{
+		Logger:  errorLogger(),
+		Backoff: &ssevents.Backoff{InitialDelay: time.Millisecond, MaxRetries: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func(errs <-chan error) {
+		for range errs {
+		}
+	}(client.Errors())
+	client.SubscribeFunc(nil, func(ssevents.Event) {})
+
+	if state := client.State(); state != ssevents.ConnStateIdle {
+		t.Fatalf("expected the idle state before start, got %s", state)
+	}
+	client.Start()
+	if state := client.State(); state != ssevents.ConnStateConnected {
+		t.Fatalf("expected the connected state after start, got %s", state)
+	}
+	if err = server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ssevents.ConnState{
+		ssevents.ConnStateConnecting, ssevents.ConnStateConnected, ssevents.ConnStateReconnecting,
+		ssevents.ConnStateClosed,
+	}
+	var states []ssevents.ConnState
+	for open := true; open; {
+		var state ssevents.ConnState
+		select {
+		case state, open = <-client.States():
+			if open {
+				states = append(states, state)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the states, got %v", states)
+		}
+	}
+	if !slices.Equal(states, expected) {
+		t.Fatalf("expected states %v, got %v", expected, states)
+	}
+}
+
+func Test_givenIdleTimeout_whenNothingReceived_thenClientReconnects(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), HeartbeatInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:      errorLogger(),
+		Transport:   ssevents.NewHandlerTransport(server.Handler()),
+		IdleTimeout: 100 * time.Millisecond,
+		Backoff:     &ssevents.Backoff{InitialDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	errs := make(chan error, 10)
+	client.OnError(func(err error) { errs <- err })
+	client.Start()
+
+	select {
+	case err = <-errs:
+		if !errors.Is(err, ssevents.ErrConnectionIdle) {
+			t.Fatalf("expected ErrConnectionIdle, got %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the idle connection to be closed")
+	}
+	for state := client.State(); state != ssevents.ConnStateConnected; state = client.State() {
+		if ctx.Err() != nil {
+			t.Fatalf("expected the client to reconnect, got %s", state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_givenConnectionHooks_whenConnectionLost_thenCalledInOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), HeartbeatInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	hooks := make(chan string, 10)
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:      errorLogger(),
+		Transport:   ssevents.NewHandlerTransport(server.Handler()),
+		IdleTimeout: 50 * time.Millisecond,
+		Backoff:     &ssevents.Backoff{InitialDelay: time.Millisecond, Jitter: 0.01},
+		OnConnect:   func() { hooks <- "connect" },
+		OnDisconnect: func(err error) {
+			hooks <- fmt.Sprintf("disconnect: %v", errors.Is(err, ssevents.ErrConnectionIdle))
+		},
+		OnReconnectAttempt: func(attempt int, delay time.Duration) {
+			hooks <- fmt.Sprintf("reconnect %d: %v", attempt, delay < 2*time.Millisecond)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	go func(errs <-chan error) {
+		for range errs {
+		}
+	}(client.Errors())
+	client.Start()
+
+	expected := []string{"connect", "disconnect: true", "reconnect 1: true", "connect"}
+	var called []string
+	for len(called) < len(expected) {
+		select {
+		case hook := <-hooks:
+			called = append(called, hook)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the hooks, got %v", called)
+		}
+	}
+	if !slices.Equal(called, expected) {
+		t.Fatalf("expected hooks %v, got %v", expected, called)
+	}
+}
+
+func Test_givenTokenProvider_whenReconnecting_thenFreshTokenSent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	authorized := make(chan string, 10)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:            errorLogger(),
+		HeartbeatInterval: time.Hour,
+		Authenticate: func(req *http.Request) (map[string]any, error) {
+			authorized <- req.Header.Get("Authorization")
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	var issued atomic.Int32
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:      errorLogger(),
+		Transport:   ssevents.NewHandlerTransport(server.Handler()),
+		IdleTimeout: 50 * time.Millisecond,
+		Backoff:     &ssevents.Backoff{InitialDelay: time.Millisecond},
+		TokenProvider: func(context.Context) (string, error) {
+			return fmt.Sprintf("token-%d", issued.Add(1)), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	go func(errs <-chan error) {
+		for range errs {
+		}
+	}(client.Errors())
+	client.Start()
+
+	for _, expected := range []string{"Bearer token-1", "Bearer token-2"} {
+		select {
+		case header := <-authorized:
+			if header != expected {
+				t.Fatalf("expected %q, got %q", expected, header)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %q", expected)
+		}
+	}
+}
+
+func Test_givenPostMethodAndBody_whenConnecting_thenSubscriptionSentWithBody(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	ctrl := ssevents.NewController(&ssevents.Options{Logger: errorLogger()})
+	defer func() { _ = ctrl.Shutdown() }()
+	subscriptions := make(chan string, 10)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil || req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "expected a JSON POST", http.StatusBadRequest)
+			return
+		}
+		subscriptions <- string(body)
+		ctrl.Handler().ServeHTTP(w, req)
+	})
+
+	client, err := ssevents.NewSSEClient("http://ssevents.test/stream", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(handler),
+		Method:    http.MethodPost,
+		Body:      []byte(`{"query":"subscription { orders }"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("order").First().Build())
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := <-subscriptions; body != `{"query":"subscription { orders }"}` {
+		t.Fatalf("expected the subscription body, got %s", body)
+	}
+	ctrl.Emit(ssevents.Event{Event: "order", Data: "1"})
+	if _, err = observer.WaitForN(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_givenFrameWithoutData_whenReconnecting_thenRetryAndLastEventIDApplied(t *testing.T) {
+	lastEventIDs := make(chan string, 1)
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if connections.Add(1) == 1 {
+			// Only protocol fields, nothing is dispatched to the observers
+			_, _ = io.WriteString(w, "id: 42\nretry: 20\n\n")
+			return
+		}
+		lastEventIDs <- req.Header.Get("Last-Event-ID")
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := ssevents.NewSSEClient(server.URL, &ssevents.ClientOptions{
+		Logger:  errorLogger(),
+		Backoff: &ssevents.Backoff{InitialDelay: 10 * time.Second, MaxRetries: ssevents.BackoffUnlimitedRetries},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	client.SubscribeFunc(nil, func(ssevents.Event) {})
+	client.Start()
+
+	// Reconnecting within the time proves the retry of the frame replaced the 10s initial delay
+	select {
+	case lastEventID := <-lastEventIDs:
+		if lastEventID != "42" {
+			t.Fatalf("expected Last-Event-ID 42 got %q", lastEventID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the client to reconnect after the retry of the frame")
+	}
+}
@@ -0,0 +1,312 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/cluster"
+)
+
+const clusterSecret = "cluster-secret"
+
+func startClusterNode(t *testing.T, id string, seeds []string) (*cluster.Node, string) {
+	t.Helper()
+	logger := errorLogger()
+
+	// The advertised url has to be known upfront, so a free port is reserved and released for the server to take
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if err = listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+	url := "http://localhost:" + strconv.Itoa(port)
+
+	node, err := cluster.NewNode(cluster.Options{
+		ID:             id,
+		AdvertiseURL:   url,
+		Seeds:          seeds,
+		GossipInterval: 20 * time.Millisecond,
+		Secret:         clusterSecret,
+		Logger:         logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Port:         port,
+		Handlers:     node.Handlers(),
+		Logger:       logger,
+		OnConnect:    node.OnConnect,
+		OnDisconnect: node.OnDisconnect,
+		Sources:      []ssevents.Source{node},
+		UserIDFunc: func(req *http.Request) string {
+			return req.URL.Query().Get("user")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Attach(server)
+
+	go func() {
+		_ = server.ListenAndServe()
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	})
+
+	return node, url
+}
+
+func waitForMembers(t *testing.T, node *cluster.Node, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(node.Members()) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d cluster members, got %v", count, node.Members())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_givenCluster_whenEmitToUserOnAnotherNode_thenDeliverToOwningConnection(t *testing.T) {
+	nodeA, urlA := startClusterNode(t, "node-a", nil)
+	nodeB, urlB := startClusterNode(t, "node-b", []string{urlA})
+	waitForMembers(t, nodeA, 2)
+	waitForMembers(t, nodeB, 2)
+
+	client, err := ssevents.NewSSEClient(urlB+"/sse?user=user-1", &ssevents.ClientOptions{
+		Logger: errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().First().Build())
+	client.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	// Registration of the connection happens on connect, though the hashing ring might still be settling
+	go func() {
+		for ctx.Err() == nil {
+			if emitErr := nodeA.EmitToUser(ctx, "user-1", ssevents.Event{Data: "hello"}); emitErr == nil {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	events, err := observer.WaitForAllOrTimeout(2 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Data != "hello" {
+		t.Errorf("expected a single hello event, got %v", events)
+	}
+}
+
+func Test_givenClusterWithoutSecret_whenCreatingNode_thenRequireInsecure(t *testing.T) {
+	_, err := cluster.NewNode(cluster.Options{ID: "node-a", AdvertiseURL: "http://localhost:3000"})
+	if !errors.Is(err, cluster.ErrMissingSecret) {
+		t.Fatalf("expected ErrMissingSecret got %v", err)
+	}
+
+	_, err = cluster.NewNode(cluster.Options{ID: "node-a", AdvertiseURL: "http://localhost:3000", Insecure: true})
+	if err != nil {
+		t.Fatalf("expected insecure node to be created got %v", err)
+	}
+}
+
+func Test_givenClusterNode_whenRequestWithWrongSecret_thenForbidden(t *testing.T) {
+	node, err := cluster.NewNode(cluster.Options{ID: "node-a", AdvertiseURL: "http://localhost:1", Secret: clusterSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	register := node.Handlers()["POST /cluster/register"]
+
+	tests := []struct {
+		name   string
+		secret string
+		status int
+	}{
+		{name: "missing secret", status: http.StatusForbidden},
+		{name: "wrong secret", secret: "guess", status: http.StatusForbidden},
+		{name: "valid secret", secret: clusterSecret, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/cluster/register", strings.NewReader("[]"))
+			if tt.secret != "" {
+				req.Header.Set("X-Cluster-Secret", tt.secret)
+			}
+			res := httptest.NewRecorder()
+
+			register(res, req)
+
+			if res.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, res.Code)
+			}
+		})
+	}
+}
+
+func Test_givenUnreachableOwner_whenConnecting_thenOnConnectDoesNotBlock(t *testing.T) {
+	// The blackhole listener accepts connections but never responds, a blocking registration would hang
+	blackhole, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = blackhole.Close() }()
+
+	node, err := cluster.NewNode(cluster.Options{
+		ID:           "node-a",
+		AdvertiseURL: "http://localhost:1",
+		Secret:       clusterSecret,
+		Logger:       errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Learn about the unreachable member through gossip so it owns part of the ring
+	gossip := fmt.Sprintf(`[{"id":"node-b","url":"http://%s","heartbeat":1}]`, blackhole.Addr())
+	req := httptest.NewRequest(http.MethodPost, "/cluster/gossip", strings.NewReader(gossip))
+	req.Header.Set("X-Cluster-Secret", clusterSecret)
+	node.Handlers()["POST /cluster/gossip"](httptest.NewRecorder(), req)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 20 {
+			node.OnConnect(ssevents.ConnInfo{ID: "conn-" + strconv.Itoa(i), UserID: "user-" + strconv.Itoa(i)})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected OnConnect to return without waiting on the owner")
+	}
+}
+
+// countingEmitter counts the targeted events delivered to the local connections
+type countingEmitter struct {
+	delivered atomic.Int32
+}
+
+func (e *countingEmitter) EmitToUser(string, ssevents.Event) int {
+	e.delivered.Add(1)
+	return 1
+}
+
+func (e *countingEmitter) EmitToSubscriber(string, ssevents.Event) bool {
+	e.delivered.Add(1)
+	return true
+}
+
+func Test_givenConnectionsSharingSubscriberID_whenOneDisconnects_thenStillRegistered(t *testing.T) {
+	node, err := cluster.NewNode(cluster.Options{
+		ID:             "node-a",
+		AdvertiseURL:   "http://localhost:1",
+		GossipInterval: 10 * time.Millisecond,
+		MemberTimeout:  50 * time.Millisecond,
+		Insecure:       true,
+		Logger:         errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := &countingEmitter{}
+	node.Attach(local)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = node.Run(ctx, nil)
+	}()
+
+	// delivered emits until the local connection is reached or the deadline passes
+	delivered := func() bool {
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			before := local.delivered.Load()
+			if emitErr := node.EmitToSubscriber(ctx, "shared", ssevents.Event{Data: "hello"}); emitErr != nil {
+				t.Fatal(emitErr)
+			}
+			if local.delivered.Load() > before {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	info := ssevents.ConnInfo{ID: "shared", UserID: "user-1"}
+	node.OnConnect(info)
+	node.OnConnect(info)
+	if !delivered() {
+		t.Fatal("expected the connection to be registered")
+	}
+	node.OnDisconnect(info)
+	// A member joining and expiring resyncs the directory from the connections of the node
+	gossip := `[{"id":"node-b","url":"http://localhost:1","heartbeat":1}]`
+	req := httptest.NewRequest(http.MethodPost, "/cluster/gossip", strings.NewReader(gossip))
+	node.Handlers()["POST /cluster/gossip"](httptest.NewRecorder(), req)
+	waitForMembers(t, node, 2)
+	deadline := time.Now().Add(time.Second)
+	for len(node.Members()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the member to expire, got %v", node.Members())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !delivered() {
+		t.Fatal("expected the subscriber to stay registered while one of its connections is open")
+	}
+
+	node.OnDisconnect(info)
+	deadline = time.Now().Add(time.Second)
+	for {
+		before := local.delivered.Load()
+		if emitErr := node.EmitToSubscriber(ctx, "shared", ssevents.Event{Data: "hello"}); emitErr != nil {
+			t.Fatal(emitErr)
+		}
+		if local.delivered.Load() == before {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the subscriber to be removed once all of its connections closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_givenOversizedClusterRequest_whenHandled_thenRequestEntityTooLarge(t *testing.T) {
+	node, err := cluster.NewNode(cluster.Options{ID: "node-a", AdvertiseURL: "http://localhost:1", Secret: clusterSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `[{"kind":"user","key":"` + strings.Repeat("a", 9<<20) + `","node":"node-b"}]`
+	req := httptest.NewRequest(http.MethodPost, "/cluster/register", strings.NewReader(body))
+	req.Header.Set("X-Cluster-Secret", clusterSecret)
+	res := httptest.NewRecorder()
+	node.Handlers()["POST /cluster/register"](res, req)
+
+	if res.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, res.Code)
+	}
+}
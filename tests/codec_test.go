@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/msgpacksse"
+	"github.com/doppelganger113/ssevents/protosse"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type shipment struct {
+	ID      string            `json:"id"`
+	Weight  float64           `json:"weight"`
+	Items   []int64           `json:"items"`
+	Labels  map[string]string `json:"labels"`
+	Payload []byte            `json:"payload"`
+	Urgent  bool              `json:"urgent"`
+}
+
+func Test_givenMsgpackCodec_whenEncodedEventDecoded_thenValueRoundTrips(t *testing.T) {
+	sent := shipment{
+		ID:      "s-1",
+		Weight:  12.75,
+		Items:   []int64{0, -1, 127, -33, 300, -40000, 1 << 40},
+		Labels:  map[string]string{"carrier": "dhl", "long": string(make([]byte, 300))},
+		Payload: []byte{0, 1, 2, 255},
+		Urgent:  true,
+	}
+	e, err := ssevents.NewEncodedEvent(msgpacksse.Codec, "shipment", sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Extensions[ssevents.EncodingExtension] != "base64" {
+		t.Fatalf("expected the binary data to be base64 encoded, got extensions %v", e.Extensions)
+	}
+
+	var received shipment
+	if err = ssevents.DecodeEvent(msgpacksse.Codec, e, &received); err != nil {
+		t.Fatal(err)
+	}
+	if received.ID != sent.ID || received.Weight != sent.Weight || !received.Urgent ||
+		len(received.Items) != len(sent.Items) || received.Labels["long"] != sent.Labels["long"] ||
+		string(received.Payload) != string(sent.Payload) {
+		t.Fatalf("expected %+v, got %+v", sent, received)
+	}
+	for i, item := range sent.Items {
+		if received.Items[i] != item {
+			t.Fatalf("expected item %d to be %d, got %d", i, item, received.Items[i])
+		}
+	}
+
+	if err = msgpacksse.Codec.Unmarshal([]byte{0xdc, 0xff}, &received); err == nil {
+		t.Fatal("expected an error for truncated data")
+	}
+}
+
+func Test_givenCodecs_whenEmitEncoded_thenTypedObserversDecode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	shipments := ssevents.SubscribeCodec[shipment](
+		client, ssevents.NewObserverBuilder().On("shipment").Buffer(10), msgpacksse.Codec,
+	)
+	names := ssevents.SubscribeCodec[*wrapperspb.StringValue](
+		client, ssevents.NewObserverBuilder().On("name").Buffer(10), protosse.Codec,
+	)
+	client.Start()
+
+	if err = server.EmitEncoded(msgpacksse.Codec, "shipment", shipment{ID: "s-2", Weight: 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.EmitEncoded(protosse.Codec, "name", wrapperspb.String("parcel")); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.EmitEncoded(protosse.Codec, "name", "not a message"); err == nil {
+		t.Fatal("expected an error encoding a value that is not a proto.Message")
+	}
+
+	select {
+	case s := <-shipments.EventCh:
+		if s.ID != "s-2" || s.Weight != 1.5 {
+			t.Fatalf("unexpected shipment %+v", s)
+		}
+	case err = <-shipments.ErrCh:
+		t.Fatal(err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the shipment")
+	}
+	select {
+	case name := <-names.EventCh:
+		if name.GetValue() != "parcel" {
+			t.Fatalf("expected the parcel name, got %q", name.GetValue())
+		}
+	case err = <-names.ErrCh:
+		t.Fatal(err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the name")
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/brotlisse"
+)
+
+func Test_givenEnableCompression_whenClientAcceptsGzip_thenEventsStreamedCompressed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), EnableCompression: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	var uncompressed bool
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{
+		Logger: errorLogger(),
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// The transport asks for gzip and decompresses on its own
+			res, resErr := http.DefaultTransport.RoundTrip(req)
+			if resErr == nil {
+				uncompressed = res.Uncompressed
+			}
+			return res, resErr
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(1).First().Build())
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Emit(ssevents.Event{Data: "compressed"})
+	select {
+	case evt := <-observer.EventCh:
+		if evt.Data != "compressed" {
+			t.Fatalf("unexpected event %s", evt)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the compressed event")
+	}
+	if !uncompressed {
+		t.Fatal("expected a gzip encoded stream")
+	}
+}
+
+func Test_givenBrotliCompressor_whenClientAcceptsBrotli_thenPreferredOverGzip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:            errorLogger(),
+		EnableCompression: true,
+		Compressors:       []ssevents.Compressor{brotlisse.Compressor{}, ssevents.GzipCompressor{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	connect := func(acceptEncoding string) *http.Response {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		res, reqErr := http.DefaultClient.Do(req)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		t.Cleanup(func() { _ = res.Body.Close() })
+		return res
+	}
+
+	if res := connect("gzip;q=0, br;q=0"); res.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("expected refused codings to stream uncompressed, got %q", res.Header.Get("Content-Encoding"))
+	}
+
+	res := connect("gzip, deflate, br")
+	if encoding := res.Header.Get("Content-Encoding"); encoding != "br" {
+		t.Fatalf("expected the br encoding, got %q", encoding)
+	}
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, brotli.NewReader(res.Body), out) }()
+	select {
+	case evt := <-out:
+		if evt.Event != "heartbeat" {
+			t.Fatalf("expected the initial heartbeat, got %s", evt)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the flushed heartbeat")
+	}
+}
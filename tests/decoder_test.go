@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/doppelganger113/ssevents"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func Test_givenLineOverScannerLimit_whenDecode_thenFullData(t *testing.T) {
+	data := strings.Repeat("x", 256*1024)
+	decoder := ssevents.NewDecoder(strings.NewReader("event: big\ndata: " + data + "\n\n"))
+
+	evt, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Event != "big" || evt.Data != data {
+		t.Fatalf("expected big event with %d bytes of data, got %s with %d", len(data), evt.Event, len(evt.Data))
+	}
+	if _, err = decoder.Decode(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF got %v", err)
+	}
+}
+
+func Test_givenFramesSplitAcrossReads_whenDecode_thenEventsAssembled(t *testing.T) {
+	stream := ": comment\r\nid: 1\r\nevent: first\r\ndata: a\r\n\r\n" +
+		"id: 2\ntraceparent: 00-abc-def-01\ndata: b\n\n" +
+		"id: 3\ndata: partial"
+	decoder := ssevents.NewDecoder(iotest.OneByteReader(strings.NewReader(stream)))
+
+	first, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Id != "1" || first.Event != "first" || first.Data != "a" {
+		t.Fatalf("unexpected first event %s", first)
+	}
+
+	second, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Id != "2" || second.Data != "b" || second.Extensions["traceparent"] != "00-abc-def-01" {
+		t.Fatalf("unexpected second event %s", second)
+	}
+
+	if _, err = decoder.Decode(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected unterminated event to be discarded with EOF, got %v", err)
+	}
+}
+
+func Test_givenRetryField_whenDecode_thenRetryParsed(t *testing.T) {
+	decoder := ssevents.NewDecoder(strings.NewReader("retry: 1500\ndata: a\n\nretry: soon\ndata: b\n\n"))
+
+	first, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Retry != 1500 {
+		t.Fatalf("expected retry 1500, got %d", first.Retry)
+	}
+
+	second, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Retry != 0 || second.Data != "b" {
+		t.Fatalf("expected invalid retry to be ignored, got %s", second)
+	}
+}
+
+func Test_givenRetryOnlyFrame_whenDecode_thenRetryKept(t *testing.T) {
+	decoder := ssevents.NewDecoder(strings.NewReader("retry: 5000\n\ndata: a\n\n"))
+
+	event, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Data != "a" || event.Retry != 0 {
+		t.Fatalf("expected the data event without retry, got %s", event)
+	}
+	if decoder.Retry() != 5000 {
+		t.Fatalf("expected retry 5000 of the frame without data, got %d", decoder.Retry())
+	}
+}
+
+func Test_givenIDOnlyFrame_whenDecode_thenLastEventIDKept(t *testing.T) {
+	decoder := ssevents.NewDecoder(strings.NewReader("id: 7\n\ndata: a\n\nid\n\n"))
+
+	event, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Data != "a" || event.Id != "" {
+		t.Fatalf("expected the data event without its own id, got %s", event)
+	}
+	if decoder.LastEventID() != "7" {
+		t.Fatalf("expected last event id 7 of the frame without data, got %q", decoder.LastEventID())
+	}
+
+	if _, err = decoder.Decode(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the end of the stream, got %v", err)
+	}
+	if decoder.LastEventID() != "" {
+		t.Fatalf("expected the empty id field to reset the last event id, got %q", decoder.LastEventID())
+	}
+}
+
+func Test_givenMultilineData_whenEncodedAndDecoded_thenNewlinesPreserved(t *testing.T) {
+	data := "{\n  \"name\": \"sse\"\r\n}\n"
+	wire, err := ssevents.Event{Event: "json", Data: data}.ToResponseString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "event: json\ndata: {\ndata:   \"name\": \"sse\"\ndata: }\ndata: \n\n\n"
+	if wire != expected {
+		t.Fatalf("expected %q got %q", expected, wire)
+	}
+
+	evt, err := ssevents.NewDecoder(strings.NewReader(wire)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedData := "{\n  \"name\": \"sse\"\n}\n"; evt.Data != expectedData {
+		t.Fatalf("expected data %q got %q", expectedData, evt.Data)
+	}
+}
+
+func Test_givenFieldsWithoutSpace_whenDecode_thenValuesParsed(t *testing.T) {
+	decoder := ssevents.NewDecoder(strings.NewReader("event:compact\ndata:first\ndata\ndata:  indented\n\n"))
+
+	evt, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Event != "compact" || evt.Data != "first\n\n indented" {
+		t.Fatalf("unexpected event %q with data %q", evt.Event, evt.Data)
+	}
+}
+
+func Test_givenJSONLinesDecoder_whenClientReadsNDJSONStream_thenLinesMappedToEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	accepted := make(chan string, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accepted <- req.Header.Get("Accept")
+		w.Header().Set("Content-Type", ssevents.MediaTypeJSONLines+"; charset=utf-8")
+		_, _ = io.WriteString(w, `{"type":"delta","text":"Hel"}`+"\n\n"+`{"type":"delta","text":"lo"}`+"\n")
+		<-req.Context().Done()
+	})
+	client, err := ssevents.NewSSEClient("http://ssevents.test/stream", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(handler),
+		Decoders: map[string]func(r io.Reader) ssevents.StreamDecoder{
+			ssevents.MediaTypeJSONLines: func(r io.Reader) ssevents.StreamDecoder {
+				decoder := ssevents.NewJSONLinesDecoder(r)
+				decoder.Map = func(line []byte) (ssevents.Event, error) {
+					var chunk struct {
+						Type string `json:"type"`
+						Text string `json:"text"`
+					}
+					err := json.Unmarshal(line, &chunk)
+					return ssevents.Event{Event: chunk.Type, Data: chunk.Text}, err
+				}
+				return decoder
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("delta").Buffer(10).Build())
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if accept := <-accepted; accept != "text/event-stream, "+ssevents.MediaTypeJSONLines {
+		t.Fatalf("expected both media types accepted, got %s", accept)
+	}
+	events, err := observer.WaitForN(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := events[0].Data + events[1].Data; text != "Hello" {
+		t.Fatalf("expected the deltas of Hello, got %s", text)
+	}
+}
+
+func Test_givenWHATWGStreams_whenDecode_thenEventsConformToSpec(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		stream   string
+		expected []ssevents.Event
+	}{
+		{
+			name:     "multiple data lines",
+			stream:   "data: YHOO\ndata: +2\ndata: 10\n\n",
+			expected: []ssevents.Event{{Data: "YHOO\n+2\n10"}},
+		},
+		{
+			name:   "comments, ids and leading spaces",
+			stream: ": test stream\n\ndata: first event\nid: 1\n\ndata:second event\nid\n\ndata:  third event\n\n",
+			expected: []ssevents.Event{
+				{Id: "1", Data: "first event"}, {Data: "second event"}, {Data: " third event"},
+			},
+		},
+		{
+			name:     "empty data and unterminated event",
+			stream:   "data\n\ndata\ndata\n\ndata:",
+			expected: []ssevents.Event{{Data: ""}, {Data: "\n"}},
+		},
+		{
+			name:     "optional space after colon",
+			stream:   "data:test\n\ndata: test\n\n",
+			expected: []ssevents.Event{{Data: "test"}, {Data: "test"}},
+		},
+		{
+			name:     "byte order mark",
+			stream:   "\xEF\xBB\xBFevent: first\ndata: a\n\n",
+			expected: []ssevents.Event{{Event: "first", Data: "a"}},
+		},
+		{
+			name:     "carriage return line endings",
+			stream:   "event: cr\rdata: a\rdata: b\r\rdata: crlf\r\n\r\n",
+			expected: []ssevents.Event{{Event: "cr", Data: "a\nb"}, {Data: "crlf"}},
+		},
+		{
+			name:     "invalid retry and id",
+			stream:   "retry: +5\nid: a\x00b\ndata: a\n\nretry: 10x\ndata: b\n\n",
+			expected: []ssevents.Event{{Data: "a"}, {Data: "b"}},
+		},
+		{
+			name:     "event without data",
+			stream:   "event: ignored\n\nevent: kept\ndata: a\n\n",
+			expected: []ssevents.Event{{Event: "kept", Data: "a"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Reading one byte at a time splits every "\r\n" across reads
+			decoder := ssevents.NewDecoder(iotest.OneByteReader(strings.NewReader(tc.stream)))
+			var events []ssevents.Event
+			for {
+				evt, err := decoder.Decode()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				events = append(events, evt)
+			}
+			if len(events) != len(tc.expected) {
+				t.Fatalf("expected events %v, got %v", tc.expected, events)
+			}
+			for i, evt := range events {
+				if evt.String() != tc.expected[i].String() {
+					t.Fatalf("expected event %q, got %q", tc.expected[i], evt)
+				}
+			}
+		})
+	}
+}
+
+func Test_givenMaxEventSize_whenEventExceedsIt_thenErrEventTooLarge(t *testing.T) {
+	stream := "data: small\n\ndata: " + strings.Repeat("x", 64*1024) + "\n\n"
+	decoder := ssevents.NewDecoder(strings.NewReader(stream))
+	decoder.MaxEventSize = 1024
+
+	if evt, err := decoder.Decode(); err != nil || evt.Data != "small" {
+		t.Fatalf("expected the small event, got %s: %v", evt, err)
+	}
+	if _, err := decoder.Decode(); !errors.Is(err, ssevents.ErrEventTooLarge) {
+		t.Fatalf("expected ErrEventTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/dockerevents"
+)
+
+func Test_givenDockerEngine_whenEventsStreamed_thenEmitAndResumeAfterLastEvent(t *testing.T) {
+	var mu sync.Mutex
+	var queries []map[string]string
+	engine := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		queries = append(queries, map[string]string{
+			"filters": req.URL.Query().Get("filters"), "since": req.URL.Query().Get("since"),
+		})
+		first := len(queries) == 1
+		mu.Unlock()
+		if !first {
+			<-req.Context().Done()
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"Type":"container","Action":"start","Actor":{"ID":"c1"},"timeNano":1700000000000000000}`)
+		_, _ = fmt.Fprintln(w, `{"Type":"container","Action":"die","Actor":{"ID":"c1"},"timeNano":1700000001000000000}`)
+	}))
+	defer engine.Close()
+
+	source, err := dockerevents.New(dockerevents.Options{
+		Host:       "tcp://" + strings.TrimPrefix(engine.URL, "http://"),
+		Filters:    map[string][]string{"label": {"ci=true"}},
+		RetryDelay: 10 * time.Millisecond,
+		Logger:     errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events := make(chan ssevents.Event, 10)
+	go func() {
+		_ = source.Run(ctx, func(e ssevents.Event) { events <- e })
+	}()
+
+	for _, expected := range []string{"container.start", "container.die"} {
+		select {
+		case e := <-events:
+			if e.Event != expected {
+				t.Fatalf("expected %s got %s", expected, e.Event)
+			}
+		case <-ctx.Done():
+			t.Fatalf("expected %s event", expected)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		got := append([]map[string]string(nil), queries...)
+		mu.Unlock()
+		if len(got) >= 2 {
+			var filters map[string][]string
+			if err = json.Unmarshal([]byte(got[0]["filters"]), &filters); err != nil {
+				t.Fatal(err)
+			}
+			if len(filters["type"]) != 1 || filters["type"][0] != "container" || filters["label"][0] != "ci=true" {
+				t.Errorf("expected container and label filters got %v", filters)
+			}
+			if got[0]["since"] != "" || got[1]["since"] != "1700000001.000000001" {
+				t.Errorf("expected the stream to resume after the last event got %v", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the stream to reconnect got %v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_givenUnsupportedDockerHost_whenCreatingSource_thenError(t *testing.T) {
+	if _, err := dockerevents.New(dockerevents.Options{Host: "ssh://docker"}); err == nil {
+		t.Fatal("expected an error for the ssh host")
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// benchmarkEmit measures a single Emit to the given number of subscribers, their channels are drained on every
+// iteration so the emit never blocks
+func benchmarkEmit(b *testing.B, strategy ssevents.EmitStrategy, subscribers, workers int) {
+	ctrl := ssevents.NewController(&ssevents.Options{
+		Logger:          errorLogger(),
+		EmitStrategy:    strategy,
+		FanoutWorkers:   workers,
+		FanoutBatchSize: 64,
+	})
+	defer func() { _ = ctrl.Shutdown() }()
+	channels := make([]chan ssevents.Event, subscribers)
+	for i := range channels {
+		channels[i] = make(chan ssevents.Event, 1)
+		ctrl.Store(i, channels[i])
+	}
+	evt := ssevents.Event{Id: "1", Event: "update", Data: "payload"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctrl.Emit(evt)
+		for _, ch := range channels {
+			<-ch
+		}
+	}
+}
+
+func BenchmarkEmit(b *testing.B) {
+	strategies := []ssevents.EmitStrategy{ssevents.EmitStrategyBlock, ssevents.EmitStrategyDrop}
+	for _, strategy := range strategies {
+		for _, subscribers := range []int{1, 100, 1000} {
+			b.Run(strategy.String()+"/"+strconv.Itoa(subscribers), func(b *testing.B) {
+				benchmarkEmit(b, strategy, subscribers, 0)
+			})
+		}
+	}
+}
+
+// Test_givenEmitWithoutWorkers_whenBenchmarked_thenSingleAllocation guards the allocations of the default emit path,
+// delivering to the shards must not allocate per shard
+func Test_givenEmitWithoutWorkers_whenBenchmarked_thenSingleAllocation(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("benchmark assertion")
+	}
+	result := testing.Benchmark(func(b *testing.B) {
+		benchmarkEmit(b, ssevents.EmitStrategyDrop, 1000, 0)
+	})
+	if allocs := result.AllocsPerOp(); allocs > 1 {
+		t.Fatalf("expected at most 1 allocation per emit, got %d (%d B)", allocs, result.AllocedBytesPerOp())
+	}
+}
+
+func BenchmarkEmitFanoutWorkers(b *testing.B) {
+	for _, subscribers := range []int{100, 1000, 10000} {
+		b.Run(strconv.Itoa(subscribers), func(b *testing.B) {
+			benchmarkEmit(b, ssevents.EmitStrategyDrop, subscribers, 4)
+		})
+	}
+}
+
+// benchmarkEvents are encoded by the encoding benchmarks, plain and with a propagated trace context
+var benchmarkEvents = map[string]ssevents.Event{
+	"Plain": {Id: "1", Event: "update", Data: "payload", Retry: 500},
+	"Extensions": {
+		Id:         "1",
+		Event:      "update",
+		Data:       "payload",
+		Extensions: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	},
+}
+
+func BenchmarkEventToResponseString(b *testing.B) {
+	for name, evt := range benchmarkEvents {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := evt.ToResponseString(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEventWriteTo(b *testing.B) {
+	for name, evt := range benchmarkEvents {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := evt.WriteTo(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEventString(b *testing.B) {
+	for name, evt := range benchmarkEvents {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = evt.String()
+			}
+		})
+	}
+}
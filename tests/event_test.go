@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/doppelganger113/ssevents"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_givenEvent_whenToResponseString_thenWireFormat(t *testing.T) {
+	evt := ssevents.Event{
+		Id:         "1",
+		Event:      "update",
+		Data:       "payload",
+		Retry:      500,
+		Extensions: map[string]string{"traceparent": "00-abc-def-01", "data": "skipped"},
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := evt.ToResponseString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "event: update\ndata: payload\nid: 1\nretry: 500\ntraceparent: 00-abc-def-01\n\n\n"
+		if result != expected {
+			t.Fatalf("expected %q got %q", expected, result)
+		}
+
+		var written bytes.Buffer
+		if n, err := evt.WriteTo(&written); err != nil || n != int64(len(expected)) || written.String() != expected {
+			t.Fatalf("expected WriteTo to write %q, got %q (%d): %v", expected, written.String(), n, err)
+		}
+	}
+}
+
+func Test_givenRedactEvent_whenEventLogged_thenDataRedacted(t *testing.T) {
+	var logs bytes.Buffer
+	ctrl := ssevents.NewController(&ssevents.Options{
+		Logger:       slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		EmitStrategy: ssevents.EmitStrategyDrop,
+		RedactEvent:  ssevents.RedactEventData,
+	})
+	defer func() { _ = ctrl.Shutdown() }()
+	// The unbuffered and never read channel makes the event dropped, logging it twice
+	ctrl.Store("subscriber", make(chan ssevents.Event))
+
+	ctrl.Emit(ssevents.Event{Id: "1", Event: "signup", Data: "alice@example.com"})
+
+	if strings.Contains(logs.String(), "alice@example.com") {
+		t.Fatalf("expected data to be redacted from the logs:\n%s", logs.String())
+	}
+	if strings.Count(logs.String(), "[REDACTED]") != 2 {
+		t.Fatalf("expected the dropped and emitted logs with redacted data:\n%s", logs.String())
+	}
+}
+
+func Test_givenValue_whenNewJSONEvent_thenDataMarshalled(t *testing.T) {
+	evt, err := ssevents.NewJSONEvent("order", map[string]any{"id": "o-1", "total": 9.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Event != "order" || evt.Data != `{"id":"o-1","total":9.5}` {
+		t.Fatalf("unexpected event %s", evt)
+	}
+
+	if _, err = ssevents.NewJSONEvent("invalid", make(chan int)); err == nil {
+		t.Fatal("expected an error for a value that can not be marshalled")
+	}
+}
+
+func Test_givenAutoEventID_whenEmitWithoutID_thenSequentialIDAssigned(t *testing.T) {
+	ctrl := ssevents.NewController(&ssevents.Options{
+		Logger:       errorLogger(),
+		EmitStrategy: ssevents.EmitStrategyDrop,
+		BufferSize:   10,
+		AutoEventID:  ssevents.SequentialEventIDs(),
+	})
+	defer func() { _ = ctrl.Shutdown() }()
+	subscriber := make(chan ssevents.Event, 10)
+	ctrl.Store("subscriber", subscriber)
+
+	ctrl.Emit(ssevents.Event{Data: "first"})
+	ctrl.Emit(ssevents.Event{Id: "custom", Data: "second"})
+	ctrl.Emit(ssevents.Event{Data: "third"})
+
+	for _, expected := range []string{"1", "custom", "2"} {
+		if evt := <-subscriber; evt.Id != expected {
+			t.Fatalf("expected id %s, got %s", expected, evt)
+		}
+	}
+}
+
+func Test_givenRandomEventID_whenGenerated_thenUniqueUUIDs(t *testing.T) {
+	first, second := ssevents.RandomEventID(), ssevents.RandomEventID()
+	if len(first) != 36 || first[14] != '4' || first == second {
+		t.Fatalf("expected unique version 4 UUIDs, got %s and %s", first, second)
+	}
+}
+
+func Test_givenLineBreaksInNameAndID_whenWritten_thenSingleFrameWithoutInjectedFields(t *testing.T) {
+	evt := ssevents.Event{Event: "update\ndata: injected", Id: "1\r\n", Data: "line 1\r\nline 2"}
+	if err := evt.Validate(); !errors.Is(err, ssevents.ErrInvalidEvent) {
+		t.Fatalf("expected ErrInvalidEvent, got %v", err)
+	}
+	if err := (ssevents.Event{Event: "update", Id: "1", Data: "line 1\nline 2"}).Validate(); err != nil {
+		t.Fatalf("expected multi-line data to be valid, got %v", err)
+	}
+
+	var written bytes.Buffer
+	if _, err := evt.WriteTo(&written); err != nil {
+		t.Fatal(err)
+	}
+	decoder := ssevents.NewDecoder(&written)
+	decoded, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Event != "updatedata: injected" || decoded.Id != "1" || decoded.Data != "line 1\nline 2" {
+		t.Fatalf("expected the line breaks of the name and id removed, got %q", decoded)
+	}
+	if next, err := decoder.Decode(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected a single frame, got %q: %v", next, err)
+	}
+}
+
+func Test_givenEventMeta_whenWrittenAndDecoded_thenMetaPreserved(t *testing.T) {
+	evt := ssevents.Event{
+		Event: "order-created",
+		Data:  "1",
+		Meta:  map[string]string{"tenant-id": "a", "note:multi": "line 1\nline 2"},
+	}
+	var written bytes.Buffer
+	if _, err := evt.WriteTo(&written); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(written.String(), `meta: {"note:multi":"line 1\nline 2","tenant-id":"a"}`+"\n") {
+		t.Fatalf("expected the meta as a single JSON field, got %q", written.String())
+	}
+
+	decoded, err := ssevents.NewDecoder(&written).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != evt.String() || decoded.Meta["note:multi"] != "line 1\nline 2" {
+		t.Fatalf("expected %s, got %s", evt, decoded)
+	}
+}
+
+func Test_givenBinaryPayload_whenEmittedToClient_thenBinaryDecoded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("frame").First().Build())
+	client.Start()
+
+	payload := []byte{0x00, 0xff, '\n', '\r', 'a', 0x80}
+	evt := ssevents.Event{Event: "frame"}
+	evt.SetBinary(payload)
+	server.Emit(evt)
+
+	received, err := observer.WaitForN(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary, err := received[0].Binary(); err != nil || !bytes.Equal(binary, payload) {
+		t.Fatalf("expected the payload %v, got %v: %v", payload, binary, err)
+	}
+	if plain, _ := (ssevents.Event{Data: "text"}).Binary(); string(plain) != "text" {
+		t.Fatalf("expected the data of an unmarked event, got %q", plain)
+	}
+}
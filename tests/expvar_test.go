@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenExpvarName_whenEventsDelivered_thenCountersPublished(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), ExpvarName: "test_expvar_server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:     errorLogger(),
+		Transport:  ssevents.NewHandlerTransport(server.Handler()),
+		ExpvarName: "test_expvar_client",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("counted").First().Build())
+	client.Start()
+	for server.SubscriberCount() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("expected the client to connect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	server.Emit(ssevents.Event{Event: "counted", Data: "x"})
+	if _, err = observer.WaitForN(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	res := httptest.NewRecorder()
+	server.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected /debug/vars to be mounted, got %d", res.Code)
+	}
+	var vars struct {
+		Server map[string]int64 `json:"test_expvar_server"`
+	}
+	if err = json.Unmarshal(res.Body.Bytes(), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if vars.Server["subscribers"] != 1 || vars.Server["emitted"] != 1 {
+		t.Fatalf("expected a subscriber and an emitted event, got %v", vars.Server)
+	}
+
+	clientVars, ok := expvar.Get("test_expvar_client").(*expvar.Map)
+	if !ok {
+		t.Fatal("expected the client counters to be published")
+	}
+	if connected := clientVars.Get("connected").(*expvar.Int).Value(); connected != 1 {
+		t.Fatalf("expected the client connected, got %d", connected)
+	}
+	// The heartbeat and the emitted event
+	if received := clientVars.Get("received").(*expvar.Int).Value(); received < 2 {
+		t.Fatalf("expected the received events counted, got %d", received)
+	}
+}
+
+func Test_givenExpvarNameTakenByAnotherVar_whenCreating_thenErrExpvarNameTaken(t *testing.T) {
+	expvar.NewString("test_expvar_taken").Set("taken")
+
+	_, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), ExpvarName: "test_expvar_taken"})
+	if !errors.Is(err, ssevents.ErrExpvarNameTaken) {
+		t.Fatalf("expected the server to fail with ErrExpvarNameTaken, got %v", err)
+	}
+	_, err = ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:     errorLogger(),
+		ExpvarName: "test_expvar_taken",
+	})
+	if !errors.Is(err, ssevents.ErrExpvarNameTaken) {
+		t.Fatalf("expected the client to fail with ErrExpvarNameTaken, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/doppelganger113/ssevents"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_givenFanoutWorkers_whenEmit_thenAllSubscribersReceiveInOrder(t *testing.T) {
+	const subscribers = 1000
+	const events = 5
+
+	ctrl := ssevents.NewController(&ssevents.Options{
+		Logger:          errorLogger(),
+		FanoutWorkers:   4,
+		FanoutBatchSize: 10,
+		BufferSize:      events,
+	})
+	defer func() { _ = ctrl.Shutdown() }()
+
+	channels := make([]chan ssevents.Event, subscribers)
+	for i := range channels {
+		channels[i] = make(chan ssevents.Event, events)
+		ctrl.Store(i, channels[i])
+	}
+
+	for i := 0; i < events; i++ {
+		ctrl.Emit(ssevents.Event{Id: strconv.Itoa(i), Data: "payload"})
+	}
+
+	for i, ch := range channels {
+		for expected := 0; expected < events; expected++ {
+			select {
+			case evt := <-ch:
+				if evt.Id != strconv.Itoa(expected) {
+					t.Fatalf("subscriber %d expected event %d got %s", i, expected, evt.Id)
+				}
+			default:
+				t.Fatalf("subscriber %d missing event %d", i, expected)
+			}
+		}
+	}
+}
+
+func Test_givenRegistryShards_whenStoringAndDeletingConcurrently_thenEmitReachesRemainingSubscribers(t *testing.T) {
+	const subscribers = 1000
+
+	ctrl := ssevents.NewController(&ssevents.Options{
+		Logger:          errorLogger(),
+		FanoutWorkers:   4,
+		FanoutBatchSize: 10,
+		RegistryShards:  8,
+		EmitStrategy:    ssevents.EmitStrategyDrop,
+	})
+	defer func() { _ = ctrl.Shutdown() }()
+
+	channels := make([]chan ssevents.Event, subscribers)
+	var wg sync.WaitGroup
+	for i := range channels {
+		channels[i] = make(chan ssevents.Event, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := "subscriber-" + strconv.Itoa(i)
+			ctrl.Store(key, channels[i])
+			if i%2 == 1 {
+				ctrl.Delete(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ctrl.Emit(ssevents.Event{Data: "payload"})
+
+	for i, ch := range channels {
+		if has := ctrl.HasSubscriber("subscriber-" + strconv.Itoa(i)); has != (i%2 == 0) {
+			t.Fatalf("subscriber %d expected registered %t", i, i%2 == 0)
+		}
+		if received := len(ch) == 1; received != (i%2 == 0) {
+			t.Fatalf("subscriber %d expected received %t", i, i%2 == 0)
+		}
+	}
+}
+
+func Test_givenBlockedSubscriber_whenEmitSync_thenReportsDeliveredAndDropped(t *testing.T) {
+	ctrl := ssevents.NewController(&ssevents.Options{Logger: errorLogger()})
+	defer func() { _ = ctrl.Shutdown() }()
+
+	ready := make(chan ssevents.Event, 1)
+	ctrl.Store("ready", ready)
+	// Nobody reads the unbuffered channel, the delivery blocks until the ctx expires
+	ctrl.Store("blocked", make(chan ssevents.Event))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	delivered, dropped, err := ctrl.EmitSync(ctx, ssevents.Event{Data: "payload"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if delivered != 1 || dropped != 1 {
+		t.Fatalf("expected 1 delivered and 1 dropped, got %d and %d", delivered, dropped)
+	}
+	if evt := <-ready; evt.Data != "payload" {
+		t.Fatalf("expected the event delivered, got %s", evt)
+	}
+}
+
+func Test_givenSlowFilter_whenEmit_thenSubscribersDeletedMeanwhile(t *testing.T) {
+	ctrl := ssevents.NewController(&ssevents.Options{Logger: errorLogger()})
+	defer func() { _ = ctrl.Shutdown() }()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	ctrl.StoreFiltered("slow", make(chan ssevents.Event, 1), func(ssevents.Event) bool {
+		once.Do(func() { close(entered) })
+		<-release
+		return true
+	})
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		ctrl.Emit(ssevents.Event{Data: "filtered"})
+	}()
+	<-entered
+
+	// Deleting locks the shard of the subscriber whose filter is running
+	deleted := make(chan struct{})
+	go func() {
+		defer close(deleted)
+		ctrl.Delete("slow")
+	}()
+	select {
+	case <-deleted:
+	case <-time.After(time.Second):
+		t.Fatal("expected the filter not to hold the lock of the registry")
+	}
+	close(release)
+	<-emitted
+	if ctrl.HasSubscriber("slow") {
+		t.Fatal("expected the subscriber to be deleted")
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenFaults_whenConnecting_thenConnectionClosedWithInjectedFault(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		faults ssevents.Faults
+	}{
+		{name: "disconnect", faults: ssevents.Faults{DisconnectRate: 1}},
+		{name: "truncate", faults: ssevents.Faults{TruncateRate: 1, WriteLatency: 10 * time.Millisecond}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			server, err := ssevents.NewServer(&ssevents.Options{
+				Logger:         errorLogger(),
+				Faults:         &tc.faults,
+				HeartbeatEvent: func() *ssevents.Event { return &ssevents.Event{Event: "heartbeat", Data: "ping"} },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = server.Shutdown(ctx) }()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://ssevents.test/sse", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := ssevents.NewHandlerTransport(server.Handler()).RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = res.Body.Close() }()
+
+			// The connection is closed by the first write, the initial heartbeat
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			heartbeat := "event: heartbeat\ndata: ping\n\n"
+			if len(body) == len(heartbeat) || !strings.HasPrefix(heartbeat, string(body)) {
+				t.Fatalf("expected at most a truncated heartbeat, got %q", body)
+			}
+		})
+	}
+}
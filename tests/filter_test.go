@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenSubscriberFilter_whenEmit_thenSendOnlyAcceptedEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:           errorLogger(),
+		BufferSize:       10,
+		SubscriberFilter: ssevents.FilterEventsFromQuery,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse?event=probe&event=created", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+
+	// Probe until the subscriber is registered
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for registered := false; !registered; {
+		select {
+		case evt := <-out:
+			registered = evt.Event == "probe"
+		case <-ticker.C:
+			server.Emit(ssevents.Event{Event: "probe", Data: "probe"})
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the subscriber")
+		}
+	}
+
+	server.Emit(ssevents.Event{Event: "deleted", Data: "deleted"})
+	server.Emit(ssevents.Event{Event: "created", Data: "created"})
+
+	for {
+		select {
+		case evt := <-out:
+			switch evt.Event {
+			case "heartbeat", "probe":
+				continue
+			case "created":
+				return
+			default:
+				t.Fatalf("expected only the created event, got %s", evt)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the created event")
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/graphqlsse"
+)
+
+func Test_givenGraphQLSubscription_whenSubscribing_thenReceiveAllResultsAndComplete(t *testing.T) {
+	subscribe := func(ctx context.Context, req graphqlsse.Request) (<-chan any, error) {
+		results := make(chan any)
+		go func() {
+			defer close(results)
+			for i := 0; i < 3; i++ {
+				select {
+				case results <- map[string]any{"data": map[string]any{"count": i}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return results, nil
+	}
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Handlers: graphqlsse.NewServer(subscribe, nil).Handlers("/graphql/stream"),
+		Logger:   errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	defer func() {
+		_ = server.Shutdown(ctx)
+	}()
+
+	var results []graphqlsse.Result
+	client := graphqlsse.NewClient(url+"/graphql/stream", http.DefaultClient)
+	err = client.Subscribe(ctx, graphqlsse.Request{Query: "subscription { count }"}, func(result graphqlsse.Result) {
+		results = append(results, result)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Errorf("expected 3 results, got %d", len(results))
+	}
+}
+
+func Test_givenCompleteEvent_whenSubscribing_thenReturnBeforeStreamCloses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: next\ndata: {\"data\":{\"count\":1}}\n\nevent: complete\ndata: \n\n")
+		w.(http.Flusher).Flush()
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var results []graphqlsse.Result
+	client := graphqlsse.NewClient(server.URL, http.DefaultClient)
+	err := client.Subscribe(ctx, graphqlsse.Request{Query: "subscription { count }"}, func(result graphqlsse.Result) {
+		results = append(results, result)
+	})
+	if err != nil {
+		t.Fatalf("expected the complete event to end the subscription, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 result, got %d", len(results))
+	}
+}
+
+func Test_givenStreamClosedWithoutComplete_whenSubscribing_thenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: next\ndata: {\"data\":{\"count\":1}}\n\n")
+	}))
+	defer server.Close()
+
+	client := graphqlsse.NewClient(server.URL, http.DefaultClient)
+	operation := graphqlsse.Request{Query: "subscription { count }"}
+	if err := client.Subscribe(context.Background(), operation, func(graphqlsse.Result) {}); err == nil {
+		t.Error("expected an error for a stream closed before the complete event")
+	}
+}
+
+func newGraphQLTestServer(t *testing.T, options *graphqlsse.ServerOptions) *httptest.Server {
+	t.Helper()
+	subscribe := func(ctx context.Context, req graphqlsse.Request) (<-chan any, error) {
+		results := make(chan any)
+		go func() {
+			<-ctx.Done()
+			close(results)
+		}()
+		return results, nil
+	}
+	mux := http.NewServeMux()
+	for pattern, handler := range graphqlsse.NewServer(subscribe, options).Handlers("/graphql/stream") {
+		mux.HandleFunc(pattern, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func reserveGraphQLStream(t *testing.T, url string) (string, int) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, url+"/graphql/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(token), resp.StatusCode
+}
+
+func Test_givenExpiredReservation_whenOpeningStream_thenNotFound(t *testing.T) {
+	server := newGraphQLTestServer(t, &graphqlsse.ServerOptions{ReservationTTL: 20 * time.Millisecond})
+
+	token, status := reserveGraphQLStream(t, server.URL)
+	if status != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, status)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(server.URL + "/graphql/stream?token=" + token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func Test_givenMaxReservations_whenReserving_thenServiceUnavailable(t *testing.T) {
+	server := newGraphQLTestServer(t, &graphqlsse.ServerOptions{MaxReservations: 1})
+
+	if _, status := reserveGraphQLStream(t, server.URL); status != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, status)
+	}
+	if _, status := reserveGraphQLStream(t, server.URL); status != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, status)
+	}
+}
+
+func Test_givenReservedStreamNotOpen_whenPostingOperation_thenConflict(t *testing.T) {
+	server := newGraphQLTestServer(t, nil)
+
+	token, _ := reserveGraphQLStream(t, server.URL)
+	body := `{"query":"subscription { count }","extensions":{"operationId":"1"}}`
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql/stream", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(graphqlsse.HeaderToken, token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
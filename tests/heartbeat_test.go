@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"github.com/doppelganger113/ssevents"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_givenConnections_whenHeartbeatIntervalPasses_thenEachReceivesHeartbeats(t *testing.T) {
+	const connections = 3
+	const interval = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), HeartbeatInterval: interval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	outs := make([]chan ssevents.Event, connections)
+	for i := range outs {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		res, resErr := http.DefaultClient.Do(req)
+		if resErr != nil {
+			t.Fatal(resErr)
+		}
+		defer func() { _ = res.Body.Close() }()
+
+		outs[i] = make(chan ssevents.Event, 10)
+		go func(out chan ssevents.Event) { _ = ssevents.ReadEvents(ctx, res.Body, out) }(outs[i])
+	}
+
+	started := time.Now()
+	for i, out := range outs {
+		// The initial heartbeat followed by two from the interval
+		for heartbeats := 0; heartbeats < 3; heartbeats++ {
+			select {
+			case evt := <-out:
+				if evt.Event != "heartbeat" {
+					t.Fatalf("connection %d expected heartbeat got %s", i, evt)
+				}
+			case <-ctx.Done():
+				t.Fatalf("connection %d received only %d heartbeats", i, heartbeats)
+			}
+		}
+	}
+	if elapsed := time.Since(started); elapsed > 10*interval {
+		t.Fatalf("heartbeats took %s for an interval of %s", elapsed, interval)
+	}
+}
+
+func Test_givenHeartbeatComment_whenHeartbeatIntervalPasses_thenCommentsInsteadOfEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:            errorLogger(),
+		HeartbeatInterval: 20 * time.Millisecond,
+		HeartbeatComment:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	comments := make(chan string, 100)
+	decoder := ssevents.NewDecoder(res.Body)
+	decoder.OnComment = func(comment string) { comments <- comment }
+	events := make(chan ssevents.Event, 10)
+	go func() {
+		for {
+			evt, decodeErr := decoder.Decode()
+			if decodeErr != nil {
+				return
+			}
+			events <- evt
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case comment := <-comments:
+			if comment != "ping" {
+				t.Fatalf("expected ping comment, got %q", comment)
+			}
+		case evt := <-events:
+			t.Fatalf("expected only comments, got event %s", evt)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for heartbeat comments")
+		}
+	}
+}
+
+func Test_givenHeartbeatEvent_whenConnected_thenCustomHeartbeatSent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(),
+		HeartbeatEvent: func() *ssevents.Event {
+			return &ssevents.Event{Event: "keepalive"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	evt, err := ssevents.NewDecoder(res.Body).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Event != "keepalive" || evt.Data != "" {
+		t.Fatalf("expected the custom heartbeat, got %s", evt)
+	}
+}
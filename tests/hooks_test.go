@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenOnConnect_whenConnecting_thenConnInfoCarriesRequestHeaders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	connected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:    errorLogger(),
+		OnConnect: func(info ssevents.ConnInfo) { connected <- info },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "hooks-test")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	select {
+	case info := <-connected:
+		if info.ID == "" || info.RemoteAddr == "" || info.Header.Get("User-Agent") != "hooks-test" {
+			t.Fatalf("expected the connection id, remote address and headers, got %+v", info)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for OnConnect")
+	}
+}
+
+func Test_givenDropStrategy_whenSubscriberIsFull_thenOnEmitErrorReceivesDroppedEvent(t *testing.T) {
+	failed := make(chan error, 1)
+	var failedEvent ssevents.Event
+	ctrl := ssevents.NewController(&ssevents.Options{
+		Logger:       errorLogger(),
+		EmitStrategy: ssevents.EmitStrategyDrop,
+		OnEmitError: func(_ ssevents.ConnInfo, e ssevents.Event, err error) {
+			failedEvent = e
+			failed <- err
+		},
+	})
+	defer func() { _ = ctrl.Shutdown() }()
+
+	ctrl.Store("full", make(chan ssevents.Event))
+	ctrl.Emit(ssevents.Event{Data: "dropped"})
+
+	select {
+	case err := <-failed:
+		if !errors.Is(err, ssevents.ErrEventDropped) || failedEvent.Data != "dropped" {
+			t.Fatalf("expected the dropped event with ErrEventDropped, got %s: %v", failedEvent, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnEmitError")
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"golang.org/x/net/http2"
+)
+
+// expectHTTP2Stream connects through the transport and expects an HTTP/2 connection receiving flushed events
+func expectHTTP2Stream(t *testing.T, server *ssevents.Server, url string, transport http.RoundTripper) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", res.Proto)
+	}
+
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+	// Every event is flushed as it is sent, the stream does not wait for more data
+	for _, expected := range []string{"heartbeat", "flushed"} {
+		if expected == "flushed" {
+			server.Emit(ssevents.Event{Event: "flushed", Data: "over http/2"})
+		}
+		select {
+		case evt := <-out:
+			if evt.Event != expected {
+				t.Fatalf("expected %s event, got %s", expected, evt)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the %s event", expected)
+		}
+	}
+}
+
+func Test_givenTLSServer_whenConnectingOverHTTP2_thenStreamEventsAndReportProtocol(t *testing.T) {
+	serverCert := issueCert(t, "localhost", nil, true)
+	connected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:    errorLogger(),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}},
+		OnConnect: func(info ssevents.ConnInfo) { connected <- info },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(serverCert.Leaf)
+	expectHTTP2Stream(t, server, url, &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool},
+		ForceAttemptHTTP2: true,
+	})
+	if info := <-connected; info.Protocol != "HTTP/2.0" {
+		t.Fatalf("expected the HTTP/2.0 protocol in the connection info, got %q", info.Protocol)
+	}
+}
+
+func Test_givenEnableH2C_whenConnectingWithoutTLS_thenStreamOverHTTP2(t *testing.T) {
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), EnableH2C: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	expectHTTP2Stream(t, server, url, &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	})
+}
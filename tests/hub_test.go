@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenHubSubscribers_whenPublish_thenDeliverToAllUntilUnsubscribed(t *testing.T) {
+	hub := ssevents.NewHub(&ssevents.Options{Logger: errorLogger(), BufferSize: 10})
+	defer hub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, unsubscribe := hub.Subscribe(context.Background())
+	second, _ := hub.Subscribe(ctx)
+	if hub.Subscribers() != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", hub.Subscribers())
+	}
+
+	if delivered := hub.Publish(ssevents.Event{Data: "to both"}); delivered != 2 {
+		t.Fatalf("expected the event to be delivered to 2 subscribers, got %d", delivered)
+	}
+	for _, ch := range []<-chan ssevents.Event{first, second} {
+		if evt := <-ch; evt.Data != "to both" {
+			t.Fatalf("unexpected event %s", evt)
+		}
+	}
+
+	unsubscribe()
+	cancel()
+	// The context cancellation unsubscribes asynchronously
+	for hub.Subscribers() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if delivered := hub.Publish(ssevents.Event{Data: "to none"}); delivered != 0 {
+		t.Fatalf("expected no subscribers, got %d deliveries", delivered)
+	}
+}
+
+func Test_givenCancelledContext_whenSubscribing_thenNotLeftSubscribed(t *testing.T) {
+	hub := ssevents.NewHub(&ssevents.Options{Logger: errorLogger()})
+	defer hub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		hub.Subscribe(ctx)
+	}
+	deadline := time.Now().Add(time.Second)
+	for hub.Subscribers() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if count := hub.Subscribers(); count != 0 {
+		t.Fatalf("expected no subscribers, got %d", count)
+	}
+}
+
+func Test_givenBlockingHub_whenSubscriberCancelsWhileFull_thenPublishUnblocked(t *testing.T) {
+	hub := ssevents.NewHub(&ssevents.Options{Logger: errorLogger(), EmitStrategy: ssevents.EmitStrategyBlock})
+	defer hub.Close()
+
+	_, unsubscribe := hub.Subscribe(context.Background())
+	hub.Publish(ssevents.Event{Data: "fills the buffer"})
+
+	published := make(chan int)
+	go func() { published <- hub.Publish(ssevents.Event{Data: "blocks"}) }()
+	unsubscribe()
+	if delivered := <-published; delivered > 1 {
+		t.Fatalf("expected at most 1 delivery, got %d", delivered)
+	}
+}
+
+func Test_givenDropOldestStrategy_whenSubscriberFallsBehind_thenKeepLatestEvents(t *testing.T) {
+	hub := ssevents.NewHub(&ssevents.Options{
+		Logger:       errorLogger(),
+		BufferSize:   2,
+		EmitStrategy: ssevents.EmitStrategyDropOldest,
+	})
+	defer hub.Close()
+	events, unsubscribe := hub.Subscribe(context.Background())
+	defer unsubscribe()
+
+	for i := 1; i <= 4; i++ {
+		if delivered := hub.Publish(ssevents.Event{Id: strconv.Itoa(i)}); delivered != 1 {
+			t.Fatalf("expected event %d delivered, got %d", i, delivered)
+		}
+	}
+	for _, expected := range []string{"3", "4"} {
+		if evt := <-events; evt.Id != expected {
+			t.Fatalf("expected event %s, got %s", expected, evt)
+		}
+	}
+}
+
+func Test_givenDisconnectStrategy_whenClientStopsReading_thenConnectionClosed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	connected := make(chan ssevents.ConnInfo, 1)
+	disconnected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:       errorLogger(),
+		EmitStrategy: ssevents.EmitStrategyDisconnect,
+		OnConnect:    func(info ssevents.ConnInfo) { connected <- info },
+		OnDisconnect: func(info ssevents.ConnInfo) { disconnected <- info },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The body is never read, so the socket buffers fill up and the subscriber falls behind
+	defer func() { _ = res.Body.Close() }()
+	info := <-connected
+
+	payload := strings.Repeat("x", 64*1024)
+	for i := 0; i < 1000; i++ {
+		server.Emit(ssevents.Event{Data: payload})
+		if depths := server.QueueDepths(); i > 0 && len(depths) == 0 {
+			break
+		}
+	}
+
+	select {
+	case closed := <-disconnected:
+		if closed.ID != info.ID {
+			t.Fatalf("expected connection %s disconnected, got %s", info.ID, closed.ID)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the slow connection to be disconnected")
+	}
+}
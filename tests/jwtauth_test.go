@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// startAuthenticatedServer starts a server requiring tokens verified by auth, connections are reported on connected
+func startAuthenticatedServer(t *testing.T, auth *jwtauth.Authenticator) (string, <-chan ssevents.ConnInfo) {
+	t.Helper()
+	connected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:       errorLogger(),
+		Authenticate: auth.Authenticate,
+		OnConnect: func(info ssevents.ConnInfo) {
+			connected <- info
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Shutdown(context.Background()) })
+
+	return url, connected
+}
+
+func connectStatus(t *testing.T, url string, header http.Header) int {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header != nil {
+		req.Header = header
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	return res.StatusCode
+}
+
+func Test_givenJWTAuth_whenConnecting_thenRejectInvalidAndExposeClaims(t *testing.T) {
+	key := []byte("secret")
+	auth, err := jwtauth.New(jwtauth.Options{Key: key, Issuer: "tests"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, connected := startAuthenticatedServer(t, auth)
+
+	if status := connectStatus(t, url+"/sse", nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token got %d", status)
+	}
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "iss": "tests"}).
+		SignedString([]byte("other"))
+	if status := connectStatus(t, url+"/sse?access_token="+forged, nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with forged token got %d", status)
+	}
+
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  "alice",
+		"iss":  "tests",
+		"exp":  time.Now().Add(time.Minute).Unix(),
+		"role": "admin",
+	}).SignedString(key)
+	if status := connectStatus(t, url+"/sse?access_token="+token, nil); status != http.StatusOK {
+		t.Fatalf("expected 200 with valid token got %d", status)
+	}
+
+	info := <-connected
+	if info.UserID != "alice" || info.Claims["role"] != "admin" {
+		t.Fatalf("expected claims of alice, got user %q and claims %v", info.UserID, info.Claims)
+	}
+}
+
+func Test_givenJWKS_whenConnectingWithBearerToken_thenVerifyWithFetchedKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}}})
+	}))
+	defer jwksServer.Close()
+
+	auth, err := jwtauth.New(jwtauth.Options{JWKSURL: jwksServer.URL, Audience: "sse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, connected := startAuthenticatedServer(t, auth)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "bob", "aud": "sse"})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := connectStatus(t, url+"/sse", http.Header{"Authorization": []string{"Bearer " + signed}})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 with valid token got %d", status)
+	}
+	if info := <-connected; info.UserID != "bob" {
+		t.Fatalf("expected user bob got %q", info.UserID)
+	}
+}
+
+func Test_givenAuthenticateEmit_whenCallerRejected_thenRespondUnauthorizedOrForbidden(t *testing.T) {
+	authenticate := func(req *http.Request) (map[string]any, error) {
+		switch req.Header.Get("Authorization") {
+		case "Bearer admin":
+			return map[string]any{"sub": "admin"}, nil
+		case "Bearer viewer":
+			return nil, fmt.Errorf("viewer can not emit: %w", ssevents.ErrForbidden)
+		default:
+			return nil, jwtauth.ErrMissingToken
+		}
+	}
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), AuthenticateEmit: authenticate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	for token, expected := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"viewer": http.StatusForbidden,
+		"admin":  http.StatusOK,
+	} {
+		req, reqErr := http.NewRequest(http.MethodPost, url+"/emit", strings.NewReader("hello"))
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, reqErr := http.DefaultClient.Do(req)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != expected {
+			t.Fatalf("expected %d for token %q, got %d", expected, token, res.StatusCode)
+		}
+	}
+}
+
+func Test_givenFailingJWKS_whenAuthenticatingConcurrently_thenFetchOnceAndBackOff(t *testing.T) {
+	var fetches atomic.Int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer jwksServer.Close()
+
+	auth, err := jwtauth.New(jwtauth.Options{JWKSURL: jwksServer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "bob"})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticate := func() error {
+		req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+		req.Header.Set("Authorization", "Bearer "+signed)
+		_, authErr := auth.Authenticate(req)
+		return authErr
+	}
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if authErr := authenticate(); authErr == nil {
+				t.Error("expected token to be rejected without keys")
+			}
+		}()
+	}
+	wg.Wait()
+	if authErr := authenticate(); authErr == nil {
+		t.Fatal("expected token to be rejected while backing off")
+	}
+
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("expected a single JWKS fetch got %d", got)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/kafkasource"
+)
+
+type fakeConsumer struct {
+	mu        sync.Mutex
+	batches   [][]kafkasource.Record
+	committed []kafkasource.Record
+}
+
+func (c *fakeConsumer) Poll(ctx context.Context) ([]kafkasource.Record, error) {
+	c.mu.Lock()
+	if len(c.batches) == 0 {
+		c.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	batch := c.batches[0]
+	c.batches = c.batches[1:]
+	c.mu.Unlock()
+	return batch, nil
+}
+
+func (c *fakeConsumer) Commit(_ context.Context, records []kafkasource.Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed = append(c.committed, records...)
+	return nil
+}
+
+func Test_givenKafkaRecords_whenRun_thenEmittedWithOffsetIDsAndCommitted(t *testing.T) {
+	consumer := &fakeConsumer{batches: [][]kafkasource.Record{{
+		{Topic: "orders", Partition: 1, Offset: 41, Value: []byte(`{"id":1}`)},
+		{
+			Topic: "orders", Partition: 1, Offset: 42, Value: []byte(`{"id":2}`),
+			Headers: map[string]string{"event": "order-created"},
+		},
+	}}}
+	source, err := kafkasource.New(kafkasource.Options{Consumer: consumer, Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	emitted := make(chan ssevents.Event, 2)
+	go func() {
+		_ = source.Run(ctx, func(e ssevents.Event) { emitted <- e })
+	}()
+
+	expected := []ssevents.Event{
+		{Id: "orders/1/41", Event: "orders", Data: `{"id":1}`},
+		{Id: "orders/1/42", Event: "order-created", Data: `{"id":2}`},
+	}
+	for _, want := range expected {
+		select {
+		case got := <-emitted:
+			if got.Id != want.Id || got.Event != want.Event || got.Data != want.Data {
+				t.Fatalf("expected %+v, got %+v", want, got)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the records")
+		}
+	}
+
+	topic, partition, offset, err := kafkasource.ParseEventID("orders/1/42")
+	if err != nil || topic != "orders" || partition != 1 || offset != 42 {
+		t.Fatalf("expected orders/1/42, got %s/%d/%d: %v", topic, partition, offset, err)
+	}
+	if _, _, _, err = kafkasource.ParseEventID("orders-42"); err == nil {
+		t.Fatal("expected an invalid event id error")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		consumer.mu.Lock()
+		committed := len(consumer.committed)
+		consumer.mu.Unlock()
+		if committed == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 committed records, got %d", committed)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/kubewatch"
+)
+
+func Test_givenKubernetesWatch_whenNotificationsReceived_thenEmitAndResumeFromLastVersion(t *testing.T) {
+	var mu sync.Mutex
+	var versions []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		versions = append(versions, req.URL.Query().Get("resourceVersion"))
+		first := len(versions) == 1
+		mu.Unlock()
+		if !first || req.URL.Query().Get("labelSelector") != "app=web" {
+			<-req.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, `{"type":"ADDED","object":{"metadata":{"name":"web-1","resourceVersion":"10"}}}`)
+		_, _ = fmt.Fprintln(w, `{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"11"}}}`)
+		_, _ = fmt.Fprintln(w, `{"type":"DELETED","object":{"metadata":{"name":"web-1","resourceVersion":"12"}}}`)
+	}))
+	defer apiServer.Close()
+
+	source, err := kubewatch.New(kubewatch.Options{
+		Server:        apiServer.URL,
+		Resources:     []string{"/api/v1/namespaces/default/pods"},
+		LabelSelector: "app=web",
+		RetryDelay:    10 * time.Millisecond,
+		Logger:        errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events := make(chan ssevents.Event, 10)
+	go func() {
+		_ = source.Run(ctx, func(e ssevents.Event) { events <- e })
+	}()
+
+	expectedEvents := []ssevents.Event{{Id: "10", Event: kubewatch.EventAdded}, {Id: "12", Event: kubewatch.EventDeleted}}
+	for _, expected := range expectedEvents {
+		select {
+		case e := <-events:
+			if e.Id != expected.Id || e.Event != expected.Event {
+				t.Fatalf("expected %s %s got %s %s", expected.Event, expected.Id, e.Event, e.Id)
+			}
+		case <-ctx.Done():
+			t.Fatalf("expected %s event", expected.Event)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		got := append([]string(nil), versions...)
+		mu.Unlock()
+		if len(got) >= 2 {
+			if got[0] != "" || got[1] != "12" {
+				t.Fatalf("expected the watch to resume from version 12 got %v", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the watch to be re-established got %v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_givenExpiredResourceVersion_whenWatching_thenBackOff(t *testing.T) {
+	var watches atomic.Int32
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		watches.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer apiServer.Close()
+
+	source, err := kubewatch.New(kubewatch.Options{
+		Server:        apiServer.URL,
+		Resources:     []string{"/api/v1/pods"},
+		RetryDelay:    20 * time.Millisecond,
+		MaxRetryDelay: 200 * time.Millisecond,
+		Logger:        errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_ = source.Run(ctx, func(ssevents.Event) {})
+
+	// 20, 40, 80 and 160ms delays fit into the 300ms with jitter, relisting without a delay would take thousands
+	if got := watches.Load(); got < 2 || got > 8 {
+		t.Fatalf("expected the expired watch to back off, got %d watches", got)
+	}
+}
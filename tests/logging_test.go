@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// logRecorder collects the JSON log records of a slog.Logger
+type logRecorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *logRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// records returns the logged records with the message
+func (r *logRecorder) records(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(r.buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["msg"] == msg {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func Test_givenConnection_whenClosed_thenAccessLoggedWithConnectionID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	recorder := &logRecorder{}
+	logger := slog.New(slog.NewJSONHandler(recorder, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("logged").First().Build())
+	client.Start()
+	for server.SubscriberCount() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("expected the client to connect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	server.Emit(ssevents.Event{Event: "logged", Data: "x"})
+	if _, err = observer.WaitForN(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	client.Shutdown()
+	for len(recorder.records(t, "sse connection closed")) == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("expected the closed connection to be logged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	opened := recorder.records(t, "sse connection opened")
+	closed := recorder.records(t, "sse connection closed")
+	emitted := recorder.records(t, "sse event emitted")
+	if len(opened) != 1 || len(closed) != 1 || len(emitted) != 1 {
+		t.Fatalf("expected a single opened, closed and emitted record, got %v, %v and %v", opened, closed, emitted)
+	}
+	connID, _ := opened[0]["conn_id"].(string)
+	if connID == "" || closed[0]["conn_id"] != connID {
+		t.Fatalf("expected both records with the connection ID, got %v and %v", opened[0], closed[0])
+	}
+	// The event sent besides the initial heartbeat
+	if sent, _ := closed[0]["events_sent"].(float64); sent < 1 {
+		t.Errorf("expected the sent events counted, got %v", closed[0])
+	}
+	if bytesSent, _ := closed[0]["bytes_sent"].(float64); bytesSent < float64(len("data: x\n\n")) {
+		t.Errorf("expected the sent bytes counted, got %v", closed[0])
+	}
+	if reason, _ := closed[0]["reason"].(string); reason == "" || emitted[0]["delivered"] != float64(1) {
+		t.Errorf("expected the disconnect reason and the delivery, got %v and %v", closed[0], emitted[0])
+	}
+}
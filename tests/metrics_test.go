@@ -0,0 +1,285 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/promsse"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gatherValues sums the counters and gauges of every metric family by its name
+func gatherValues(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	values := make(map[string]float64)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			values[family.GetName()] += metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func Test_givenMetricsHandler_whenScraped_thenServerMetricsExposed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+	collector, err := promsse.RegisterServerCollector(registry, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:         errorLogger(),
+		Metrics:        collector,
+		MetricsHandler: promsse.Handler(registry),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+	// The initial heartbeat is sent once the connection is counted
+	<-out
+	server.Emit(ssevents.Event{Data: "counted"})
+
+	metricsRes, err := http.Get(url + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = metricsRes.Body.Close() }()
+	body, err := io.ReadAll(metricsRes.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"test_sse_server_active_connections 1",
+		"test_sse_server_events_emitted_total 1",
+		"test_sse_server_heartbeats_sent_total 1",
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("expected %q in the metrics:\n%s", expected, body)
+		}
+	}
+}
+
+func Test_givenClientCollector_whenEventsReceived_thenStreamHealthReported(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	registry := prometheus.NewRegistry()
+	collector, err := promsse.RegisterClientCollector(registry, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+		Metrics:   collector,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("order-created").Limit(2).Build())
+	client.Start()
+
+	server.Emit(ssevents.Event{Event: "order-created", Data: "1"})
+	server.Emit(ssevents.Event{Event: "order-created", Data: "2"})
+	if _, err = observer.WaitForN(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	values := gatherValues(t, registry)
+	// The heartbeat and the two events, each at least "data: x\n\n"
+	if received := values["test_sse_client_events_received_total"]; received < 3 {
+		t.Fatalf("expected at least 3 received events, got %v", received)
+	}
+	if read := values["test_sse_client_read_bytes_total"]; read < float64(3*len("data: x\n\n")) {
+		t.Fatalf("expected the bytes of the events read, got %v", read)
+	}
+	if connected := values["test_sse_client_connected"]; connected != 1 {
+		t.Fatalf("expected the client connected, got %v", connected)
+	}
+}
+
+func Test_givenValidateEvent_whenInvalidEventEmitted_thenRejectedAndCounted(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector, err := promsse.RegisterServerCollector(registry, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:         errorLogger(),
+		Metrics:        collector,
+		MetricsHandler: promsse.Handler(registry),
+		ValidateEvent: func(e ssevents.Event) error {
+			if e.Event == "" {
+				return errors.New("event name is required")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	if _, _, err = server.EmitSync(context.Background(), ssevents.Event{Data: "unnamed"}); !errors.Is(
+		err, ssevents.ErrEventRejected,
+	) {
+		t.Fatalf("expected ErrEventRejected, got %v", err)
+	}
+	if err = server.EmitJSON("order", map[string]int{"id": 1}); err != nil {
+		t.Fatalf("expected the named event to be accepted, got %v", err)
+	}
+
+	res, err := http.Post(url+"/emit", "text/plain", strings.NewReader("unnamed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for the rejected event, got %d", res.StatusCode)
+	}
+
+	metricsRes, err := http.Get(url + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = metricsRes.Body.Close() }()
+	body, err := io.ReadAll(metricsRes.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "test_sse_server_events_rejected_total 2") {
+		t.Fatalf("expected 2 rejected events in the metrics:\n%s", body)
+	}
+}
+
+func Test_givenServerCollector_whenClientDisconnects_thenConnectionCountsUpdated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+	collector, err := promsse.RegisterServerCollector(registry, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), Metrics: collector})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	connCtx, disconnect := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(connCtx, http.MethodGet, "http://ssevents.test/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ssevents.NewHandlerTransport(server.Handler()).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(connCtx, res.Body, out) }()
+	<-out
+	if active := gatherValues(t, registry)["test_sse_server_active_connections"]; active != 1 {
+		t.Fatalf("expected an active connection, got %v", active)
+	}
+
+	disconnect()
+	_ = res.Body.Close()
+	// The disconnect is counted once the handler returns
+	for gatherValues(t, registry)["test_sse_server_disconnects_total"] != 1 {
+		if ctx.Err() != nil {
+			t.Fatal("expected the disconnect to be counted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	values := gatherValues(t, registry)
+	if values["test_sse_server_active_connections"] != 0 || values["test_sse_server_connects_total"] != 1 {
+		t.Fatalf("expected a single connect without active connections, got %v", values)
+	}
+}
+
+func Test_givenClientCollector_whenStreamDropped_thenReconnectsAndParseErrorsCounted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// Every connection sends an event with an invalid line and ends the stream right away
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "bogus\ndata: x\n\n")
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	collector, err := promsse.RegisterClientCollector(registry, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ssevents.NewSSEClient(server.URL, &ssevents.ClientOptions{
+		Logger:  errorLogger(),
+		Metrics: collector,
+		Backoff: &ssevents.Backoff{
+			InitialDelay: 5 * time.Millisecond, MaxDelay: 5 * time.Millisecond,
+			MaxRetries: ssevents.BackoffUnlimitedRetries,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Limit(2).Build())
+	client.Start()
+
+	if _, err = observer.WaitForN(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	values := gatherValues(t, registry)
+	if values["test_sse_client_reconnect_attempts_total"] < 1 {
+		t.Fatalf("expected the dropped stream to be reconnected, got %v", values)
+	}
+	if values["test_sse_client_parse_errors_total"] < 2 {
+		t.Fatalf("expected the invalid line of both streams to be counted, got %v", values)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenMiddlewares_whenRequestingRoutes_thenAppliedInOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:      errorLogger(),
+		Middlewares: []func(http.Handler) http.Handler{tag("outer"), tag("inner")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sseRes, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = sseRes.Body.Close() }()
+	if _, err = ssevents.NewDecoder(sseRes.Body).Decode(); err != nil {
+		t.Fatalf("expected the stream to be flushed through the middlewares: %v", err)
+	}
+
+	emitRes, err := http.Post(url+"/emit", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = emitRes.Body.Close()
+
+	for _, res := range []*http.Response{sseRes, emitRes} {
+		if tags := res.Header.Values("X-Middleware"); strings.Join(tags, ",") != "outer,inner" {
+			t.Fatalf("expected the middlewares applied in order, got %v", tags)
+		}
+	}
+}
+
+func Test_givenCustomHandler_whenServedThroughMiddleware_thenStreamStarts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ctrl := ssevents.NewController(&ssevents.Options{Logger: errorLogger()})
+	defer func() { _ = ctrl.Shutdown() }()
+	srv := httptest.NewServer(ctrl.Middleware(func(ctx context.Context, _ *http.Request, res chan<- ssevents.Event) {
+		select {
+		case res <- ssevents.Event{Event: "custom", Data: "from handler"}:
+		case <-ctx.Done():
+		}
+		<-ctx.Done()
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected the response headers to be sent, got %v", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", contentType)
+	}
+
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+	for {
+		select {
+		case evt := <-out:
+			if evt.Event == "custom" {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the event of the custom handler")
+		}
+	}
+}
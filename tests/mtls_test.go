@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+// issueCert creates a certificate for the common name signed by the parent, self-signed when parent is nil
+func issueCert(t *testing.T, commonName string, parent *tls.Certificate, isCA bool) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	signerCert, signerKey := template, any(key)
+	if parent != nil {
+		signerCert, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func Test_givenClientCAs_whenConnecting_thenRequireCertificateAndExposeSubject(t *testing.T) {
+	ca := issueCert(t, "test-ca", nil, true)
+	serverCert := issueCert(t, "localhost", &ca, false)
+	clientCert := issueCert(t, "billing-service", &ca, false)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	connected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:    errorLogger(),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}},
+		ClientCAs: pool,
+		OnConnect: func(info ssevents.ConnInfo) {
+			connected <- info
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	withoutCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if res, reqErr := withoutCert.Do(req); reqErr == nil {
+		_ = res.Body.Close()
+		t.Fatal("expected the connection without a client certificate to fail")
+	}
+
+	withCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+	}}}
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	res, err := withCert.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	info := <-connected
+	if info.ClientCert == nil || info.ClientCert.Subject.CommonName != "billing-service" {
+		t.Fatalf("expected client certificate of billing-service, got %v", info.ClientCert)
+	}
+	if info.UserID != "billing-service" {
+		t.Fatalf("expected user billing-service got %q", info.UserID)
+	}
+}
+
+func Test_givenCertificateFiles_whenListenAndServeTLS_thenServeHTTPS(t *testing.T) {
+	serverCert := issueCert(t, "localhost", nil, true)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	keyDER, err := x509.MarshalECPrivateKey(serverCert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err = os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeTLSOnRandomPort(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+	if !strings.HasPrefix(url, "https://") {
+		t.Fatalf("expected an https url, got %s", url)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(serverCert.Leaf)
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(1).First().Build())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	server.Emit(ssevents.Event{Data: "over tls"})
+	select {
+	case evt := <-observer.EventCh:
+		if evt.Data != "over tls" {
+			t.Fatalf("unexpected event %s", evt)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the event")
+	}
+}
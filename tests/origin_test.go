@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenAllowedOrigins_whenConnecting_thenRejectOtherOrigins(t *testing.T) {
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:         errorLogger(),
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	cases := map[string]int{
+		"":                                http.StatusOK,
+		"https://app.example.com":         http.StatusOK,
+		"https://eu.app.example.org":      http.StatusOK,
+		"https://example.org":             http.StatusForbidden,
+		"http://app.example.com":          http.StatusForbidden,
+		"https://evil.com":                http.StatusForbidden,
+		"https://app.example.com.evil":    http.StatusForbidden,
+		"https://evil.com:1/.example.org": http.StatusForbidden,
+	}
+	for origin, expected := range cases {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		if status := connectStatus(t, url+"/sse", header); status != expected {
+			t.Errorf("origin %q expected status %d got %d", origin, expected, status)
+		}
+	}
+}
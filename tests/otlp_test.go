@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/otlp"
+)
+
+// collectingEmitter records the emitted events
+type collectingEmitter struct {
+	events []ssevents.Event
+}
+
+func (c *collectingEmitter) Emit(e ssevents.Event) {
+	c.events = append(c.events, e)
+}
+
+const otlpLogsRequest = `{"resourceLogs":[{
+"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},
+"scopeLogs":[{"scope":{"name":"http"},"logRecords":[{"timeUnixNano":"1700000000000000000","severityText":"INFO",
+"body":{"stringValue":"request served"},"attributes":[{"key":"status","value":{"intValue":"200"}}]}]}]}]}`
+
+func Test_givenGzippedOTLPLogs_whenExported_thenEmitLogRecords(t *testing.T) {
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if _, err := gz.Write([]byte(otlpLogsRequest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/logs", &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	emitter := &collectingEmitter{}
+
+	otlp.Handler(emitter)(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", res.Code, res.Body.String())
+	}
+	if len(emitter.events) != 1 || emitter.events[0].Event != otlp.EventLog {
+		t.Fatalf("expected a single log event got %v", emitter.events)
+	}
+	var record otlp.Record
+	if err := json.Unmarshal([]byte(emitter.events[0].Data), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Body != "request served" || record.Resource["service.name"] != "api" || record.Scope != "http" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
+
+func Test_givenOversizedOTLPLogs_whenExported_thenRejectTooLarge(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(bytes.Repeat([]byte(" "), otlp.MaxBodySize+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+	}{
+		{name: "plain", body: bytes.Repeat([]byte(" "), otlp.MaxBodySize+1)},
+		{name: "gzip expanding past the limit", body: compressed.Bytes(), encoding: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/logs", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			res := httptest.NewRecorder()
+			emitter := &collectingEmitter{}
+
+			otlp.Handler(emitter)(res, req)
+
+			if res.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("expected 413 got %d: %s", res.Code, res.Body.String())
+			}
+			if len(emitter.events) != 0 {
+				t.Fatalf("expected no events got %d", len(emitter.events))
+			}
+		})
+	}
+}
+
+func Test_givenProtobufOTLPLogs_whenExported_thenRejectUnsupportedMediaType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader("\x0a\x00"))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	res := httptest.NewRecorder()
+
+	otlp.Handler(&collectingEmitter{})(res, req)
+
+	if res.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 got %d", res.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/outbox"
+)
+
+type fakeOutboxRow struct {
+	id         int64
+	event      string
+	data       string
+	dispatched bool
+}
+
+// fakeOutbox is a database/sql driver serving an in-memory outbox table, every query selects the rows after the
+// cursor that were not dispatched and every exec marks the row with the given id as dispatched
+type fakeOutbox struct {
+	mu   sync.Mutex
+	rows []fakeOutboxRow
+}
+
+func (f *fakeOutbox) Connect(context.Context) (driver.Conn, error) { return fakeOutboxConn{f}, nil }
+func (f *fakeOutbox) Driver() driver.Driver                        { return nil }
+
+type fakeOutboxConn struct{ outbox *fakeOutbox }
+
+func (c fakeOutboxConn) Prepare(string) (driver.Stmt, error) { return fakeOutboxStmt(c), nil }
+func (c fakeOutboxConn) Close() error                        { return nil }
+func (c fakeOutboxConn) Begin() (driver.Tx, error)           { return nil, errors.New("unsupported") }
+
+type fakeOutboxStmt struct{ outbox *fakeOutbox }
+
+func (s fakeOutboxStmt) Close() error  { return nil }
+func (s fakeOutboxStmt) NumInput() int { return 1 }
+
+func (s fakeOutboxStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.outbox.mu.Lock()
+	defer s.outbox.mu.Unlock()
+	for i := range s.outbox.rows {
+		if s.outbox.rows[i].id == args[0].(int64) {
+			s.outbox.rows[i].dispatched = true
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeOutboxStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.outbox.mu.Lock()
+	defer s.outbox.mu.Unlock()
+	var rows [][]driver.Value
+	for _, r := range s.outbox.rows {
+		if r.id > args[0].(int64) && !r.dispatched {
+			rows = append(rows, []driver.Value{r.id, r.event, []byte(r.data)})
+		}
+	}
+	return &fakeOutboxRows{rows: rows}, nil
+}
+
+type fakeOutboxRows struct{ rows [][]driver.Value }
+
+func (r *fakeOutboxRows) Columns() []string { return []string{"id", "event", "data"} }
+func (r *fakeOutboxRows) Close() error      { return nil }
+
+func (r *fakeOutboxRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func Test_givenOutboxRows_whenPolled_thenEmitAndMarkDispatchedOnce(t *testing.T) {
+	table := &fakeOutbox{rows: []fakeOutboxRow{
+		{id: 1, event: "order-created", data: `{"id":1}`},
+		{id: 2, event: "order-paid", data: `{"id":1}`},
+	}}
+	poller, err := outbox.NewPoller(outbox.Options{
+		DB:             sql.OpenDB(table),
+		Query:          "SELECT id, event, data FROM outbox WHERE id > $1 AND dispatched_at IS NULL ORDER BY id",
+		MarkDispatched: "UPDATE outbox SET dispatched_at = now() WHERE id = $1",
+		Logger:         errorLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var emitted []ssevents.Event
+	emit := func(e ssevents.Event) { emitted = append(emitted, e) }
+	if err = poller.Poll(context.Background(), emit); err != nil {
+		t.Fatal(err)
+	}
+	if err = poller.Poll(context.Background(), emit); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ssevents.Event{
+		{Id: "1", Event: "order-created", Data: `{"id":1}`},
+		{Id: "2", Event: "order-paid", Data: `{"id":1}`},
+	}
+	if len(emitted) != len(expected) {
+		t.Fatalf("expected %d events got %v", len(expected), emitted)
+	}
+	for i, e := range expected {
+		if emitted[i].Id != e.Id || emitted[i].Event != e.Event || emitted[i].Data != e.Data {
+			t.Errorf("expected %+v got %+v", e, emitted[i])
+		}
+	}
+	for _, r := range table.rows {
+		if !r.dispatched {
+			t.Errorf("expected row %d to be marked dispatched", r.id)
+		}
+	}
+}
+
+func Test_givenOutboxOptions_whenMissingDBOrQuery_thenError(t *testing.T) {
+	if _, err := outbox.NewPoller(outbox.Options{Query: "SELECT 1"}); !errors.Is(err, outbox.ErrMissingDB) {
+		t.Errorf("expected ErrMissingDB got %v", err)
+	}
+	if _, err := outbox.NewPoller(outbox.Options{DB: sql.OpenDB(&fakeOutbox{})}); !errors.Is(err, outbox.ErrMissingQuery) {
+		t.Errorf("expected ErrMissingQuery got %v", err)
+	}
+}
+
+func Test_givenSource_whenServerStarted_thenEmitToSubscribers(t *testing.T) {
+	source := ssevents.SourceFunc(func(ctx context.Context, emit func(e ssevents.Event)) error {
+		// Emitting until stopped reaches the client whenever it connects
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				emit(ssevents.Event{Event: "source", Data: "hello"})
+			}
+		}
+	})
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), Sources: []ssevents.Source{source}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().First().Build())
+	client.Start()
+
+	events, err := observer.WaitForAllOrTimeout(2 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event != "source" || events[0].Data != "hello" {
+		t.Fatalf("expected the source event got %v", events)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenEnablePprof_whenRequested_thenOnlyAuthorizedCallersProfiled(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    *ssevents.Options
+		path       string
+		remoteAddr string
+		status     int
+		profiled   bool
+	}{
+		{
+			name:    "disabled",
+			options: &ssevents.Options{},
+			// Unmapped requests are answered by the catch-all route without a body
+			path: "/debug/pprof/", remoteAddr: "127.0.0.1:1234", status: http.StatusOK,
+		},
+		{
+			name:    "loopback",
+			options: &ssevents.Options{EnablePprof: true},
+			path:    "/debug/pprof/goroutine?debug=1", remoteAddr: "127.0.0.1:1234", status: http.StatusOK, profiled: true,
+		},
+		{
+			name:    "remote",
+			options: &ssevents.Options{EnablePprof: true},
+			path:    "/debug/pprof/", remoteAddr: "192.0.2.1:1234", status: http.StatusForbidden,
+		},
+		{
+			name: "custom path and authorization",
+			options: &ssevents.Options{
+				EnablePprof: true,
+				PprofPath:   "/internal/pprof",
+				PprofAuthorize: func(req *http.Request) bool {
+					return req.Header.Get("X-Admin") == "yes"
+				},
+			},
+			path: "/internal/pprof/cmdline", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, profiled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Logger = errorLogger()
+			server, err := ssevents.NewServer(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Admin", "yes")
+			res := httptest.NewRecorder()
+
+			server.Handler().ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, res.Code)
+			}
+			if profiled := res.Body.Len() > 0; res.Code == http.StatusOK && profiled != tt.profiled {
+				t.Fatalf("expected profiled %t got body %q", tt.profiled, res.Body.String())
+			}
+		})
+	}
+}
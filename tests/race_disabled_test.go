@@ -0,0 +1,6 @@
+//go:build !race
+
+package tests
+
+// raceEnabled reports the race detector, which makes sync.Pool drop pooled values at random
+const raceEnabled = false
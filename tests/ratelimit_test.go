@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenEmitRateLimits_whenProducerFloods_thenRespondTooManyRequests(t *testing.T) {
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:           errorLogger(),
+		EmitRateLimit:    ssevents.RateLimit{Rate: 0.01, Burst: 4},
+		EmitKeyRateLimit: ssevents.RateLimit{Rate: 0.01, Burst: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	emit := func(apiKey string) *http.Response {
+		req, reqErr := http.NewRequest(http.MethodPost, url+"/emit", strings.NewReader("hello"))
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		req.Header.Set("X-API-Key", apiKey)
+		res, reqErr := http.DefaultClient.Do(req)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		_ = res.Body.Close()
+		return res
+	}
+
+	for _, apiKey := range []string{"a", "a", "b", "b"} {
+		if res := emit(apiKey); res.StatusCode != http.StatusOK {
+			t.Fatalf("expected producer %s to be allowed, got %d", apiKey, res.StatusCode)
+		}
+	}
+
+	res := emit("a")
+	if res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After over the producer limit, got %d", res.StatusCode)
+	}
+	if res = emit("c"); res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the global limit, got %d", res.StatusCode)
+	}
+}
+
+func Test_givenDisabledEmitEndpoint_whenPostingToEmit_thenNotServed(t *testing.T) {
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), DisableEmitEndpoint: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	res, err := http.Post(url+"/emit", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		t.Fatal("expected the disabled emit endpoint to reject the request")
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/ssetest"
+)
+
+func Test_givenRecordedStream_whenReplayedToClient_thenSameEventsReceived(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	var recording bytes.Buffer
+	recorder, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+		Sinks:     []ssevents.Sink{ssevents.NewRecorder(&recording)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := recorder.Subscribe(ssevents.NewObserverBuilder().On("order-created").Buffer(10).Build())
+	recorder.Start()
+
+	emitted := []ssevents.Event{
+		{Id: "1", Event: "order-created", Data: `{"id":"1"}`},
+		{Id: "2", Event: "order-created", Data: "line 1\nline 2", Extensions: map[string]string{"tenant": "a"}},
+	}
+	for _, evt := range emitted {
+		server.Emit(evt)
+	}
+	ssetest.Collect(t, recorded, len(emitted))
+	recorder.Shutdown()
+
+	replayer, err := ssevents.NewReplayer(&recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer.Speed = 10
+	client, err := ssevents.NewSSEClient("http://upstream.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(replayer.Handler()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	replayed := client.Subscribe(ssevents.NewObserverBuilder().On("order-created").Buffer(10).Build())
+	client.Start()
+
+	for i, evt := range ssetest.Collect(t, replayed, len(emitted)) {
+		if evt.String() != emitted[i].String() {
+			t.Fatalf("expected the replayed event %s, got %s", emitted[i], evt)
+		}
+	}
+}
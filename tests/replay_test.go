@@ -0,0 +1,293 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenReplayBuffer_whenResumingWithLastEventID_thenReplayMissedThenLive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), ReplayBufferSize: 3, BufferSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	// The buffer keeps only events 3, 4 and 5
+	for i := 1; i <= 5; i++ {
+		server.Emit(ssevents.Event{Id: strconv.Itoa(i), Data: "missed"})
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "3")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+
+	next := func() ssevents.Event {
+		for {
+			select {
+			case evt := <-out:
+				if evt.Event != "heartbeat" {
+					return evt
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for an event")
+			}
+		}
+	}
+
+	for _, expected := range []string{"4", "5"} {
+		if evt := next(); evt.Id != expected {
+			t.Fatalf("expected replayed event %s got %s", expected, evt)
+		}
+	}
+	// The replay is sent once the subscriber is stored, so live events are not missed
+	server.Emit(ssevents.Event{Id: "6", Data: "live"})
+	if evt := next(); evt.Id != "6" || evt.Data != "live" {
+		t.Fatalf("expected live event 6 got %s", evt)
+	}
+}
+
+func Test_givenClient_whenEventsWithIDReceived_thenTrackLastEventID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(2).Limit(2).Build())
+	client.Start()
+
+	server.Emit(ssevents.Event{Id: "41", Data: "first"})
+	server.Emit(ssevents.Event{Id: "42", Data: "second"})
+	observer.WaitForAll()
+
+	if lastEventID := client.LastEventID(); lastEventID != "42" {
+		t.Fatalf("expected last event id 42 got %q", lastEventID)
+	}
+}
+
+func Test_givenReplayBuffer_whenEventsWithoutID_thenKeepOnlyResumableEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), ReplayBufferSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	server.Emit(ssevents.Event{Id: "1", Data: "first"})
+	server.Emit(ssevents.Event{Id: "2", Data: "second"})
+	for i := 0; i < 5; i++ {
+		server.Emit(ssevents.Event{Data: "without id"})
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+	for {
+		select {
+		case evt := <-out:
+			if evt.Event == "heartbeat" {
+				continue
+			}
+			if evt.Id != "2" {
+				t.Fatalf("expected replayed event 2 got %s", evt)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the replay")
+		}
+	}
+}
+
+func Test_givenMemoryEventStore_whenRetentionExceeded_thenOnlyRetainedEventsReplayed(t *testing.T) {
+	ctx := context.Background()
+	store := ssevents.NewMemoryEventStore(3, 0)
+	for i := 1; i <= 5; i++ {
+		if err := store.Append(ctx, ssevents.Event{Id: strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, found, _ := store.After(ctx, "2"); found {
+		t.Fatal("expected event 2 to be dropped over the max count")
+	}
+	events, found, err := store.After(ctx, "3")
+	if err != nil || !found || len(events) != 2 || events[0].Id != "4" || events[1].Id != "5" {
+		t.Fatalf("expected events 4 and 5 after 3, got %v found %t: %v", events, found, err)
+	}
+
+	aging := ssevents.NewMemoryEventStore(10, 20*time.Millisecond)
+	_ = aging.Append(ctx, ssevents.Event{Id: "old"})
+	time.Sleep(30 * time.Millisecond)
+	_ = aging.Append(ctx, ssevents.Event{Id: "new"})
+	if _, found, _ = aging.After(ctx, "old"); found {
+		t.Fatal("expected the old event to be expired")
+	}
+}
+
+type recordingEventStore struct {
+	*ssevents.MemoryEventStore
+	appended chan ssevents.Event
+}
+
+func (s recordingEventStore) Append(ctx context.Context, e ssevents.Event) error {
+	s.appended <- e
+	return s.MemoryEventStore.Append(ctx, e)
+}
+
+func Test_givenEventStore_whenEmitting_thenStoredEventsReplayed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	store := recordingEventStore{ssevents.NewMemoryEventStore(10, 0), make(chan ssevents.Event, 10)}
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), EventStore: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	server.Emit(ssevents.Event{Id: "1", Data: "first"})
+	server.Emit(ssevents.Event{Data: "without id"})
+	server.Emit(ssevents.Event{Id: "2", Data: "second"})
+	if evt := <-store.appended; evt.Id != "1" {
+		t.Fatalf("expected event 1 stored got %s", evt)
+	}
+	if evt := <-store.appended; evt.Id != "2" {
+		t.Fatalf("expected event 2 stored got %s", evt)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+	for {
+		select {
+		case evt := <-out:
+			if evt.Event == "heartbeat" {
+				continue
+			}
+			if evt.Id != "2" || evt.Data != "second" {
+				t.Fatalf("expected replayed event 2 got %s", evt)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the replay")
+		}
+	}
+}
+
+func Test_givenResumingConnection_whenEventWithoutIDEmitted_thenDeliveredLive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), ReplayBufferSize: 10, BufferSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	server.Emit(ssevents.Event{Id: "1", Data: "seen"})
+	server.Emit(ssevents.Event{Id: "2", Data: "missed"})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1")
+	// Events without an ID are emitted while the connection resumes, the first one delivered to it must arrive
+	first := make(chan string, 1)
+	go func() {
+		for i := 0; ctx.Err() == nil; i++ {
+			data := "unnamed-" + strconv.Itoa(i)
+			if delivered, _, _ := server.EmitSync(ctx, ssevents.Event{Data: data}); delivered > 0 {
+				first <- data
+				return
+			}
+		}
+	}()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	out := make(chan ssevents.Event, 10)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+
+	var expected string
+	select {
+	case expected = <-first:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the delivery of an event without an ID")
+	}
+	for {
+		select {
+		case evt := <-out:
+			if evt.Event == "heartbeat" || evt.Id == "2" {
+				continue
+			}
+			if evt.Data != expected {
+				t.Fatalf("expected the event %s without an ID, got %s", expected, evt)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the event %s without an ID", expected)
+		}
+	}
+}
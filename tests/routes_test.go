@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenSSERoute_whenEmitOnItsController_thenOnlyItsConnectionsReceive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := server.AddSSERoute("/sse/orders", &ssevents.Options{
+		Logger:     errorLogger(),
+		BufferSize: 10,
+		HeartbeatEvent: func() *ssevents.Event {
+			return &ssevents.Event{Event: "orders-heartbeat"}
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.AddSSERoute("/sse/orders", nil, nil); err == nil {
+		t.Fatal("expected an error adding the route twice")
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	connect := func(path string) <-chan ssevents.Event {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url+path, nil)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		res, reqErr := http.DefaultClient.Do(req)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		t.Cleanup(func() { _ = res.Body.Close() })
+		events := make(chan ssevents.Event, 100)
+		go func() { _ = ssevents.ReadEvents(ctx, res.Body, events) }()
+		return events
+	}
+	mainEvents, orderEvents := connect("/sse"), connect("/sse/orders")
+
+	if heartbeat := <-orderEvents; heartbeat.Event != "orders-heartbeat" {
+		t.Fatalf("expected the heartbeat of the route options, got %s", heartbeat)
+	}
+	if heartbeat := <-mainEvents; heartbeat.Event != "heartbeat" {
+		t.Fatalf("expected the default heartbeat, got %s", heartbeat)
+	}
+
+	// The subscriber is stored once the connection handler runs, emit until it arrives
+	for received := false; !received; {
+		orders.Emit(ssevents.Event{Event: "order-created", Data: "1"})
+		select {
+		case evt := <-orderEvents:
+			received = evt.Event == "order-created"
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the route event")
+		}
+	}
+	for received := false; !received; {
+		server.Emit(ssevents.Event{Event: "main", Data: "2"})
+		select {
+		case evt := <-mainEvents:
+			if evt.Event == "order-created" {
+				t.Fatalf("main endpoint received the event of the route")
+			}
+			received = evt.Event == "main"
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the main event")
+		}
+	}
+
+	report := server.ShutdownWithReport(ctx)
+	if report.ConnectionsDrained != 2 {
+		t.Fatalf("expected both connections drained, got %+v", report)
+	}
+}
+
+func Test_givenMountedHandlers_whenEmit_thenConnectionsOfOwnServerReceive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	handler, ctrl := ssevents.Handler(&ssevents.Options{Logger: errorLogger()})
+	defer func() { _ = ctrl.Shutdown() }()
+	notifications := ssevents.NewController(&ssevents.Options{Logger: errorLogger()})
+	defer func() { _ = notifications.Shutdown() }()
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", handler))
+	mux.Handle("GET /notifications", notifications.Handler())
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path string
+		ctrl *ssevents.HttpController
+	}{
+		{path: "/api/sse", ctrl: ctrl},
+		{path: "/notifications", ctrl: notifications},
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events := make(chan ssevents.Event, 100)
+		go func() { _ = ssevents.ReadEvents(ctx, res.Body, events) }()
+
+		for received := false; !received; {
+			tc.ctrl.Emit(ssevents.Event{Event: "mounted", Data: tc.path})
+			select {
+			case evt := <-events:
+				received = evt.Event == "mounted"
+			case <-time.After(10 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for the event on %s", tc.path)
+			}
+		}
+		_ = res.Body.Close()
+	}
+}
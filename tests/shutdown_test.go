@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenConnectedSubscriber_whenShutdownWithReport_thenReportDrainedConnection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	connected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(),
+		OnConnect: func(info ssevents.ConnInfo) {
+			connected <- info
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	<-connected
+
+	report := server.ShutdownWithReport(ctx)
+	if err = report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Clean() || report.ConnectionsDrained != 1 || report.ConnectionsForceClosed != 0 {
+		t.Fatalf("expected a clean shutdown draining the connection, got %+v", report)
+	}
+	if len(report.Components) != 3 {
+		t.Fatalf("expected controller, sources and http components, got %+v", report.Components)
+	}
+}
+
+func Test_givenObserverWithUnreadEvents_whenShutdownWithReport_thenReportDiscarded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(5).Build())
+	client.Start()
+
+	for i := 0; i < 2; i++ {
+		server.Emit(ssevents.Event{Data: "unread"})
+	}
+	for deadline := time.Now().Add(time.Second); len(observer.EventCh) < 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	report := client.ShutdownWithReport()
+	if report.ObserversClosed != 1 || report.EventsDiscarded != 2 {
+		t.Fatalf("expected one closed observer with 2 unread events, got %+v", report)
+	}
+}
+
+func Test_givenShutdownEvent_whenShutdown_thenSubscribersReceiveItBeforeClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:        errorLogger(),
+		ShutdownEvent: ssevents.NewShutdownEvent(3 * time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	out := make(chan ssevents.Event, 10)
+	go func() {
+		_ = ssevents.ReadEvents(ctx, res.Body, out)
+		close(out)
+	}()
+	// The initial heartbeat tells the connection is established
+	<-out
+
+	if err = server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var last ssevents.Event
+	for evt := range out {
+		last = evt
+	}
+	if last.Event != ssevents.EventNameShutdown || last.Retry != 3000 {
+		t.Fatalf("expected the shutdown event with retry as the last one, got %+v", last)
+	}
+}
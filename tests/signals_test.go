@@ -0,0 +1,55 @@
+//go:build unix
+
+package tests
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenNotifyContext_whenSignalledTwice_thenCancelAndForce(t *testing.T) {
+	forced := make(chan os.Signal, 1)
+	ctx, stop := ssevents.NotifyContextWithForce(context.Background(), func(sig os.Signal) {
+		forced <- sig
+	}, syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the context to be cancelled on the first signal")
+	}
+	if cause := context.Cause(ctx); cause == nil || cause.Error() != "received signal user defined signal 1" {
+		t.Fatalf("expected the signal as the cause got %v", cause)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case sig := <-forced:
+		if sig != syscall.SIGUSR1 {
+			t.Fatalf("expected force on SIGUSR1 got %s", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected force to be called on the second signal")
+	}
+}
+
+func Test_givenNotifyContext_whenStopped_thenCancelWithoutSignal(t *testing.T) {
+	ctx, stop := ssevents.NotifyContext(context.Background(), syscall.SIGUSR2)
+	stop()
+	stop()
+
+	if ctx.Err() == nil {
+		t.Fatal("expected the context to be cancelled by stop")
+	}
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/ssetest"
+)
+
+func Test_givenSSETestMatchers_whenEventsEmitted_thenExpectedEventsFound(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client, server, shutdown, err := BootstrapClientAndServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	observer := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	client.Start()
+
+	server.Emit(ssevents.Event{Event: "order-created", Data: `{"order":{"id":"1","items":[{"qty":2}]}}`})
+	server.Emit(ssevents.Event{Event: "order-created", Data: `{"order":{"id":"2","items":[{"qty":3}]}}`})
+	server.Emit(ssevents.Event{Event: "order-shipped", Data: "order 2 shipped"})
+	server.Emit(ssevents.Event{Event: "tick", Data: "1"})
+	server.Emit(ssevents.Event{Event: "tick", Data: "2"})
+
+	evt := ssetest.ExpectEvent(t, observer,
+		ssetest.All(ssetest.ByName("order-created"), ssetest.JSONField("order.items.0.qty", 3)), time.Second)
+	if evt.Data != `{"order":{"id":"2","items":[{"qty":3}]}}` {
+		t.Fatalf("expected the second order, got %s", evt)
+	}
+	ssetest.ExpectEvent(t, observer, ssetest.DataContains("shipped"), time.Second)
+
+	if events := ssetest.Collect(t, observer, 2); events[0].Data != "1" || events[1].Data != "2" {
+		t.Fatalf("expected the ticks, got %v", events)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenSubscriberIDFunc_whenEmitToSubscriber_thenDeliverOnlyToThatConnection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:    errorLogger(),
+		SendHello: true,
+		SubscriberIDFunc: func(req *http.Request) string {
+			return req.URL.Query().Get("client_id")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	connect := func(clientID string) *ssevents.Client {
+		client, clientErr := ssevents.NewSSEClient(url+"/sse?client_id="+clientID, &ssevents.ClientOptions{
+			Logger: errorLogger(),
+		})
+		if clientErr != nil {
+			t.Fatal(clientErr)
+		}
+		t.Cleanup(client.Shutdown)
+		return client
+	}
+	first, second := connect("tab-1"), connect("tab-2")
+	firstEvents := first.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	secondEvents := second.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	first.Start()
+	second.Start()
+
+	info, ok := first.ServerInfo()
+	for ; !ok && ctx.Err() == nil; info, ok = first.ServerInfo() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if info.SubscriberID != "tab-1" {
+		t.Fatalf("expected the hello to announce the subscriber id, got %+v", info)
+	}
+
+	for !server.EmitToSubscriber("tab-2", ssevents.Event{Data: "only for tab-2"}) && ctx.Err() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case evt := <-secondEvents.EventCh:
+		if evt.Data != "only for tab-2" {
+			t.Fatalf("unexpected event %s", evt)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the targeted event")
+	}
+	if len(firstEvents.EventCh) != 0 {
+		t.Fatalf("expected the other connection to receive nothing, got %s", <-firstEvents.EventCh)
+	}
+}
+
+func Test_givenConnectedSubscribers_whenListed_thenPresenceReported(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:      errorLogger(),
+		EnableStats: true,
+		SubscriberIDFunc: func(req *http.Request) string {
+			return req.URL.Query().Get("client_id")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	for _, query := range []string{"client_id=tab-1&topic=orders", "client_id=tab-2&topic=orders,payments"} {
+		client, clientErr := ssevents.NewSSEClient(url+"/sse?"+query, &ssevents.ClientOptions{Logger: errorLogger()})
+		if clientErr != nil {
+			t.Fatal(clientErr)
+		}
+		t.Cleanup(client.Shutdown)
+		if clientErr = client.StartContext(ctx); clientErr != nil {
+			t.Fatal(clientErr)
+		}
+	}
+	for server.SubscriberCount() < 2 && ctx.Err() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	subscribers := server.Subscribers()
+	if len(subscribers) != 2 || subscribers[0].ID != "tab-1" || subscribers[1].ID != "tab-2" {
+		t.Fatalf("expected tab-1 and tab-2 in the order of connecting, got %+v", subscribers)
+	}
+	if n := server.TopicSubscriberCount("orders"); n != 2 {
+		t.Fatalf("expected 2 subscribers of orders, got %d", n)
+	}
+	if n := server.TopicSubscriberCount("payments"); n != 1 {
+		t.Fatalf("expected 1 subscriber of payments, got %d", n)
+	}
+
+	res, err := http.Get(url + "/sse/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	var stats ssevents.Stats
+	if err = json.NewDecoder(res.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Subscribers != 2 || stats.Topics["orders"] != 2 || stats.Topics["payments"] != 1 ||
+		len(stats.Connections) != 2 || stats.Connections[1].ID != "tab-2" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+)
+
+func Test_givenTopicSubscriptions_whenEmitTo_thenDeliverOnlyToSubscribers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), BufferSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	subscribe := func(query string) <-chan ssevents.Event {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse?"+query, nil)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		res, reqErr := http.DefaultClient.Do(req)
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		t.Cleanup(func() { _ = res.Body.Close() })
+		out := make(chan ssevents.Event, 10)
+		go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+		return out
+	}
+	next := func(out <-chan ssevents.Event) ssevents.Event {
+		for {
+			select {
+			case evt := <-out:
+				if evt.Event != "heartbeat" && evt.Event != "probe" {
+					return evt
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for an event")
+			}
+		}
+	}
+
+	orders := subscribe("topic=orders")
+	both := subscribe("topic=orders,payments")
+	for server.EmitTo("orders", ssevents.Event{Event: "probe", Data: "probe"}) < 2 && ctx.Err() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if delivered := server.EmitTo("payments", ssevents.Event{Data: "payment"}); delivered != 1 {
+		t.Fatalf("expected the payment to be sent to 1 connection, got %d", delivered)
+	}
+	if delivered := server.EmitTo("orders", ssevents.Event{Data: "order"}); delivered != 2 {
+		t.Fatalf("expected the order to be sent to 2 connections, got %d", delivered)
+	}
+
+	if evt := next(orders); evt.Data != "order" {
+		t.Fatalf("expected the orders subscriber to receive only the order, got %s", evt)
+	}
+	if evt := next(both); evt.Data != "payment" {
+		t.Fatalf("expected the payment first, got %s", evt)
+	}
+	if evt := next(both); evt.Data != "order" {
+		t.Fatalf("expected the order second, got %s", evt)
+	}
+
+	res, err := http.Post(url+"/emit?topic=payments", "text/plain", strings.NewReader("from endpoint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if evt := next(both); evt.Data != "from endpoint" {
+		t.Fatalf("expected the payment emitted through the endpoint, got %s", evt)
+	}
+}
+
+func Test_givenDynamicTopics_whenClientChangesTopics_thenDeliveredWithoutReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var connects atomic.Int32
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:        errorLogger(),
+		SendHello:     true,
+		DynamicTopics: true,
+		OnConnect:     func(ssevents.ConnInfo) { connects.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	client, err := ssevents.NewSSEClient(url+"/sse?topic=orders", &ssevents.ClientOptions{Logger: errorLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	events := client.Subscribe(ssevents.NewObserverBuilder().Buffer(10).Build())
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := client.ServerInfo()
+	for ; !ok && ctx.Err() == nil; info, ok = client.ServerInfo() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !info.Subscriptions {
+		t.Fatalf("expected the hello to announce subscriptions, got %+v", info)
+	}
+
+	if delivered := server.EmitTo("payments", ssevents.Event{Data: "missed"}); delivered != 0 {
+		t.Fatalf("expected no subscriber of payments yet, got %d", delivered)
+	}
+	if err = client.AddTopic(ctx, "payments"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.RemoveTopic(ctx, "orders"); err != nil {
+		t.Fatal(err)
+	}
+	if topics := client.Topics(); len(topics) != 1 || topics[0] != "payments" {
+		t.Fatalf("expected only the payments topic, got %v", topics)
+	}
+
+	if delivered := server.EmitTo("orders", ssevents.Event{Data: "order"}); delivered != 0 {
+		t.Fatalf("expected the orders topic to be removed, got %d subscribers", delivered)
+	}
+	if delivered := server.EmitTo("payments", ssevents.Event{Data: "payment"}); delivered != 1 {
+		t.Fatalf("expected the payment to be sent to 1 connection, got %d", delivered)
+	}
+	select {
+	case evt := <-events.EventCh:
+		if evt.Data != "payment" {
+			t.Fatalf("expected the payment, got %s", evt)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the payment")
+	}
+	if n := connects.Load(); n != 1 {
+		t.Fatalf("expected a single connection, got %d", n)
+	}
+
+	res, err := http.Post(url+"/sse/subscriptions", "application/json",
+		strings.NewReader(`{"subscriberId":"unknown","add":["orders"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown subscriber, got %d", res.StatusCode)
+	}
+}
+
+func Test_givenOversizedSubscriptionChange_whenPosted_thenRequestEntityTooLarge(t *testing.T) {
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), DynamicTopics: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"subscriberId":"s-1","add":["` + strings.Repeat("t", 1<<20) + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/sse/subscriptions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected %d got %d", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/otelsse"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type traceKey struct{}
+
+// recordingTracer keeps the trace of the X-Trace header of /emit requests in the context and records the spans
+type recordingTracer struct {
+	mu          sync.Mutex
+	emits       []string
+	deliveries  []string
+	connections int
+	reasons     []string
+}
+
+func (r *recordingTracer) Extract(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, traceKey{}, header.Get("X-Trace"))
+}
+
+func (r *recordingTracer) StartConnection(ctx context.Context, _ ssevents.ConnInfo) (context.Context, func(string)) {
+	r.mu.Lock()
+	r.connections++
+	r.mu.Unlock()
+	return ctx, func(reason string) {
+		r.mu.Lock()
+		r.reasons = append(r.reasons, reason)
+		r.mu.Unlock()
+	}
+}
+
+func (r *recordingTracer) StartEmit(ctx context.Context, _ ssevents.Event) (context.Context, func()) {
+	trace, _ := ctx.Value(traceKey{}).(string)
+	r.mu.Lock()
+	r.emits = append(r.emits, trace)
+	r.mu.Unlock()
+	return ctx, func() {}
+}
+
+func (r *recordingTracer) Delivered(_, emitCtx context.Context, _ ssevents.Event) {
+	trace, _ := emitCtx.Value(traceKey{}).(string)
+	r.mu.Lock()
+	r.deliveries = append(r.deliveries, trace)
+	r.mu.Unlock()
+}
+
+func (r *recordingTracer) Inject(ctx context.Context, carrier map[string]string) {
+	if trace, _ := ctx.Value(traceKey{}).(string); trace != "" {
+		carrier["x-trace"] = trace
+	}
+}
+
+func (r *recordingTracer) snapshot() (emits, deliveries, reasons []string, connections int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.emits...), append([]string(nil), r.deliveries...),
+		append([]string(nil), r.reasons...), r.connections
+}
+
+func Test_givenTracer_whenEmitRequestDelivered_thenSpansCarryTheRequestTrace(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tracer := &recordingTracer{}
+	server, err := ssevents.NewServer(&ssevents.Options{Logger: errorLogger(), Tracer: tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("traced").First().Build())
+	client.Start()
+	for server.SubscriberCount() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("expected the client to connect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/emit", strings.NewReader(`{"event":"traced","data":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace", "trace-1")
+	res := httptest.NewRecorder()
+	server.Handler().ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", res.Code, res.Body.String())
+	}
+	if _, err = observer.WaitForN(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Shutdown()
+	// The connection span ends once the handler returns
+	for _, _, reasons, _ := tracer.snapshot(); len(reasons) == 0; _, _, reasons, _ = tracer.snapshot() {
+		if ctx.Err() != nil {
+			t.Fatal("expected the connection span to end")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	emits, deliveries, reasons, connections := tracer.snapshot()
+	if len(emits) != 1 || emits[0] != "trace-1" {
+		t.Errorf("expected the emit span to continue trace-1, got %v", emits)
+	}
+	if len(deliveries) != 1 || deliveries[0] != "trace-1" {
+		t.Errorf("expected the delivery linked to trace-1, got %v", deliveries)
+	}
+	if connections != 1 || len(reasons) != 1 {
+		t.Errorf("expected a single ended connection span, got %d connections ended with %v", connections, reasons)
+	}
+}
+
+func Test_givenPropagateTraceContext_whenEmitRequestTraced_thenClientContinuesTheTrace(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tracer := otelsse.NewTracer(&otelsse.Options{Propagator: propagation.TraceContext{}})
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger: errorLogger(), Tracer: tracer, PropagateTraceContext: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    errorLogger(),
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown()
+	observer := client.Subscribe(ssevents.NewObserverBuilder().On("traced").First().Build())
+	client.Start()
+	for server.SubscriberCount() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("expected the client to connect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+	req := httptest.NewRequest(http.MethodPost, "/emit", strings.NewReader(`{"event":"traced","data":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-b7ad6b7169203331-01")
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	events, err := observer.WaitForN(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(events[0].Extensions["traceparent"], traceID) {
+		t.Fatalf("expected the traceparent extension of trace %s, got %v", traceID, events[0].Extensions)
+	}
+	spanContext := trace.SpanContextFromContext(tracer.ExtractEvent(context.Background(), events[0]))
+	if spanContext.TraceID().String() != traceID {
+		t.Fatalf("expected the consumer to continue trace %s, got %s", traceID, spanContext.TraceID())
+	}
+}
@@ -13,13 +13,17 @@ type TestBootstrapOptions struct {
 	logger *slog.Logger
 }
 
+func errorLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
 // BootstrapClientAndServer handles boilerplate set up of server and client for testing environment, by default logs
 // only on errors, override logger for debug and info logs.
 func BootstrapClientAndServer(options *TestBootstrapOptions) (
 	*ssevents.Client, *ssevents.Server, func(ctx context.Context) error, error,
 ) {
 	// Errors only logger
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	logger := errorLogger()
 	if options != nil {
 		if options.logger != nil {
 			logger = options.logger
@@ -35,13 +39,11 @@ func BootstrapClientAndServer(options *TestBootstrapOptions) (
 		return nil, nil, nil, fmt.Errorf("failed starting server: %w", err)
 	}
 
-	url, _, err := server.ListenAndServeOnRandomPort()
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed establishing server on a random port: %w", err)
-	}
-
-	// Start client
-	client, err := ssevents.NewSSEClient(url+"/sse", &ssevents.ClientOptions{Logger: logger})
+	// The client is served by the server handler in-process, without listening on a port
+	client, err := ssevents.NewSSEClient("http://ssevents.test/sse", &ssevents.ClientOptions{
+		Logger:    logger,
+		Transport: ssevents.NewHandlerTransport(server.Handler()),
+	})
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed starting client: %w", err)
 	}
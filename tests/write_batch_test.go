@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"github.com/doppelganger113/ssevents"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_givenMaxBatch_whenEventsBurst_thenAllDeliveredInOrder(t *testing.T) {
+	const events = 20
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:     errorLogger(),
+		BufferSize: events,
+		MaxBatch:   8,
+		MaxDelay:   10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	out := make(chan ssevents.Event, events)
+	go func() { _ = ssevents.ReadEvents(ctx, res.Body, out) }()
+
+	// Probe until the subscriber is registered so that no event of the burst is missed
+	for registered := false; !registered; {
+		server.Emit(ssevents.Event{Event: "probe", Data: "probe"})
+		select {
+		case evt := <-out:
+			registered = evt.Event == "probe"
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the subscriber")
+		}
+	}
+
+	for i := 0; i < events; i++ {
+		server.Emit(ssevents.Event{Id: strconv.Itoa(i), Data: "payload"})
+	}
+
+	for expected := 0; expected < events; expected++ {
+		select {
+		case evt := <-out:
+			if evt.Event == "probe" || evt.Event == "heartbeat" {
+				expected--
+				continue
+			}
+			if evt.Id != strconv.Itoa(expected) {
+				t.Fatalf("expected event %d got %s", expected, evt)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for event %d", expected)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doppelganger113/ssevents"
+	"github.com/doppelganger113/ssevents/promsse"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_givenWriteTimeout_whenClientStopsReading_thenStalledConnectionClosed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+	collector, err := promsse.RegisterServerCollector(registry, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	connected := make(chan ssevents.ConnInfo, 1)
+	disconnected := make(chan ssevents.ConnInfo, 1)
+	server, err := ssevents.NewServer(&ssevents.Options{
+		Logger:       errorLogger(),
+		Metrics:      collector,
+		WriteTimeout: 100 * time.Millisecond,
+		BufferSize:   10,
+		EmitStrategy: ssevents.EmitStrategyDrop,
+		OnConnect:    func(info ssevents.ConnInfo) { connected <- info },
+		OnDisconnect: func(info ssevents.ConnInfo) { disconnected <- info },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _, err := server.ListenAndServeOnRandomPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The body is never read, so the socket buffers fill up and the writes stall
+	defer func() { _ = res.Body.Close() }()
+	info := <-connected
+
+	payload := strings.Repeat("x", 64*1024)
+	for closed := false; !closed; {
+		select {
+		case gone := <-disconnected:
+			if gone.ID != info.ID {
+				t.Fatalf("expected connection %s disconnected, got %s", info.ID, gone.ID)
+			}
+			closed = true
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the stalled connection to be closed")
+		default:
+			server.Emit(ssevents.Event{Data: payload})
+		}
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == "test_sse_server_write_timeouts_total" {
+			if value := family.GetMetric()[0].GetCounter().GetValue(); value != 1 {
+				t.Fatalf("expected 1 write timeout, got %v", value)
+			}
+			return
+		}
+	}
+	t.Fatal("write timeouts metric not found")
+}
@@ -0,0 +1,76 @@
+package ssevents
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// topicQueryParam names the query parameter of the SSE url selecting topics, e.g. /sse?topic=orders&topic=payments
+// or /sse?topic=orders,payments
+const topicQueryParam = "topic"
+
+// topicsFromQuery returns the unique topics of the topic query parameter
+func topicsFromQuery(query url.Values) []string {
+	var topics []string
+	for _, value := range query[topicQueryParam] {
+		for _, topic := range strings.Split(value, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" && !slices.Contains(topics, topic) {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics
+}
+
+// Subscribed reports if the connection subscribed to the topic
+func (i ConnInfo) Subscribed(topic string) bool {
+	return slices.Contains(i.Topics, topic)
+}
+
+// EmitTo sends an event only to the connections subscribed to the topic through the topic query parameter, returns the
+// number of connections the event was sent to. Events passed to Emit still reach every connection.
+func (c *HttpController) EmitTo(topic string, e Event) int {
+	n, _ := c.emitTo(topic, e)
+	return n
+}
+
+// emitTo is EmitTo returning the error of the Options ValidateEvent
+func (c *HttpController) emitTo(topic string, e Event) (int, error) {
+	if topic == "" {
+		return 0, nil
+	}
+	return c.emitWhere(e, "topic:"+topic, func(info ConnInfo) bool {
+		return info.Subscribed(topic)
+	})
+}
+
+// EmitTo sends an event to the connections subscribed to the topic, see HttpController.EmitTo
+func (s *Server) EmitTo(topic string, e Event) int {
+	return s.sseCtrl.EmitTo(topic, e)
+}
+
+// eventQueryParam names the query parameter of the SSE url selecting event names, e.g. /sse?event=created,deleted
+const eventQueryParam = "event"
+
+// FilterEventsFromQuery is a SubscriberFilter accepting only the event names listed in the event query parameter,
+// repeated or comma separated, like /sse?event=order-created,order-deleted. Without the parameter all events are
+// accepted.
+func FilterEventsFromQuery(req *http.Request) Filter {
+	var names []string
+	for _, value := range req.URL.Query()[eventQueryParam] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	return func(e Event) bool {
+		return slices.Contains(names, e.Event)
+	}
+}
@@ -0,0 +1,65 @@
+package ssevents
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	DisconnectReasonClientGone     = "client gone"
+	DisconnectReasonShutdown       = "server shutdown"
+	DisconnectReasonWriteFailed    = "write failed"
+	DisconnectReasonHandlerStopped = "handler stopped"
+	DisconnectReasonSlowConsumer   = "slow consumer"
+	DisconnectReasonWriteTimeout   = "write timeout"
+	DisconnectReasonFaultInjected  = "fault injected"
+)
+
+// Tracer creates spans for SSE connections and emitted events, see the otelsse package for an OpenTelemetry
+// implementation. Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Extract returns the context carrying the trace propagated through the headers of an /emit request
+	Extract(ctx context.Context, header http.Header) context.Context
+	// StartConnection starts the span of an SSE connection, end is called with one of the DisconnectReason values
+	StartConnection(ctx context.Context, info ConnInfo) (connCtx context.Context, end func(reason string))
+	// StartEmit starts the span of a single emit, the returned context travels with the event until its delivery
+	StartEmit(ctx context.Context, e Event) (emitCtx context.Context, end func())
+	// Delivered is called once the event was written to the connection
+	Delivered(connCtx, emitCtx context.Context, e Event)
+	// Inject writes the trace context of the ctx into the carrier, e.g. traceparent and tracestate, used for
+	// propagating traces to consumers through the event Extensions when Options PropagateTraceContext is set
+	Inject(ctx context.Context, carrier map[string]string)
+}
+
+// noopTracer is used when no tracer is configured
+type noopTracer struct{}
+
+func (noopTracer) Extract(ctx context.Context, _ http.Header) context.Context {
+	return ctx
+}
+
+func (noopTracer) StartConnection(ctx context.Context, _ ConnInfo) (context.Context, func(reason string)) {
+	return ctx, func(string) {}
+}
+
+func (noopTracer) StartEmit(ctx context.Context, _ Event) (context.Context, func()) {
+	return ctx, func() {}
+}
+
+func (noopTracer) Delivered(_, _ context.Context, _ Event) {}
+
+func (noopTracer) Inject(_ context.Context, _ map[string]string) {}
+
+// Context returns the context the event was emitted with, it carries the emit span when a Tracer is configured.
+func (e Event) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// WithContext returns a copy of the event carrying the ctx.
+func (e Event) WithContext(ctx context.Context) Event {
+	e.ctx = ctx
+	return e
+}
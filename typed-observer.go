@@ -0,0 +1,55 @@
+package ssevents
+
+// TypedObserver receives the data of the events of an Observer decoded by a Codec into T
+type TypedObserver[T any] struct {
+	// EventCh receives the decoded data, it is closed together with the observer
+	EventCh chan T
+	// ErrCh receives the events that failed decoding, an error is dropped when the previous one is still unread
+	ErrCh chan error
+	// Observer is the underlying observer, pass it to Client.Unsubscribe to stop receiving events
+	Observer *Observer
+}
+
+// SubscribeJSON subscribes the observer built by the builder, or of all but heartbeat events when it is nil, and
+// decodes the data of every event it receives from JSON into T. The EventCh buffer matches the one of the observer.
+func SubscribeJSON[T any](c *Client, builder *ObserverBuilder) *TypedObserver[T] {
+	return SubscribeCodec[T](c, builder, JSONCodec)
+}
+
+// SubscribeCodec is SubscribeJSON decoding the data with the codec, e.g. the one the server emits the events with
+// through EmitEncoded
+func SubscribeCodec[T any](c *Client, builder *ObserverBuilder, codec Codec) *TypedObserver[T] {
+	if builder == nil {
+		builder = NewObserverBuilder()
+	}
+	o := c.Subscribe(builder.Build())
+	typed := &TypedObserver[T]{
+		EventCh:  make(chan T, cap(o.EventCh)),
+		ErrCh:    make(chan error, 1),
+		Observer: o,
+	}
+
+	go func() {
+		defer close(typed.ErrCh)
+		defer close(typed.EventCh)
+		for evt := range o.EventCh {
+			var v T
+			if err := DecodeEvent(codec, evt, &v); err != nil {
+				select {
+				case typed.ErrCh <- err:
+				default:
+					c.logger.Error("dropping error, channel full", "err", err)
+				}
+				continue
+			}
+			// An unsubscribed observer whose typed events are no longer read must not leak the goroutine
+			select {
+			case typed.EventCh <- v:
+			case <-o.done:
+				return
+			}
+		}
+	}()
+
+	return typed
+}
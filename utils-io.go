@@ -1,52 +1,75 @@
 package ssevents
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"time"
 )
 
 // ReadEvents - reads, typically, from an HTTP response body, constructs the event and sends it out
-// to the out channel.
+// to the out channel. Events are decoded with a Decoder, use it directly for reading without a channel.
 func ReadEvents(ctx context.Context, reader io.Reader, out chan<- Event) error {
-	scanner := bufio.NewScanner(reader)
-	var event Event
+	_, err := readEvents(ctx, NewDecoder(reader), out, noopClientMetrics{}, nil)
+	return err
+}
+
+// readEvents is ReadEvents of the decoder reporting to the metrics and returning the number of received events. Every
+// event is first passed to intercept, when set, and is not sent out if it returns false.
+func readEvents(
+	ctx context.Context,
+	decoder StreamDecoder,
+	out chan<- Event,
+	metrics ClientMetrics,
+	intercept func(e Event) bool,
+) (int, error) {
+	var received int
 
-	for scanner.Scan() {
+	for ctx.Err() == nil {
+		event, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			return received, nil
+		}
+		if err != nil {
+			return received, fmt.Errorf("error reading stream: %w", err)
+		}
+
+		metrics.EventReceived(event.Event)
+		received++
+		if intercept != nil && !intercept(event) {
+			continue
+		}
 		select {
+		case out <- event:
 		case <-ctx.Done():
-			return nil
-		default:
-			line := scanner.Text()
-			if line == "" {
-				if event.Data != "" {
-					select {
-					case out <- event:
-					case <-ctx.Done():
-						return nil
-					}
-				}
-				event = Event{} // Reset for next event
-				continue
-			}
-
-			if strings.HasPrefix(line, "id: ") {
-				id := strings.TrimPrefix(line, "id: ")
-				event.Id = id
-			} else if strings.HasPrefix(line, "event: ") {
-				evt := strings.TrimPrefix(line, "event: ")
-				event.Event = evt
-			} else if strings.HasPrefix(line, "data: ") {
-				event.Data += strings.TrimPrefix(line, "data: ")
-			}
+			return received, nil
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading SSE stream: %w", err)
+	return received, nil
+}
+
+// idleReader calls onIdle once nothing was read for the timeout, every read of some bytes restarts the wait
+type idleReader struct {
+	reader  io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleReader(reader io.Reader, timeout time.Duration, onIdle func()) *idleReader {
+	return &idleReader{reader: reader, timeout: timeout, timer: time.AfterFunc(timeout, onIdle)}
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
 	}
+	return n, err
+}
 
-	return nil
+// stop releases the timer once the reading is done
+func (r *idleReader) stop() {
+	r.timer.Stop()
 }